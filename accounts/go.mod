@@ -20,6 +20,7 @@ require (
 	github.com/openzipkin/zipkin-go v0.2.2
 	github.com/owncloud/ocis/ocis-pkg v0.0.0-20201103111659-46bf133a3c63
 	github.com/owncloud/ocis/settings v0.0.0-20200918114005-1a0ddd2190ee
+	github.com/owncloud/ocis/store v0.0.0-20200918125107-fcca9faa81c8
 	github.com/prometheus/client_golang v1.7.1
 	github.com/restic/calens v0.2.0
 	github.com/rs/zerolog v1.20.0
@@ -36,5 +37,6 @@ require (
 replace (
 	github.com/owncloud/ocis/ocis-pkg => ../ocis-pkg
 	github.com/owncloud/ocis/settings => ../settings
+	github.com/owncloud/ocis/store => ../store
 	google.golang.org/grpc => google.golang.org/grpc v1.26.0
 )
@@ -41,6 +41,11 @@ type MockAccountsService struct {
 	CreateFunc func(ctx context.Context, in *CreateAccountRequest, opts ...client.CallOption) (*Account, error)
 	UpdateFunc func(ctx context.Context, in *UpdateAccountRequest, opts ...client.CallOption) (*Account, error)
 	DeleteFunc func(ctx context.Context, in *DeleteAccountRequest, opts ...client.CallOption) (*empty.Empty, error)
+
+	RevokeSignInSessionsFunc func(ctx context.Context, in *RevokeSignInSessionsRequest, opts ...client.CallOption) (*Account, error)
+	CreateAppTokenFunc       func(ctx context.Context, in *CreateAppTokenRequest, opts ...client.CallOption) (*CreateAppTokenResponse, error)
+	ListAppTokensFunc        func(ctx context.Context, in *ListAppTokensRequest, opts ...client.CallOption) (*ListAppTokensResponse, error)
+	DeleteAppTokenFunc       func(ctx context.Context, in *DeleteAppTokenRequest, opts ...client.CallOption) (*empty.Empty, error)
 }
 
 // ListAccounts will panic if the function has been called, but not mocked
@@ -87,3 +92,39 @@ func (m MockAccountsService) DeleteAccount(ctx context.Context, in *DeleteAccoun
 
 	panic("DeleteFunc was called in test but not mocked")
 }
+
+// RevokeSignInSessions will panic if the function has been called, but not mocked
+func (m MockAccountsService) RevokeSignInSessions(ctx context.Context, in *RevokeSignInSessionsRequest, opts ...client.CallOption) (*Account, error) {
+	if m.RevokeSignInSessionsFunc != nil {
+		return m.RevokeSignInSessionsFunc(ctx, in, opts...)
+	}
+
+	panic("RevokeSignInSessionsFunc was called in test but not mocked")
+}
+
+// CreateAppToken will panic if the function has been called, but not mocked
+func (m MockAccountsService) CreateAppToken(ctx context.Context, in *CreateAppTokenRequest, opts ...client.CallOption) (*CreateAppTokenResponse, error) {
+	if m.CreateAppTokenFunc != nil {
+		return m.CreateAppTokenFunc(ctx, in, opts...)
+	}
+
+	panic("CreateAppTokenFunc was called in test but not mocked")
+}
+
+// ListAppTokens will panic if the function has been called, but not mocked
+func (m MockAccountsService) ListAppTokens(ctx context.Context, in *ListAppTokensRequest, opts ...client.CallOption) (*ListAppTokensResponse, error) {
+	if m.ListAppTokensFunc != nil {
+		return m.ListAppTokensFunc(ctx, in, opts...)
+	}
+
+	panic("ListAppTokensFunc was called in test but not mocked")
+}
+
+// DeleteAppToken will panic if the function has been called, but not mocked
+func (m MockAccountsService) DeleteAppToken(ctx context.Context, in *DeleteAppTokenRequest, opts ...client.CallOption) (*empty.Empty, error) {
+	if m.DeleteAppTokenFunc != nil {
+		return m.DeleteAppTokenFunc(ctx, in, opts...)
+	}
+
+	panic("DeleteAppTokenFunc was called in test but not mocked")
+}
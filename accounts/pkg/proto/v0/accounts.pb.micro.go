@@ -76,6 +76,34 @@ func NewAccountsServiceEndpoints() []*api.Endpoint {
 			Body:    "*",
 			Handler: "rpc",
 		},
+		&api.Endpoint{
+			Name:    "AccountsService.RevokeSignInSessions",
+			Path:    []string{"/api/v0/accounts/accounts-revoke-sign-in-sessions"},
+			Method:  []string{"POST"},
+			Body:    "*",
+			Handler: "rpc",
+		},
+		&api.Endpoint{
+			Name:    "AccountsService.CreateAppToken",
+			Path:    []string{"/api/v0/accounts/app-tokens-create"},
+			Method:  []string{"POST"},
+			Body:    "*",
+			Handler: "rpc",
+		},
+		&api.Endpoint{
+			Name:    "AccountsService.ListAppTokens",
+			Path:    []string{"/api/v0/accounts/app-tokens-list"},
+			Method:  []string{"POST"},
+			Body:    "*",
+			Handler: "rpc",
+		},
+		&api.Endpoint{
+			Name:    "AccountsService.DeleteAppToken",
+			Path:    []string{"/api/v0/accounts/app-tokens-delete"},
+			Method:  []string{"POST"},
+			Body:    "*",
+			Handler: "rpc",
+		},
 	}
 }
 
@@ -92,6 +120,14 @@ type AccountsService interface {
 	UpdateAccount(ctx context.Context, in *UpdateAccountRequest, opts ...client.CallOption) (*Account, error)
 	// Deletes an account
 	DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...client.CallOption) (*empty.Empty, error)
+	// Signs an account out everywhere by invalidating all sessions and refresh tokens issued until now
+	RevokeSignInSessions(ctx context.Context, in *RevokeSignInSessionsRequest, opts ...client.CallOption) (*Account, error)
+	// Creates an app token for an account. The token is only returned once.
+	CreateAppToken(ctx context.Context, in *CreateAppTokenRequest, opts ...client.CallOption) (*CreateAppTokenResponse, error)
+	// Lists the app tokens of an account
+	ListAppTokens(ctx context.Context, in *ListAppTokensRequest, opts ...client.CallOption) (*ListAppTokensResponse, error)
+	// Deletes an app token of an account
+	DeleteAppToken(ctx context.Context, in *DeleteAppTokenRequest, opts ...client.CallOption) (*empty.Empty, error)
 }
 
 type accountsService struct {
@@ -156,6 +192,46 @@ func (c *accountsService) DeleteAccount(ctx context.Context, in *DeleteAccountRe
 	return out, nil
 }
 
+func (c *accountsService) RevokeSignInSessions(ctx context.Context, in *RevokeSignInSessionsRequest, opts ...client.CallOption) (*Account, error) {
+	req := c.c.NewRequest(c.name, "AccountsService.RevokeSignInSessions", in)
+	out := new(Account)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountsService) CreateAppToken(ctx context.Context, in *CreateAppTokenRequest, opts ...client.CallOption) (*CreateAppTokenResponse, error) {
+	req := c.c.NewRequest(c.name, "AccountsService.CreateAppToken", in)
+	out := new(CreateAppTokenResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountsService) ListAppTokens(ctx context.Context, in *ListAppTokensRequest, opts ...client.CallOption) (*ListAppTokensResponse, error) {
+	req := c.c.NewRequest(c.name, "AccountsService.ListAppTokens", in)
+	out := new(ListAppTokensResponse)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountsService) DeleteAppToken(ctx context.Context, in *DeleteAppTokenRequest, opts ...client.CallOption) (*empty.Empty, error) {
+	req := c.c.NewRequest(c.name, "AccountsService.DeleteAppToken", in)
+	out := new(empty.Empty)
+	err := c.c.Call(ctx, req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for AccountsService service
 
 type AccountsServiceHandler interface {
@@ -169,6 +245,14 @@ type AccountsServiceHandler interface {
 	UpdateAccount(context.Context, *UpdateAccountRequest, *Account) error
 	// Deletes an account
 	DeleteAccount(context.Context, *DeleteAccountRequest, *empty.Empty) error
+	// Signs an account out everywhere by invalidating all sessions and refresh tokens issued until now
+	RevokeSignInSessions(context.Context, *RevokeSignInSessionsRequest, *Account) error
+	// Creates an app token for an account. The token is only returned once.
+	CreateAppToken(context.Context, *CreateAppTokenRequest, *CreateAppTokenResponse) error
+	// Lists the app tokens of an account
+	ListAppTokens(context.Context, *ListAppTokensRequest, *ListAppTokensResponse) error
+	// Deletes an app token of an account
+	DeleteAppToken(context.Context, *DeleteAppTokenRequest, *empty.Empty) error
 }
 
 func RegisterAccountsServiceHandler(s server.Server, hdlr AccountsServiceHandler, opts ...server.HandlerOption) error {
@@ -178,6 +262,10 @@ func RegisterAccountsServiceHandler(s server.Server, hdlr AccountsServiceHandler
 		CreateAccount(ctx context.Context, in *CreateAccountRequest, out *Account) error
 		UpdateAccount(ctx context.Context, in *UpdateAccountRequest, out *Account) error
 		DeleteAccount(ctx context.Context, in *DeleteAccountRequest, out *empty.Empty) error
+		RevokeSignInSessions(ctx context.Context, in *RevokeSignInSessionsRequest, out *Account) error
+		CreateAppToken(ctx context.Context, in *CreateAppTokenRequest, out *CreateAppTokenResponse) error
+		ListAppTokens(ctx context.Context, in *ListAppTokensRequest, out *ListAppTokensResponse) error
+		DeleteAppToken(ctx context.Context, in *DeleteAppTokenRequest, out *empty.Empty) error
 	}
 	type AccountsService struct {
 		accountsService
@@ -218,6 +306,34 @@ func RegisterAccountsServiceHandler(s server.Server, hdlr AccountsServiceHandler
 		Body:    "*",
 		Handler: "rpc",
 	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "AccountsService.RevokeSignInSessions",
+		Path:    []string{"/api/v0/accounts/accounts-revoke-sign-in-sessions"},
+		Method:  []string{"POST"},
+		Body:    "*",
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "AccountsService.CreateAppToken",
+		Path:    []string{"/api/v0/accounts/app-tokens-create"},
+		Method:  []string{"POST"},
+		Body:    "*",
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "AccountsService.ListAppTokens",
+		Path:    []string{"/api/v0/accounts/app-tokens-list"},
+		Method:  []string{"POST"},
+		Body:    "*",
+		Handler: "rpc",
+	}))
+	opts = append(opts, api.WithEndpoint(&api.Endpoint{
+		Name:    "AccountsService.DeleteAppToken",
+		Path:    []string{"/api/v0/accounts/app-tokens-delete"},
+		Method:  []string{"POST"},
+		Body:    "*",
+		Handler: "rpc",
+	}))
 	return s.Handle(s.NewHandler(&AccountsService{h}, opts...))
 }
 
@@ -245,6 +361,22 @@ func (h *accountsServiceHandler) DeleteAccount(ctx context.Context, in *DeleteAc
 	return h.AccountsServiceHandler.DeleteAccount(ctx, in, out)
 }
 
+func (h *accountsServiceHandler) RevokeSignInSessions(ctx context.Context, in *RevokeSignInSessionsRequest, out *Account) error {
+	return h.AccountsServiceHandler.RevokeSignInSessions(ctx, in, out)
+}
+
+func (h *accountsServiceHandler) CreateAppToken(ctx context.Context, in *CreateAppTokenRequest, out *CreateAppTokenResponse) error {
+	return h.AccountsServiceHandler.CreateAppToken(ctx, in, out)
+}
+
+func (h *accountsServiceHandler) ListAppTokens(ctx context.Context, in *ListAppTokensRequest, out *ListAppTokensResponse) error {
+	return h.AccountsServiceHandler.ListAppTokens(ctx, in, out)
+}
+
+func (h *accountsServiceHandler) DeleteAppToken(ctx context.Context, in *DeleteAppTokenRequest, out *empty.Empty) error {
+	return h.AccountsServiceHandler.DeleteAppToken(ctx, in, out)
+}
+
 // Api Endpoints for GroupsService service
 
 func NewGroupsServiceEndpoints() []*api.Endpoint {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/micro/go-micro/v2/client"
@@ -19,6 +20,7 @@ import (
 	svc "github.com/owncloud/ocis/accounts/pkg/service/v0"
 	"github.com/owncloud/ocis/ocis-pkg/service/grpc"
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
+	store "github.com/owncloud/ocis/store/pkg/proto/v0"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -31,6 +33,9 @@ var dataPath = createTmpDir()
 var newCreatedAccounts = []string{}
 var newCreatedGroups = []string{}
 
+// deletedSigningKeys records the users whose signing keys were deleted from the store
+var deletedSigningKeys = map[string]bool{}
+
 var mockedRoleAssignment = map[string]string{}
 
 var (
@@ -82,7 +87,7 @@ func init() {
 	var hdlr *svc.Service
 	var err error
 
-	if hdlr, err = svc.New(svc.Logger(command.NewLogger(cfg)), svc.Config(cfg), svc.RoleService(buildRoleServiceMock())); err != nil {
+	if hdlr, err = svc.New(svc.Logger(command.NewLogger(cfg)), svc.Config(cfg), svc.RoleService(buildRoleServiceMock()), svc.Store(storeMock{})); err != nil {
 		log.Fatalf("Could not create new service")
 	}
 
@@ -205,6 +210,16 @@ func buildRoleServiceMock() settings.RoleService {
 	}
 }
 
+// storeMock only implements the calls the accounts service makes to the store service
+type storeMock struct {
+	store.StoreService
+}
+
+func (storeMock) Delete(ctx context.Context, in *store.DeleteRequest, opts ...client.CallOption) (*store.DeleteResponse, error) {
+	deletedSigningKeys[in.Key] = true
+	return &store.DeleteResponse{}, nil
+}
+
 func cleanUp(t *testing.T) {
 	datastore := filepath.Join(dataPath, "accounts")
 
@@ -1279,3 +1294,112 @@ func TestAccountUpdateReadOnlyField(t *testing.T) {
 
 	cleanUp(t)
 }
+
+func TestAppTokens(t *testing.T) {
+	createAccount(t, "user1")
+	user1 := getAccount("user1")
+	cl := proto.NewAccountsService("com.owncloud.api.accounts", service.Client())
+
+	created, err := cl.CreateAppToken(context.Background(), &proto.CreateAppTokenRequest{
+		AccountId: user1.Id,
+		Label:     "sync client",
+		Scope:     proto.AppTokenScopeReadOnly,
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.Token)
+	assert.Empty(t, created.AppToken.Hash)
+	assert.Equal(t, "sync client", created.AppToken.Label)
+
+	// the token can be used instead of the password
+	res, err := cl.ListAccounts(context.Background(), &proto.ListAccountsRequest{
+		Query: fmt.Sprintf("login eq '%s' and password eq '%s'", user1.PreferredName, created.Token),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, res.Accounts, 1)
+	token := res.Accounts[0].AuthenticatedAppToken()
+	assert.NotNil(t, token)
+	assert.Equal(t, created.AppToken.Id, token.Id)
+	assert.Equal(t, proto.AppTokenScopeReadOnly, token.Scope)
+	assert.NotNil(t, token.LastUsedDateTime)
+
+	// password logins don't return app tokens
+	res, err = cl.ListAccounts(context.Background(), &proto.ListAccountsRequest{
+		Query: fmt.Sprintf("login eq '%s' and password eq '%s'", user1.PreferredName, "heysdjfsdlk"),
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, res.Accounts[0].AuthenticatedAppToken())
+
+	list, err := cl.ListAppTokens(context.Background(), &proto.ListAppTokensRequest{AccountId: user1.Id})
+	assert.NoError(t, err)
+	assert.Len(t, list.AppTokens, 1)
+	assert.Empty(t, list.AppTokens[0].Hash)
+
+	acc, err := cl.GetAccount(context.Background(), &proto.GetAccountRequest{Id: user1.Id})
+	assert.NoError(t, err)
+	assert.Len(t, acc.AppTokens, 1)
+	assert.Empty(t, acc.AppTokens[0].Hash)
+
+	_, err = cl.DeleteAppToken(context.Background(), &proto.DeleteAppTokenRequest{AccountId: user1.Id, Id: created.AppToken.Id})
+	assert.NoError(t, err)
+
+	_, err = cl.ListAccounts(context.Background(), &proto.ListAccountsRequest{
+		Query: fmt.Sprintf("login eq '%s' and password eq '%s'", user1.PreferredName, created.Token),
+	})
+	assert.Error(t, err)
+
+	_, err = cl.DeleteAppToken(context.Background(), &proto.DeleteAppTokenRequest{AccountId: user1.Id, Id: created.AppToken.Id})
+	var e *merrors.Error
+	if errors.As(err, &e) {
+		assert.EqualValues(t, 404, e.Code)
+	} else {
+		t.Fatal("Unexpected error type")
+	}
+
+	cleanUp(t)
+}
+
+func TestCreateAppTokenInvalidScope(t *testing.T) {
+	createAccount(t, "user1")
+	cl := proto.NewAccountsService("com.owncloud.api.accounts", service.Client())
+
+	_, err := cl.CreateAppToken(context.Background(), &proto.CreateAppTokenRequest{
+		AccountId: getAccount("user1").Id,
+		Scope:     "write-only",
+	})
+	var e *merrors.Error
+	if errors.As(err, &e) {
+		assert.EqualValues(t, 400, e.Code)
+	} else {
+		t.Fatal("Unexpected error type")
+	}
+
+	cleanUp(t)
+}
+
+func TestRevokeSignInSessions(t *testing.T) {
+	createAccount(t, "user1")
+	user1 := getAccount("user1")
+	cl := proto.NewAccountsService("com.owncloud.api.accounts", service.Client())
+
+	before := time.Now().Add(-time.Second)
+	acc, err := cl.RevokeSignInSessions(context.Background(), &proto.RevokeSignInSessionsRequest{Id: user1.Id})
+	assert.NoError(t, err)
+	assert.Empty(t, acc.PasswordProfile.Password)
+	assert.True(t, acc.SignInSessionsValidFromDateTime.AsTime().After(before))
+	assert.Equal(t, acc.SignInSessionsValidFromDateTime.AsTime(), acc.RefreshTokensValidFromDateTime.AsTime())
+	assert.True(t, deletedSigningKeys[user1.Id])
+
+	acc, err = cl.GetAccount(context.Background(), &proto.GetAccountRequest{Id: user1.Id})
+	assert.NoError(t, err)
+	assert.True(t, acc.SignInSessionsValidFromDateTime.AsTime().After(before))
+
+	_, err = cl.RevokeSignInSessions(context.Background(), &proto.RevokeSignInSessionsRequest{Id: "no-such-account"})
+	var e *merrors.Error
+	if errors.As(err, &e) {
+		assert.EqualValues(t, 404, e.Code)
+	} else {
+		t.Fatal("Unexpected error type")
+	}
+
+	cleanUp(t)
+}
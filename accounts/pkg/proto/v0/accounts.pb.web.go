@@ -143,6 +143,101 @@ func (h *webAccountsServiceHandler) DeleteAccount(w http.ResponseWriter, r *http
 	render.NoContent(w, r)
 }
 
+func (h *webAccountsServiceHandler) RevokeSignInSessions(w http.ResponseWriter, r *http.Request) {
+
+	req := &RevokeSignInSessionsRequest{}
+
+	resp := &Account{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.RevokeSignInSessions(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webAccountsServiceHandler) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+
+	req := &CreateAppTokenRequest{}
+
+	resp := &CreateAppTokenResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.CreateAppToken(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webAccountsServiceHandler) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+
+	req := &ListAppTokensRequest{}
+
+	resp := &ListAppTokensResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.ListAppTokens(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
+func (h *webAccountsServiceHandler) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+
+	req := &DeleteAppTokenRequest{}
+	resp := &empty.Empty{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.DeleteAppToken(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+	render.NoContent(w, r)
+}
+
 func RegisterAccountsServiceWeb(r chi.Router, i AccountsServiceHandler, middlewares ...func(http.Handler) http.Handler) {
 	handler := &webAccountsServiceHandler{
 		r: r,
@@ -154,6 +249,10 @@ func RegisterAccountsServiceWeb(r chi.Router, i AccountsServiceHandler, middlewa
 	r.MethodFunc("POST", "/api/v0/accounts/accounts-create", handler.CreateAccount)
 	r.MethodFunc("POST", "/api/v0/accounts/accounts-update", handler.UpdateAccount)
 	r.MethodFunc("POST", "/api/v0/accounts/accounts-delete", handler.DeleteAccount)
+	r.MethodFunc("POST", "/api/v0/accounts/accounts-revoke-sign-in-sessions", handler.RevokeSignInSessions)
+	r.MethodFunc("POST", "/api/v0/accounts/app-tokens-create", handler.CreateAppToken)
+	r.MethodFunc("POST", "/api/v0/accounts/app-tokens-list", handler.ListAppTokens)
+	r.MethodFunc("POST", "/api/v0/accounts/app-tokens-delete", handler.DeleteAppToken)
 }
 
 type webGroupsServiceHandler struct {
@@ -702,6 +801,222 @@ func (m *DeleteAccountRequest) UnmarshalJSON(b []byte) error {
 
 var _ json.Unmarshaler = (*DeleteAccountRequest)(nil)
 
+// RevokeSignInSessionsRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of RevokeSignInSessionsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RevokeSignInSessionsRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *RevokeSignInSessionsRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := RevokeSignInSessionsRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*RevokeSignInSessionsRequest)(nil)
+
+// RevokeSignInSessionsRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of RevokeSignInSessionsRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var RevokeSignInSessionsRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *RevokeSignInSessionsRequest) UnmarshalJSON(b []byte) error {
+	return RevokeSignInSessionsRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*RevokeSignInSessionsRequest)(nil)
+
+// CreateAppTokenRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of CreateAppTokenRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CreateAppTokenRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *CreateAppTokenRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := CreateAppTokenRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*CreateAppTokenRequest)(nil)
+
+// CreateAppTokenRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of CreateAppTokenRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CreateAppTokenRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *CreateAppTokenRequest) UnmarshalJSON(b []byte) error {
+	return CreateAppTokenRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*CreateAppTokenRequest)(nil)
+
+// CreateAppTokenResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of CreateAppTokenResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CreateAppTokenResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *CreateAppTokenResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := CreateAppTokenResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*CreateAppTokenResponse)(nil)
+
+// CreateAppTokenResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of CreateAppTokenResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var CreateAppTokenResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *CreateAppTokenResponse) UnmarshalJSON(b []byte) error {
+	return CreateAppTokenResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*CreateAppTokenResponse)(nil)
+
+// ListAppTokensRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListAppTokensRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListAppTokensRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListAppTokensRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListAppTokensRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListAppTokensRequest)(nil)
+
+// ListAppTokensRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListAppTokensRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListAppTokensRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListAppTokensRequest) UnmarshalJSON(b []byte) error {
+	return ListAppTokensRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListAppTokensRequest)(nil)
+
+// ListAppTokensResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of ListAppTokensResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListAppTokensResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *ListAppTokensResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := ListAppTokensResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*ListAppTokensResponse)(nil)
+
+// ListAppTokensResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of ListAppTokensResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var ListAppTokensResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *ListAppTokensResponse) UnmarshalJSON(b []byte) error {
+	return ListAppTokensResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*ListAppTokensResponse)(nil)
+
+// DeleteAppTokenRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of DeleteAppTokenRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteAppTokenRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *DeleteAppTokenRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := DeleteAppTokenRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*DeleteAppTokenRequest)(nil)
+
+// DeleteAppTokenRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of DeleteAppTokenRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var DeleteAppTokenRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *DeleteAppTokenRequest) UnmarshalJSON(b []byte) error {
+	return DeleteAppTokenRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*DeleteAppTokenRequest)(nil)
+
 // AccountJSONMarshaler describes the default jsonpb.Marshaler used by all
 // instances of Account. This struct is safe to replace or modify but
 // should not be done so concurrently.
@@ -738,6 +1053,42 @@ func (m *Account) UnmarshalJSON(b []byte) error {
 
 var _ json.Unmarshaler = (*Account)(nil)
 
+// AppTokenJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of AppToken. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AppTokenJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *AppToken) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := AppTokenJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*AppToken)(nil)
+
+// AppTokenJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of AppToken. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var AppTokenJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *AppToken) UnmarshalJSON(b []byte) error {
+	return AppTokenJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*AppToken)(nil)
+
 // IdentitiesJSONMarshaler describes the default jsonpb.Marshaler used by all
 // instances of Identities. This struct is safe to replace or modify but
 // should not be done so concurrently.
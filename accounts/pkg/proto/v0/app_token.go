@@ -0,0 +1,13 @@
+package proto
+
+// AppTokenScopeReadOnly restricts an app token to requests that do not modify any data.
+const AppTokenScopeReadOnly = "read-only"
+
+// AuthenticatedAppToken returns the app token an account was authenticated with. Accounts returned by an auth query
+// only contain the app token that was used to authenticate, it is nil when the password was used.
+func (x *Account) AuthenticatedAppToken() *AppToken {
+	if x == nil || len(x.AppTokens) != 1 {
+		return nil
+	}
+	return x.AppTokens[0]
+}
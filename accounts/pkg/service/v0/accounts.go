@@ -151,7 +151,23 @@ func (s Service) ListAccounts(ctx context.Context, in *proto.ListAccountsRequest
 
 		a := &proto.Account{}
 		err = s.repo.LoadAccount(ctx, ids[0], a)
-		if err != nil || a.PasswordProfile == nil || len(a.PasswordProfile.Password) == 0 {
+		if err != nil {
+			return merrors.Unauthorized(s.id, "account not found or invalid credentials")
+		}
+
+		// app tokens allow clients to use basic auth without knowing the password, e.g. when the account only
+		// exists in an external idp. Only the token that was used is returned so callers can check its scope.
+		if t := s.authenticateAppToken(ctx, a, password); t != nil {
+			if a.PasswordProfile != nil {
+				a.PasswordProfile.Password = ""
+			}
+			a.AppTokens = []*proto.AppToken{t}
+			out.Accounts = []*proto.Account{a}
+
+			return nil
+		}
+
+		if a.PasswordProfile == nil || len(a.PasswordProfile.Password) == 0 {
 			return merrors.Unauthorized(s.id, "account not found or invalid credentials")
 		}
 
@@ -197,6 +213,7 @@ func (s Service) ListAccounts(ctx context.Context, in *proto.ListAccountsRequest
 		}
 
 		a.PasswordProfile.Password = ""
+		a.AppTokens = nil
 		out.Accounts = []*proto.Account{a}
 
 		return nil
@@ -227,6 +244,7 @@ func (s Service) ListAccounts(ctx context.Context, in *proto.ListAccountsRequest
 			if a.PasswordProfile != nil {
 				a.PasswordProfile.Password = ""
 			}
+			hideAppTokenHashes(a)
 		}
 		return nil
 	}
@@ -254,6 +272,7 @@ func (s Service) ListAccounts(ctx context.Context, in *proto.ListAccountsRequest
 		if a.PasswordProfile != nil {
 			a.PasswordProfile.Password = ""
 		}
+		hideAppTokenHashes(a)
 
 		out.Accounts = append(out.Accounts, a)
 	}
@@ -309,6 +328,7 @@ func (s Service) GetAccount(ctx context.Context, in *proto.GetAccountRequest, ou
 	if out.PasswordProfile != nil {
 		out.PasswordProfile.Password = ""
 	}
+	hideAppTokenHashes(out)
 
 	return
 }
@@ -327,6 +347,9 @@ func (s Service) CreateAccount(ctx context.Context, in *proto.CreateAccountReque
 
 	p.Merge(out, in.Account)
 
+	// app tokens can only be created with CreateAppToken
+	out.AppTokens = nil
+
 	if out.Id == "" {
 		out.Id = uuid.Must(uuid.NewV4()).String()
 	}
@@ -580,6 +603,57 @@ func (s Service) UpdateAccount(ctx context.Context, in *proto.UpdateAccountReque
 	if out.PasswordProfile != nil {
 		out.PasswordProfile.Password = ""
 	}
+	hideAppTokenHashes(out)
+
+	return
+}
+
+// RevokeSignInSessions implements the AccountsServiceHandler interface
+// all sessions and refresh tokens issued before now become invalid, which signs the account out everywhere. The proxy
+// rejects access tokens issued before now, and access tokens whose sign in, taken from their auth_time claim, happened
+// before now, which covers the access tokens obtained with an old refresh token. The signing keys of the account are
+// deleted, so urls signed with them are rejected as well.
+func (s Service) RevokeSignInSessions(ctx context.Context, in *proto.RevokeSignInSessionsRequest, out *proto.Account) (err error) {
+	if !s.hasAccountManagementPermissions(ctx) {
+		return merrors.Forbidden(s.id, "no permission for RevokeSignInSessions")
+	}
+
+	var id string
+	if id, err = cleanupID(in.Id); err != nil {
+		return merrors.InternalServerError(s.id, "could not clean up account id: %v", err.Error())
+	}
+
+	if err = s.repo.LoadAccount(ctx, id, out); err != nil {
+		if storage.IsNotFoundErr(err) {
+			return merrors.NotFound(s.id, "account not found: %v", err.Error())
+		}
+
+		s.log.Error().Err(err).Str("id", id).Msg("could not load account")
+		return merrors.InternalServerError(s.id, "could not load account: %v", err.Error())
+	}
+
+	tsnow := timestamppb.Now()
+	out.SignInSessionsValidFromDateTime = tsnow
+	out.RefreshTokensValidFromDateTime = tsnow
+
+	if err = s.repo.WriteAccount(ctx, out); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not persist revoked sessions")
+		return merrors.InternalServerError(s.id, "could not persist revoked sessions: %v", err.Error())
+	}
+
+	// the keys are created again when the user requests a signing key the next time
+	if err = s.signingKeys.Delete(ctx, id); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not delete signing keys")
+		return merrors.InternalServerError(s.id, "could not delete signing keys: %v", err.Error())
+	}
+
+	s.log.Info().Str("id", id).Msg("revoked sign in sessions")
+
+	// remove password
+	if out.PasswordProfile != nil {
+		out.PasswordProfile.Password = ""
+	}
+	hideAppTokenHashes(out)
 
 	return
 }
@@ -652,6 +726,13 @@ func (s Service) DeleteAccount(ctx context.Context, in *proto.DeleteAccountReque
 		return merrors.InternalServerError(s.id, "could not remove account: %v", err.Error())
 	}
 
+	// the last used timestamps of the app tokens are kept apart from the account
+	for _, t := range a.AppTokens {
+		if err := s.repo.DeleteAppTokenLastUsed(ctx, id, t.Id); err != nil && !storage.IsNotFoundErr(err) {
+			s.log.Error().Err(err).Str("id", id).Str("token", t.Id).Msg("could not delete app token last used timestamp")
+		}
+	}
+
 	if err = s.index.Delete(a); err != nil {
 		s.log.Error().Err(err).Str("id", id).Str("accountId", id).Msg("could not remove account from index")
 		return merrors.InternalServerError(s.id, "could not remove account from index: %v", err.Error())
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/accounts/pkg/storage"
+	"github.com/owncloud/ocis/ocis-pkg/middleware"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// appTokenLastUsedResolution limits how often the last used timestamp of an app token is persisted. Clients
+// authenticate on every request and we don't want to write the account each time.
+const appTokenLastUsedResolution = 5 * time.Minute
+
+// appTokenSeparator separates the token id from the secret
+const appTokenSeparator = "."
+
+// CreateAppToken implements the AccountsServiceHandler interface
+func (s Service) CreateAppToken(ctx context.Context, in *proto.CreateAppTokenRequest, out *proto.CreateAppTokenResponse) (err error) {
+	var id string
+	if id, err = s.checkAppTokenPermissions(ctx, in.AccountId, "CreateAppToken"); err != nil {
+		return err
+	}
+
+	switch in.Scope {
+	case "", proto.AppTokenScopeReadOnly:
+	default:
+		return merrors.BadRequest(s.id, "invalid app token scope: %s", in.Scope)
+	}
+
+	now := time.Now()
+	if in.ExpirationDateTime != nil && !in.ExpirationDateTime.AsTime().After(now) {
+		return merrors.BadRequest(s.id, "app token expiration must be in the future")
+	}
+
+	a := &proto.Account{}
+	if err = s.loadAppTokenAccount(ctx, id, a); err != nil {
+		return err
+	}
+
+	token, secret, err := newAppToken()
+	if err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not generate app token")
+		return merrors.InternalServerError(s.id, "could not generate app token: %v", err.Error())
+	}
+	token.Label = in.Label
+	token.Scope = in.Scope
+	token.CreatedDateTime = timestamppb.New(now)
+	token.ExpirationDateTime = in.ExpirationDateTime
+
+	a.AppTokens = append(a.AppTokens, token)
+	if err = s.repo.WriteAccount(ctx, a); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not persist app token")
+		return merrors.InternalServerError(s.id, "could not persist app token: %v", err.Error())
+	}
+
+	out.AppToken = withoutHash(token)
+	out.Token = secret
+	return nil
+}
+
+// ListAppTokens implements the AccountsServiceHandler interface
+func (s Service) ListAppTokens(ctx context.Context, in *proto.ListAppTokensRequest, out *proto.ListAppTokensResponse) (err error) {
+	var id string
+	if id, err = s.checkAppTokenPermissions(ctx, in.AccountId, "ListAppTokens"); err != nil {
+		return err
+	}
+
+	a := &proto.Account{}
+	if err = s.loadAppTokenAccount(ctx, id, a); err != nil {
+		return err
+	}
+
+	out.AppTokens = make([]*proto.AppToken, 0, len(a.AppTokens))
+	for _, t := range a.AppTokens {
+		token := withoutHash(t)
+		s.loadAppTokenLastUsed(ctx, id, token)
+		out.AppTokens = append(out.AppTokens, token)
+	}
+	return nil
+}
+
+// DeleteAppToken implements the AccountsServiceHandler interface
+func (s Service) DeleteAppToken(ctx context.Context, in *proto.DeleteAppTokenRequest, out *empty.Empty) (err error) {
+	var id string
+	if id, err = s.checkAppTokenPermissions(ctx, in.AccountId, "DeleteAppToken"); err != nil {
+		return err
+	}
+
+	a := &proto.Account{}
+	if err = s.loadAppTokenAccount(ctx, id, a); err != nil {
+		return err
+	}
+
+	tokens := make([]*proto.AppToken, 0, len(a.AppTokens))
+	for _, t := range a.AppTokens {
+		if t.Id != in.Id {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == len(a.AppTokens) {
+		return merrors.NotFound(s.id, "app token not found: %s", in.Id)
+	}
+	a.AppTokens = tokens
+
+	if err = s.repo.WriteAccount(ctx, a); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not delete app token")
+		return merrors.InternalServerError(s.id, "could not delete app token: %v", err.Error())
+	}
+
+	if err = s.repo.DeleteAppTokenLastUsed(ctx, id, in.Id); err != nil && !storage.IsNotFoundErr(err) {
+		s.log.Error().Err(err).Str("id", id).Str("token", in.Id).Msg("could not delete app token last used timestamp")
+	}
+
+	s.log.Info().Str("id", id).Str("token", in.Id).Msg("deleted app token")
+	return nil
+}
+
+// checkAppTokenPermissions returns the cleaned up account id if the app tokens of the account may be managed.
+// Users with the self management permission can only manage their own app tokens.
+func (s Service) checkAppTokenPermissions(ctx context.Context, accountID, method string) (id string, err error) {
+	hasSelf := s.hasSelfManagementPermissions(ctx)
+	hasManagement := s.hasAccountManagementPermissions(ctx)
+	if !hasSelf && !hasManagement {
+		return "", merrors.Forbidden(s.id, "no permission for %s", method)
+	}
+
+	if id, err = cleanupID(accountID); err != nil {
+		return "", merrors.InternalServerError(s.id, "could not clean up account id: %v", err.Error())
+	}
+
+	if hasSelf && !hasManagement {
+		aid, ok := metadata.Get(ctx, middleware.AccountID)
+		if !ok {
+			return "", merrors.InternalServerError(s.id, "account id not in context")
+		}
+		if id != aid {
+			return "", merrors.Forbidden(s.id, "no permission for %s of another user", method)
+		}
+	}
+	return id, nil
+}
+
+func (s Service) loadAppTokenAccount(ctx context.Context, id string, a *proto.Account) error {
+	if err := s.repo.LoadAccount(ctx, id, a); err != nil {
+		if storage.IsNotFoundErr(err) {
+			return merrors.NotFound(s.id, "account not found: %v", err.Error())
+		}
+
+		s.log.Error().Err(err).Str("id", id).Msg("could not load account")
+		return merrors.InternalServerError(s.id, "could not load account: %v", err.Error())
+	}
+	return nil
+}
+
+// authenticateAppToken checks if the password is a valid app token of the account. On success the token without its
+// hash is returned. The last used timestamp is persisted at most every appTokenLastUsedResolution, apart from the
+// account so concurrent updates of the account are not overwritten.
+func (s Service) authenticateAppToken(ctx context.Context, a *proto.Account, password string) *proto.AppToken {
+	id, secret, ok := splitAppToken(password)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	for _, t := range a.AppTokens {
+		if t.Id != id {
+			continue
+		}
+		if !appTokenSecretValid(t, secret) || appTokenExpired(t, now) {
+			return nil
+		}
+
+		token := withoutHash(t)
+		s.loadAppTokenLastUsed(ctx, a.Id, token)
+		if token.LastUsedDateTime == nil || now.Sub(token.LastUsedDateTime.AsTime()) >= appTokenLastUsedResolution {
+			token.LastUsedDateTime = timestamppb.New(now)
+			if err := s.repo.WriteAppTokenLastUsed(ctx, a.Id, t.Id, now); err != nil {
+				// authentication must not fail because the timestamp could not be updated
+				s.log.Error().Err(err).Str("id", a.Id).Str("token", t.Id).Msg("could not persist app token last used timestamp")
+			}
+		}
+		return token
+	}
+	return nil
+}
+
+// loadAppTokenLastUsed sets the last used timestamp of the token. Tokens which were used before the timestamps were
+// kept apart from the account keep the timestamp stored in the account.
+func (s Service) loadAppTokenLastUsed(ctx context.Context, accountID string, t *proto.AppToken) {
+	lastUsed, err := s.repo.LoadAppTokenLastUsed(ctx, accountID, t.Id)
+	if err != nil {
+		if !storage.IsNotFoundErr(err) {
+			s.log.Error().Err(err).Str("id", accountID).Str("token", t.Id).Msg("could not load app token last used timestamp")
+		}
+		return
+	}
+	t.LastUsedDateTime = timestamppb.New(lastUsed)
+}
+
+// newAppToken generates a new app token. The returned secret has the form `<id>.<secret>` and is only handed out
+// once, the token only contains a hash of it.
+func newAppToken() (token *proto.AppToken, secret string, err error) {
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	s := make([]byte, 32)
+	if _, err = rand.Read(s); err != nil {
+		return nil, "", err
+	}
+
+	token = &proto.AppToken{
+		Id:   hex.EncodeToString(id),
+		Hash: hashAppTokenSecret(hex.EncodeToString(s)),
+	}
+	return token, token.Id + appTokenSeparator + hex.EncodeToString(s), nil
+}
+
+// splitAppToken splits a token into its id and secret part.
+func splitAppToken(token string) (id, secret string, ok bool) {
+	parts := strings.SplitN(token, appTokenSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// hashAppTokenSecret hashes the random part of a token. The secret has enough entropy to not require a slow hash
+// like bcrypt, which would slow down every request of a client using basic auth.
+func hashAppTokenSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func appTokenSecretValid(t *proto.AppToken, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashAppTokenSecret(secret))) == 1
+}
+
+func appTokenExpired(t *proto.AppToken, now time.Time) bool {
+	return t.ExpirationDateTime != nil && !now.Before(t.ExpirationDateTime.AsTime())
+}
+
+func withoutHash(t *proto.AppToken) *proto.AppToken {
+	return &proto.AppToken{
+		Id:                 t.Id,
+		Label:              t.Label,
+		Scope:              t.Scope,
+		CreatedDateTime:    t.CreatedDateTime,
+		LastUsedDateTime:   t.LastUsedDateTime,
+		ExpirationDateTime: t.ExpirationDateTime,
+	}
+}
+
+// hideAppTokenHashes removes the hashes of all app tokens of the account before it is returned to clients.
+func hideAppTokenHashes(a *proto.Account) {
+	for i := range a.AppTokens {
+		a.AppTokens[i] = withoutHash(a.AppTokens[i])
+	}
+}
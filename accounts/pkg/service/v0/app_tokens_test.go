@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/accounts/pkg/storage"
+	olog "github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateAppTokenKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(Logger(olog.NewLogger()), Config(tempAccountsConfig(t)), RoleService(roleServiceMock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, secret, err := newAppToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &proto.Account{}
+	assert.NoError(t, s.repo.LoadAccount(ctx, "4c510ada-c86b-4815-8820-42cdf82c3d51", a))
+	a.AppTokens = append(a.AppTokens, token)
+	assert.NoError(t, s.repo.WriteAccount(ctx, a))
+
+	// the account is changed after it was loaded for authentication
+	stale := &proto.Account{}
+	assert.NoError(t, s.repo.LoadAccount(ctx, a.Id, stale))
+	a.DisplayName = "Albert"
+	assert.NoError(t, s.repo.WriteAccount(ctx, a))
+
+	authenticated := s.authenticateAppToken(ctx, stale, secret)
+	if assert.NotNil(t, authenticated) {
+		assert.NotNil(t, authenticated.LastUsedDateTime)
+	}
+
+	current := &proto.Account{}
+	assert.NoError(t, s.repo.LoadAccount(ctx, a.Id, current))
+	assert.Equal(t, "Albert", current.DisplayName)
+
+	list := &proto.ListAppTokensResponse{}
+	assert.NoError(t, s.ListAppTokens(ctx, &proto.ListAppTokensRequest{AccountId: a.Id}, list))
+	if assert.Len(t, list.AppTokens, 1) {
+		assert.Equal(t, authenticated.LastUsedDateTime.AsTime(), list.AppTokens[0].LastUsedDateTime.AsTime())
+	}
+}
+
+func TestDeleteAccountDeletesAppTokensLastUsed(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(Logger(olog.NewLogger()), Config(tempAccountsConfig(t)), RoleService(roleServiceMock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := newAppToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &proto.Account{}
+	assert.NoError(t, s.repo.LoadAccount(ctx, "4c510ada-c86b-4815-8820-42cdf82c3d51", a))
+	a.AppTokens = append(a.AppTokens, token)
+	assert.NoError(t, s.repo.WriteAccount(ctx, a))
+	assert.NoError(t, s.repo.WriteAppTokenLastUsed(ctx, a.Id, token.Id, time.Now()))
+
+	assert.NoError(t, s.DeleteAccount(ctx, &proto.DeleteAccountRequest{Id: a.Id}, &empty.Empty{}))
+	_, err = s.repo.LoadAppTokenLastUsed(ctx, a.Id, token.Id)
+	assert.True(t, storage.IsNotFoundErr(err))
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/owncloud/ocis/accounts/pkg/config"
+	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/accounts/pkg/storage"
+	"github.com/owncloud/ocis/ocis-pkg/backup"
+	"github.com/owncloud/ocis/ocis-pkg/indexer"
+)
+
+// BackupSource backs up the accounts and groups of the configured repo, disk or cs3, in the layout of the disk repo.
+// The indexes aren't backed up, they are rebuilt when the accounts are restored.
+type BackupSource struct {
+	options Options
+}
+
+// NewBackupSource returns the backup source of the accounts service.
+func NewBackupSource(opts ...Option) backup.Source {
+	return BackupSource{options: newOptions(opts...)}
+}
+
+// Name implements the backup.Source interface.
+func (b BackupSource) Name() string {
+	return "accounts"
+}
+
+// Export implements the backup.Source interface.
+func (b BackupSource) Export(dir string) error {
+	return copyRepo(createMetadataStorage(b.options.Config, b.options.Logger), b.diskRepo(dir))
+}
+
+// CheckEmpty implements the backup.Source interface.
+func (b BackupSource) CheckEmpty() error {
+	return checkEmpty(context.Background(), createMetadataStorage(b.options.Config, b.options.Logger))
+}
+
+// Import implements the backup.Source interface.
+func (b BackupSource) Import(dir string) error {
+	ctx := context.Background()
+	repo := createMetadataStorage(b.options.Config, b.options.Logger)
+	if err := checkEmpty(ctx, repo); err != nil {
+		return err
+	}
+
+	if err := copyRepo(b.diskRepo(dir), repo); err != nil {
+		return err
+	}
+
+	indexcfg, err := configFromSvc(b.options.Config)
+	if err != nil {
+		return err
+	}
+	// remove indexes left behind by an earlier instance, like RebuildIndex does
+	idx := indexer.CreateIndexer(indexcfg)
+	if err := recreateContainers(idx, indexcfg); err != nil {
+		return err
+	}
+	if err := idx.Reset(); err != nil {
+		return err
+	}
+	if err := recreateContainers(idx, indexcfg); err != nil {
+		return err
+	}
+	return reindexDocuments(ctx, repo, idx)
+}
+
+// diskRepo returns a disk repo keeping the accounts and groups in dir.
+func (b BackupSource) diskRepo(dir string) storage.Repo {
+	cfg := config.New()
+	cfg.Repo.Disk.Path = dir
+	return storage.NewDiskRepo(cfg, b.options.Logger)
+}
+
+func checkEmpty(ctx context.Context, repo storage.Repo) error {
+	accounts := make([]*proto.Account, 0)
+	if err := repo.LoadAccounts(ctx, &accounts); err != nil {
+		return err
+	}
+	groups := make([]*proto.Group, 0)
+	if err := repo.LoadGroups(ctx, &groups); err != nil {
+		return err
+	}
+	if len(accounts) > 0 || len(groups) > 0 {
+		return errors.New("the accounts service already has accounts or groups")
+	}
+	return nil
+}
+
+// copyRepo writes all accounts and groups of one repo to another, with the last used timestamps of the app tokens.
+func copyRepo(from, to storage.Repo) error {
+	ctx := context.Background()
+	accounts := make([]*proto.Account, 0)
+	if err := from.LoadAccounts(ctx, &accounts); err != nil {
+		return err
+	}
+	for i := range accounts {
+		if err := to.WriteAccount(ctx, accounts[i]); err != nil {
+			return err
+		}
+		if err := copyAppTokensLastUsed(ctx, from, to, accounts[i]); err != nil {
+			return err
+		}
+	}
+
+	groups := make([]*proto.Group, 0)
+	if err := from.LoadGroups(ctx, &groups); err != nil {
+		return err
+	}
+	for i := range groups {
+		if err := to.WriteGroup(ctx, groups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyAppTokensLastUsed writes the last used timestamps of the app tokens of an account, which are kept apart from
+// the account, to another repo. Tokens which were never used have no timestamp.
+func copyAppTokensLastUsed(ctx context.Context, from, to storage.Repo, a *proto.Account) error {
+	for _, t := range a.AppTokens {
+		lastUsed, err := from.LoadAppTokenLastUsed(ctx, a.Id, t.Id)
+		if storage.IsNotFoundErr(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := to.WriteAppTokenLastUsed(ctx, a.Id, t.Id, lastUsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/accounts/pkg/config"
+	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/accounts/pkg/storage"
+	"github.com/owncloud/ocis/ocis-pkg/backup"
+	"github.com/owncloud/ocis/ocis-pkg/indexer"
+	olog "github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempAccountsConfig(t *testing.T) *config.Config {
+	dir, err := ioutil.TempDir("", "accounts-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := config.New()
+	cfg.Server.Name = "accounts"
+	cfg.Repo.Disk.Path = dir
+	cfg.Index.GID.Lower = 1000
+	cfg.Index.GID.Upper = 1000
+	return cfg
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	logger := olog.NewLogger()
+	cfg := tempAccountsConfig(t)
+	// creates the default accounts and groups
+	_, err := New(Logger(logger), Config(cfg), RoleService(roleServiceMock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last used timestamps of app tokens are kept apart from the accounts
+	repo := storage.NewDiskRepo(cfg, logger)
+	einstein := &proto.Account{}
+	if err := repo.LoadAccount(context.Background(), "4c510ada-c86b-4815-8820-42cdf82c3d51", einstein); err != nil {
+		t.Fatal(err)
+	}
+	einstein.AppTokens = []*proto.AppToken{{Id: "used"}, {Id: "unused"}}
+	if err := repo.WriteAccount(context.Background(), einstein); err != nil {
+		t.Fatal(err)
+	}
+	lastUsed := time.Date(2021, 1, 25, 11, 46, 36, 0, time.UTC)
+	if err := repo.WriteAppTokenLastUsed(context.Background(), einstein.Id, "used", lastUsed); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := &bytes.Buffer{}
+	_, err = backup.Create(archive, "test", NewBackupSource(Logger(logger), Config(cfg)))
+	assert.NoError(t, err)
+
+	restoredCfg := tempAccountsConfig(t)
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), NewBackupSource(Logger(logger), Config(restoredCfg)))
+	assert.NoError(t, err)
+
+	repo = storage.NewDiskRepo(restoredCfg, logger)
+	einstein = &proto.Account{}
+	assert.NoError(t, repo.LoadAccount(context.Background(), "4c510ada-c86b-4815-8820-42cdf82c3d51", einstein))
+	assert.Equal(t, "einstein@example.org", einstein.Mail)
+	assert.NotEmpty(t, einstein.MemberOf)
+	restoredLastUsed, err := repo.LoadAppTokenLastUsed(context.Background(), einstein.Id, "used")
+	assert.NoError(t, err)
+	assert.True(t, lastUsed.Equal(restoredLastUsed))
+	_, err = repo.LoadAppTokenLastUsed(context.Background(), einstein.Id, "unused")
+	assert.True(t, storage.IsNotFoundErr(err))
+	groups := make([]*proto.Group, 0)
+	assert.NoError(t, repo.LoadGroups(context.Background(), &groups))
+	assert.NotEmpty(t, groups)
+
+	// the indexes were rebuilt
+	indexcfg, err := configFromSvc(restoredCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := indexer.CreateIndexer(indexcfg)
+	assert.NoError(t, recreateContainers(idx, indexcfg))
+	ids, err := idx.FindBy(&proto.Account{}, "Mail", "einstein@example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{einstein.Id}, ids)
+
+	// the accounts service already has accounts
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), NewBackupSource(Logger(logger), Config(restoredCfg)))
+	assert.Error(t, err)
+}
@@ -22,6 +22,7 @@ func (s Service) expandMembers(g *proto.Group) {
 		// TODO resolve by name, when a create or update is issued they may not have an id? fall back to searching the group id in the index?
 		a := &proto.Account{}
 		if err := s.repo.LoadAccount(context.Background(), g.Members[i].Id, a); err == nil {
+			hideAppTokenHashes(a)
 			expanded = append(expanded, a)
 		} else {
 			// log errors but con/var/tmp/ocis-accounts-store-408341811tinue execution for now
@@ -5,6 +5,7 @@ import (
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/ocis-pkg/roles"
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
+	store "github.com/owncloud/ocis/store/pkg/proto/v0"
 )
 
 // Option defines a single option function.
@@ -16,6 +17,7 @@ type Options struct {
 	Config      *config.Config
 	RoleService settings.RoleService
 	RoleManager *roles.Manager
+	Store       store.StoreService
 }
 
 func newOptions(opts ...Option) Options {
@@ -55,3 +57,10 @@ func RoleManager(val *roles.Manager) Option {
 		o.RoleManager = val
 	}
 }
+
+// Store provides a function to set the Store option.
+func Store(val store.StoreService) Option {
+	return func(o *Options) {
+		o.Store = val
+	}
+}
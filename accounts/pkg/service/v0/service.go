@@ -22,6 +22,8 @@ import (
 	"github.com/owncloud/ocis/ocis-pkg/roles"
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
 	settings_svc "github.com/owncloud/ocis/settings/pkg/service/v0"
+	store "github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/signingkeys"
 )
 
 // userDefaultGID is the default integer representing the "users" group.
@@ -47,6 +49,10 @@ func New(opts ...Option) (s *Service, err error) {
 		)
 		roleManager = &m
 	}
+	storeService := options.Store
+	if storeService == nil {
+		storeService = store.NewStoreService("com.owncloud.api.store", grpc.DefaultClient)
+	}
 
 	s = &Service{
 		id:          cfg.GRPC.Namespace + "." + cfg.Server.Name,
@@ -55,6 +61,7 @@ func New(opts ...Option) (s *Service, err error) {
 		RoleService: roleService,
 		RoleManager: roleManager,
 		repo:        createMetadataStorage(cfg, logger),
+		signingKeys: signingkeys.New(storeService),
 	}
 
 	if s.index, err = s.buildIndex(); err != nil {
@@ -425,6 +432,7 @@ type Service struct {
 	RoleService settings.RoleService
 	RoleManager *roles.Manager
 	repo        storage.Repo
+	signingKeys signingkeys.Keyrings
 }
 
 func cleanupID(id string) (string, error) {
@@ -74,3 +74,49 @@ func (t tracing) DeleteAccount(ctx context.Context, req *v0proto.DeleteAccountRe
 	}, "Execute Accounts.DeleteAccout handler")
 	return t.next.DeleteAccount(ctx, req, e)
 }
+
+func (t tracing) RevokeSignInSessions(ctx context.Context, req *v0proto.RevokeSignInSessionsRequest, acc *v0proto.Account) error {
+	ctx, span := trace.StartSpan(ctx, "Accounts.RevokeSignInSessions")
+	defer span.End()
+
+	span.Annotate([]trace.Attribute{
+		trace.StringAttribute("id", req.Id),
+	}, "Execute Accounts.RevokeSignInSessions handler")
+
+	return t.next.RevokeSignInSessions(ctx, req, acc)
+}
+
+func (t tracing) CreateAppToken(ctx context.Context, req *v0proto.CreateAppTokenRequest, rsp *v0proto.CreateAppTokenResponse) error {
+	ctx, span := trace.StartSpan(ctx, "Accounts.CreateAppToken")
+	defer span.End()
+
+	span.Annotate([]trace.Attribute{
+		trace.StringAttribute("account_id", req.AccountId),
+		trace.StringAttribute("scope", req.Scope),
+	}, "Execute Accounts.CreateAppToken handler")
+
+	return t.next.CreateAppToken(ctx, req, rsp)
+}
+
+func (t tracing) ListAppTokens(ctx context.Context, req *v0proto.ListAppTokensRequest, rsp *v0proto.ListAppTokensResponse) error {
+	ctx, span := trace.StartSpan(ctx, "Accounts.ListAppTokens")
+	defer span.End()
+
+	span.Annotate([]trace.Attribute{
+		trace.StringAttribute("account_id", req.AccountId),
+	}, "Execute Accounts.ListAppTokens handler")
+
+	return t.next.ListAppTokens(ctx, req, rsp)
+}
+
+func (t tracing) DeleteAppToken(ctx context.Context, req *v0proto.DeleteAppTokenRequest, e *empty.Empty) error {
+	ctx, span := trace.StartSpan(ctx, "Accounts.DeleteAppToken")
+	defer span.End()
+
+	span.Annotate([]trace.Attribute{
+		trace.StringAttribute("account_id", req.AccountId),
+		trace.StringAttribute("id", req.Id),
+	}, "Execute Accounts.DeleteAppToken handler")
+
+	return t.next.DeleteAppToken(ctx, req, e)
+}
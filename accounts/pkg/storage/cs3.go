@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	v1beta11 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
@@ -288,6 +289,75 @@ func (r CS3Repo) DeleteGroup(ctx context.Context, id string) (err error) {
 	return err
 }
 
+// WriteAppTokenLastUsed writes the last used timestamp of an app token via cs3
+func (r CS3Repo) WriteAppTokenLastUsed(ctx context.Context, accountID, tokenID string, t time.Time) (err error) {
+	tkn, err := r.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn)
+	if err := r.makeRootDirIfNotExist(ctx, appTokensFolder); err != nil {
+		return err
+	}
+
+	resp, err := r.dataProvider.put(r.appTokenURL(accountID, tokenID), strings.NewReader(t.UTC().Format(time.RFC3339Nano)), tkn)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// LoadAppTokenLastUsed loads the last used timestamp of an app token via cs3
+func (r CS3Repo) LoadAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (t time.Time, err error) {
+	tkn, err := r.authenticate(ctx)
+	if err != nil {
+		return t, err
+	}
+
+	resp, err := r.dataProvider.get(r.appTokenURL(accountID, tokenID), tkn)
+	if err != nil {
+		return t, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return t, &notFoundErr{"app token", tokenID}
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return t, err
+	}
+	return time.Parse(time.RFC3339Nano, string(b))
+}
+
+// DeleteAppTokenLastUsed deletes the last used timestamp of an app token via cs3
+func (r CS3Repo) DeleteAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (err error) {
+	t, err := r.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, token.TokenHeader, t)
+
+	resp, err := r.storageProvider.Delete(ctx, &provider.DeleteRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: path.Join("/meta", appTokensFolder, appTokenName(accountID, tokenID))},
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if resp.Status.Code == v1beta11.Code_CODE_NOT_FOUND {
+		return &notFoundErr{"app token", tokenID}
+	}
+
+	return nil
+}
+
 func (r CS3Repo) authenticate(ctx context.Context) (token string, err error) {
 	return AuthenticateCS3(ctx, r.cfg.ServiceUser, r.tm)
 }
@@ -323,6 +393,10 @@ func (r CS3Repo) groupURL(id string) string {
 	return singleJoiningSlash(r.cfg.Repo.CS3.DataURL, path.Join(r.cfg.Repo.CS3.DataPrefix, groupsFolder, id))
 }
 
+func (r CS3Repo) appTokenURL(accountID, tokenID string) string {
+	return singleJoiningSlash(r.cfg.Repo.CS3.DataURL, path.Join(r.cfg.Repo.CS3.DataPrefix, appTokensFolder, appTokenName(accountID, tokenID)))
+}
+
 func (r CS3Repo) makeRootDirIfNotExist(ctx context.Context, folder string) error {
 	return MakeDirIfNotExist(ctx, r.storageProvider, folder)
 }
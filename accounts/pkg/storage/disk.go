@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/owncloud/ocis/accounts/pkg/config"
 	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
@@ -26,6 +27,7 @@ func NewDiskRepo(cfg *config.Config, log olog.Logger) DiskRepo {
 	paths := []string{
 		filepath.Join(cfg.Repo.Disk.Path, accountsFolder),
 		filepath.Join(cfg.Repo.Disk.Path, groupsFolder),
+		filepath.Join(cfg.Repo.Disk.Path, appTokensFolder),
 	}
 	for i := range paths {
 		if _, err := os.Stat(paths[i]); err != nil {
@@ -166,6 +168,38 @@ func (r DiskRepo) DeleteGroup(ctx context.Context, id string) (err error) {
 	return
 }
 
+// WriteAppTokenLastUsed to the local filesystem
+func (r DiskRepo) WriteAppTokenLastUsed(ctx context.Context, accountID, tokenID string, t time.Time) (err error) {
+	path := filepath.Join(r.cfg.Repo.Disk.Path, appTokensFolder, appTokenName(accountID, tokenID))
+	return ioutil.WriteFile(path, []byte(t.UTC().Format(time.RFC3339Nano)), 0600)
+}
+
+// LoadAppTokenLastUsed from the local filesystem
+func (r DiskRepo) LoadAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (t time.Time, err error) {
+	path := filepath.Join(r.cfg.Repo.Disk.Path, appTokensFolder, appTokenName(accountID, tokenID))
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = &notFoundErr{"app token", tokenID}
+		}
+		return
+	}
+
+	return time.Parse(time.RFC3339Nano, string(data))
+}
+
+// DeleteAppTokenLastUsed from the local filesystem
+func (r DiskRepo) DeleteAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (err error) {
+	path := filepath.Join(r.cfg.Repo.Disk.Path, appTokensFolder, appTokenName(accountID, tokenID))
+	if err = os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			err = &notFoundErr{"app token", tokenID}
+		}
+	}
+
+	return
+}
+
 // deflateMemberOf replaces the groups of a user with an instance that only contains the id
 func (r DiskRepo) deflateMemberOf(a *proto.Account) {
 	if a == nil {
@@ -2,13 +2,15 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/accounts/pkg/proto/v0"
 )
 
 const (
-	accountsFolder = "accounts"
-	groupsFolder   = "groups"
+	accountsFolder  = "accounts"
+	groupsFolder    = "groups"
+	appTokensFolder = "app-tokens"
 )
 
 // Repo defines the storage operations
@@ -21,4 +23,14 @@ type Repo interface {
 	LoadGroup(ctx context.Context, id string, g *proto.Group) (err error)
 	LoadGroups(ctx context.Context, g *[]*proto.Group) (err error)
 	DeleteGroup(ctx context.Context, id string) (err error)
+	// The last used timestamps of app tokens are kept apart from the accounts, so updating them on authentication
+	// doesn't overwrite concurrent changes of the account.
+	WriteAppTokenLastUsed(ctx context.Context, accountID, tokenID string, t time.Time) (err error)
+	LoadAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (t time.Time, err error)
+	DeleteAppTokenLastUsed(ctx context.Context, accountID, tokenID string) (err error)
+}
+
+// appTokenName returns the name of the file keeping the last used timestamp of an app token
+func appTokenName(accountID, tokenID string) string {
+	return accountID + "." + tokenID
 }
@@ -103,6 +103,17 @@ func (h ocisHandler) Bind(bindDN, bindSimplePw string, conn net.Conn) (ldap.LDAP
 		return ldap.LDAPResultInvalidCredentials, nil
 	}
 
+	// app tokens can be used to bind, but a bind grants full access to the account
+	if t := res.Accounts[0].AuthenticatedAppToken(); t != nil && t.Scope == accounts.AppTokenScopeReadOnly {
+		h.log.Error().
+			Str("handler", "ocis").
+			Str("username", userName).
+			Str("binddn", bindDN).
+			Interface("src", conn.RemoteAddr()).
+			Msg("Login failed, read-only app tokens can not be used to bind")
+		return ldap.LDAPResultInvalidCredentials, nil
+	}
+
 	stats.Frontend.Add("bind_successes", 1)
 	h.log.Debug().
 		Str("handler", "ocis").
@@ -0,0 +1,315 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/micro/go-micro/v2/registry"
+)
+
+// FormatVersion is the current version of the archive format. Archives of a newer version can't be restored.
+const FormatVersion = 1
+
+// manifestName is the name of the manifest, it is the first entry of an archive.
+const manifestName = "manifest.json"
+
+// Source is the data of a service that is backed up.
+type Source interface {
+	// Name of the source, the exported files are kept in a directory of that name in the archive.
+	Name() string
+	// Export writes a snapshot of the data to the empty directory dir.
+	Export(dir string) error
+	// CheckEmpty returns an error if the service already has data which Import would refuse to overwrite.
+	CheckEmpty() error
+	// Import restores the data exported to dir. It fails if the service already has data.
+	Import(dir string) error
+}
+
+// Manifest describes the content of an archive.
+type Manifest struct {
+	Version     int       `json:"version"`
+	Created     time.Time `json:"created"`
+	OcisVersion string    `json:"ocis_version"`
+	// Sources are the names of the backed up sources.
+	Sources []string `json:"sources"`
+	Files   []File   `json:"files"`
+}
+
+// File is a file of an archive.
+type File struct {
+	// Path is the slash separated path of the file in the archive, it starts with the name of its source.
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Create exports the sources and writes them to w as a gzipped tar archive. The manifest with the checksums of all
+// files is written first, so an archive can be verified while it is read.
+func Create(w io.Writer, ocisVersion string, sources ...Source) (*Manifest, error) {
+	staging, err := ioutil.TempDir("", "ocis-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	m := &Manifest{
+		Version:     FormatVersion,
+		Created:     time.Now().UTC(),
+		OcisVersion: ocisVersion,
+		Sources:     []string{},
+		Files:       []File{},
+	}
+	for _, s := range sources {
+		if err := validPath(s.Name()); err != nil || strings.Contains(s.Name(), "/") {
+			return nil, fmt.Errorf("invalid source name %q", s.Name())
+		}
+		dir := filepath.Join(staging, s.Name())
+		if err := os.Mkdir(dir, 0700); err != nil {
+			return nil, err
+		}
+		if err := s.Export(dir); err != nil {
+			return nil, fmt.Errorf("could not export %s: %w", s.Name(), err)
+		}
+		m.Sources = append(m.Sources, s.Name())
+	}
+
+	err = filepath.Walk(staging, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		sum, err := checksum(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, p)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, File{Path: filepath.ToSlash(rel), Size: info.Size(), SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestName, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return nil, err
+	}
+	for _, f := range m.Files {
+		if err := writeFile(tw, f, filepath.Join(staging, filepath.FromSlash(f.Path))); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return m, gw.Close()
+}
+
+// Verify reads an archive and checks that it contains exactly the files of its manifest with the right checksums.
+func Verify(r io.Reader) (*Manifest, error) {
+	return read(r, func(f File, content io.Reader) error {
+		_, err := io.Copy(ioutil.Discard, content)
+		return err
+	})
+}
+
+// Restore verifies an archive and imports it into the sources. Nothing is imported if the archive is damaged, lacks
+// the data of one of the sources or one of the sources already has data.
+func Restore(r io.Reader, sources ...Source) (*Manifest, error) {
+	for _, s := range sources {
+		if err := s.CheckEmpty(); err != nil {
+			return nil, fmt.Errorf("could not restore %s: %w", s.Name(), err)
+		}
+	}
+
+	staging, err := ioutil.TempDir("", "ocis-restore")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	m, err := read(r, func(f File, content io.Reader) error {
+		p := filepath.Join(staging, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		return copyFile(p, content)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sources {
+		if !contains(m.Sources, s.Name()) {
+			return nil, fmt.Errorf("the archive contains no %s data", s.Name())
+		}
+	}
+	for _, s := range sources {
+		dir := filepath.Join(staging, s.Name())
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+		if err := s.Import(dir); err != nil {
+			return m, fmt.Errorf("could not import %s: %w", s.Name(), err)
+		}
+	}
+	return m, nil
+}
+
+// CheckStopped returns an error if one of the services is registered in the registry. The data of running services
+// can change while it is backed up or restored.
+func CheckStopped(r registry.Registry, services ...string) error {
+	for _, name := range services {
+		instances, err := r.GetService(name)
+		if err != nil && err != registry.ErrNotFound {
+			return fmt.Errorf("could not check if %s is running: %w", name, err)
+		}
+		if len(instances) > 0 {
+			return fmt.Errorf("%s is running, stop the services first", name)
+		}
+	}
+	return nil
+}
+
+// read reads the manifest of an archive and passes the content of every file to fn. The checksums are compared after
+// fn consumed the content, so callers must not use the files before read returned without error.
+func read(r io.Reader, fn func(f File, content io.Reader) error) (*Manifest, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	tr := tar.NewReader(gr)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, fmt.Errorf("not a backup archive: missing manifest")
+	}
+	m := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version > FormatVersion {
+		return nil, fmt.Errorf("archive version %d is newer than the supported version %d", m.Version, FormatVersion)
+	}
+
+	files := make(map[string]File, len(m.Files))
+	for _, f := range m.Files {
+		if err := validPath(f.Path); err != nil {
+			return nil, err
+		}
+		files[f.Path] = f
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		f, ok := files[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("unexpected file %s", hdr.Name)
+		}
+		delete(files, hdr.Name)
+
+		h := sha256.New()
+		if err := fn(f, io.TeeReader(tr, h)); err != nil {
+			return nil, err
+		}
+		if hdr.Size != f.Size || hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s", f.Path)
+		}
+	}
+	for p := range files {
+		return nil, fmt.Errorf("missing file %s", p)
+	}
+	return m, nil
+}
+
+// validPath checks that a path of the archive can't escape the directory it is extracted to.
+func validPath(p string) error {
+	if p == "" || p == "." || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") || strings.Contains(p, "\\") {
+		return fmt.Errorf("invalid path %q", p)
+	}
+	return nil
+}
+
+func writeFile(tw *tar.Writer, f File, p string) error {
+	fh, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	return writeEntry(tw, f.Path, f.Size, fh)
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, content io.Reader) error {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0600,
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, content, size)
+	return err
+}
+
+func checksum(p string) (string, error) {
+	fh, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fh); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile writes content to a new file, it fails if the file exists.
+func copyFile(p string, content io.Reader) error {
+	fh, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fh, content); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
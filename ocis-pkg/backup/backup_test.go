@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/micro/go-micro/v2/registry"
+	"github.com/micro/go-micro/v2/registry/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "backup-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func writeTestFile(t *testing.T, p, content string) {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, p string) string {
+	data, err := ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	src := tempDir(t)
+	writeTestFile(t, filepath.Join(src, "settings", "bundles", "b1.json"), "bundle")
+	writeTestFile(t, filepath.Join(src, "settings", "values", "v1.json"), "value")
+	writeTestFile(t, filepath.Join(src, "idp", "registration.yaml"), "clients: []")
+
+	archive := &bytes.Buffer{}
+	m, err := Create(archive, "1.0.0",
+		Dir("settings", filepath.Join(src, "settings")),
+		Dir("idp-validation-keys", filepath.Join(src, "missing")),
+		Files("idp", map[string]string{
+			"identifier-registration.yaml": filepath.Join(src, "idp", "registration.yaml"),
+			"encryption-secret":            filepath.Join(src, "idp", "secret"),
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, FormatVersion, m.Version)
+	assert.Equal(t, []string{"settings", "idp-validation-keys", "idp"}, m.Sources)
+	assert.Len(t, m.Files, 3)
+
+	verified, err := Verify(bytes.NewReader(archive.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, m.Files, verified.Files)
+
+	dst := tempDir(t)
+	_, err = Restore(bytes.NewReader(archive.Bytes()),
+		Dir("settings", filepath.Join(dst, "settings")),
+		Dir("idp-validation-keys", filepath.Join(dst, "idp-validation-keys")),
+		Files("idp", map[string]string{
+			"identifier-registration.yaml": filepath.Join(dst, "idp", "registration.yaml"),
+			"encryption-secret":            filepath.Join(dst, "idp", "secret"),
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "bundle", readTestFile(t, filepath.Join(dst, "settings", "bundles", "b1.json")))
+	assert.Equal(t, "value", readTestFile(t, filepath.Join(dst, "settings", "values", "v1.json")))
+	assert.Equal(t, "clients: []", readTestFile(t, filepath.Join(dst, "idp", "registration.yaml")))
+	assert.NoFileExists(t, filepath.Join(dst, "idp", "secret"))
+	assert.NoFileExists(t, filepath.Join(dst, "settings", "bundles", "link"))
+}
+
+func TestRestoreRequiresEmptyDir(t *testing.T) {
+	src := tempDir(t)
+	writeTestFile(t, filepath.Join(src, "b1.json"), "bundle")
+	archive := &bytes.Buffer{}
+	_, err := Create(archive, "1.0.0", Dir("settings", src))
+	assert.NoError(t, err)
+
+	dst := tempDir(t)
+	writeTestFile(t, filepath.Join(dst, "b2.json"), "existing")
+	_, err = Restore(archive, Dir("settings", dst))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dst, "b1.json"))
+}
+
+func TestRestoreChecksAllSourcesFirst(t *testing.T) {
+	src := tempDir(t)
+	writeTestFile(t, filepath.Join(src, "settings", "b1.json"), "bundle")
+	writeTestFile(t, filepath.Join(src, "keys", "key.pem"), "key")
+	archive := &bytes.Buffer{}
+	_, err := Create(archive, "1.0.0", Dir("settings", filepath.Join(src, "settings")), Dir("keys", filepath.Join(src, "keys")))
+	assert.NoError(t, err)
+
+	// the settings would be restored before the keys are found to exist already
+	dst := tempDir(t)
+	writeTestFile(t, filepath.Join(dst, "keys", "key.pem"), "existing")
+	_, err = Restore(archive, Dir("settings", filepath.Join(dst, "settings")), Dir("keys", filepath.Join(dst, "keys")))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dst, "settings", "b1.json"))
+}
+
+func TestCreateFailsOnSymlinks(t *testing.T) {
+	src := tempDir(t)
+	writeTestFile(t, filepath.Join(src, "b1.json"), "bundle")
+	if err := os.Symlink("b1.json", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Create(&bytes.Buffer{}, "1.0.0", Dir("settings", src))
+	assert.Error(t, err)
+}
+
+func TestCheckStopped(t *testing.T) {
+	r := memory.NewRegistry()
+	assert.NoError(t, CheckStopped(r, "com.owncloud.api.store"))
+
+	assert.NoError(t, r.Register(&registry.Service{Name: "com.owncloud.api.store", Nodes: []*registry.Node{{Id: "1", Address: "127.0.0.1:9460"}}}))
+	assert.NoError(t, CheckStopped(r, "com.owncloud.api.accounts"))
+	assert.EqualError(t, CheckStopped(r, "com.owncloud.api.accounts", "com.owncloud.api.store"), "com.owncloud.api.store is running, stop the services first")
+}
+
+func TestRestoreRequiresAllSources(t *testing.T) {
+	archive := &bytes.Buffer{}
+	_, err := Create(archive, "1.0.0", Dir("settings", ""))
+	assert.NoError(t, err)
+
+	dst := tempDir(t)
+	_, err = Restore(archive, Dir("settings", dst), Dir("thumbnails", filepath.Join(dst, "thumbnails")))
+	assert.EqualError(t, err, "the archive contains no thumbnails data")
+}
+
+// entry is a file of a hand crafted archive.
+type entry struct {
+	name    string
+	content string
+}
+
+func craftArchive(t *testing.T, m Manifest, entries ...entry) []byte {
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range append([]entry{{manifestName, string(manifest)}}, entries...) {
+		assert.NoError(t, writeEntry(tw, e.name, int64(len(e.content)), bytes.NewReader([]byte(e.content))))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestVerifyDamagedArchives(t *testing.T) {
+	src := tempDir(t)
+	writeTestFile(t, filepath.Join(src, "b1.json"), "bundle")
+	sum, err := checksum(filepath.Join(src, "b1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := File{Path: "settings/b1.json", Size: 6, SHA256: sum}
+
+	tests := []struct {
+		name    string
+		archive []byte
+	}{
+		{"not an archive", []byte("hello")},
+		{"missing manifest", func() []byte {
+			buf := &bytes.Buffer{}
+			gw := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gw)
+			assert.NoError(t, writeEntry(tw, "settings/b1.json", 6, bytes.NewReader([]byte("bundle"))))
+			tw.Close()
+			gw.Close()
+			return buf.Bytes()
+		}()},
+		{"newer version", craftArchive(t, Manifest{Version: FormatVersion + 1, Files: []File{file}}, entry{file.Path, "bundle"})},
+		{"changed content", craftArchive(t, Manifest{Version: FormatVersion, Files: []File{file}}, entry{file.Path, "bunble"})},
+		{"missing file", craftArchive(t, Manifest{Version: FormatVersion, Files: []File{file}})},
+		{"unexpected file", craftArchive(t, Manifest{Version: FormatVersion, Files: []File{file}}, entry{file.Path, "bundle"}, entry{"settings/b2.json", "x"})},
+		{"path outside the archive", craftArchive(t, Manifest{Version: FormatVersion, Files: []File{{Path: "../b1.json", Size: 6, SHA256: sum}}}, entry{"../b1.json", "bundle"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(bytes.NewReader(tt.archive))
+			assert.Error(t, err)
+
+			dst := tempDir(t)
+			_, err = Restore(bytes.NewReader(tt.archive), Dir("settings", filepath.Join(dst, "settings")))
+			assert.Error(t, err)
+			assert.NoDirExists(t, filepath.Join(dst, "settings"))
+		})
+	}
+
+	_, err = Verify(bytes.NewReader(craftArchive(t, Manifest{Version: FormatVersion, Files: []File{file}}, entry{file.Path, "bundle"})))
+	assert.NoError(t, err)
+}
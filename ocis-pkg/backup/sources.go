@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// dirSource backs up the regular files of a directory tree.
+type dirSource struct {
+	name string
+	path string
+}
+
+// Dir returns a source backing up the regular files below path. Nothing is backed up if path is empty or doesn't
+// exist. The directory must be empty or missing when it is restored. Other files like symlinks can't be backed up.
+func Dir(name, path string) Source {
+	return dirSource{name: name, path: path}
+}
+
+func (s dirSource) Name() string {
+	return s.name
+}
+
+func (s dirSource) Export(dir string) error {
+	if s.path == "" {
+		return nil
+	}
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+	return copyTree(s.path, dir)
+}
+
+func (s dirSource) CheckEmpty() error {
+	if s.path == "" {
+		return nil
+	}
+	entries, err := ioutil.ReadDir(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", s.path)
+	}
+	return nil
+}
+
+func (s dirSource) Import(dir string) error {
+	if s.path == "" {
+		return nil
+	}
+	if err := s.CheckEmpty(); err != nil {
+		return err
+	}
+	return copyTree(dir, s.path)
+}
+
+// filesSource backs up single files, e.g. configuration files.
+type filesSource struct {
+	name  string
+	files map[string]string
+}
+
+// Files returns a source backing up single files. The keys of files are the names of the files in the archive, the
+// values their paths. Files with an empty path or which don't exist are skipped. Restored files replace existing ones.
+func Files(name string, files map[string]string) Source {
+	return filesSource{name: name, files: files}
+}
+
+func (s filesSource) Name() string {
+	return s.name
+}
+
+func (s filesSource) Export(dir string) error {
+	for name, p := range s.files {
+		if p == "" {
+			continue
+		}
+		fh, err := os.Open(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = copyFile(filepath.Join(dir, name), fh)
+		fh.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckEmpty implements the Source interface, existing files are replaced.
+func (s filesSource) CheckEmpty() error {
+	return nil
+}
+
+func (s filesSource) Import(dir string) error {
+	for name, p := range s.files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) || p == "" {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree copies the regular files below from to the directory to. It fails on other files like symlinks, instead of
+// leaving them out of a backup silently.
+func copyTree(from, to string) error {
+	return filepath.Walk(from, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0700)
+		case info.Mode().IsRegular():
+			fh, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer fh.Close()
+			return copyFile(target, fh)
+		default:
+			return fmt.Errorf("%s is not a regular file", p)
+		}
+	})
+}
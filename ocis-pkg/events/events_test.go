@@ -0,0 +1,30 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadFinishedSignature(t *testing.T) {
+	ev := UploadFinished{
+		Owner: UserID{Idp: "https://localhost:9200", OpaqueID: "4c510ada-c86b-4815-8820-42cdf82c3d51"},
+		Path:  "/a.jpg",
+		ETag:  "1872ade88f3013edeb33decd74a4f947",
+	}
+	assert.Equal(t, ErrInvalidSignature, ev.Verify("secret"))
+
+	ev.Sign("secret")
+	assert.NoError(t, ev.Verify("secret"))
+	assert.Equal(t, ErrInvalidSignature, ev.Verify("other"))
+	assert.Equal(t, ErrInvalidSignature, ev.Verify(""))
+
+	forged := ev
+	forged.Owner.OpaqueID = "ddc2004c-0977-11eb-9d3f-a793888cd0f8"
+	assert.Equal(t, ErrInvalidSignature, forged.Verify("secret"))
+
+	// moving a character from one field to the next changes the signature
+	forged = ev
+	forged.Path, forged.ETag = "/a.jp", "g"+ev.ETag
+	assert.Equal(t, ErrInvalidSignature, forged.Verify("secret"))
+}
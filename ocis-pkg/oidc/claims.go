@@ -72,6 +72,18 @@ type StandardClaims struct {
 
 	Iss string `json:"iss"`
 
+	// Time at which the token was issued. Its value is a JSON number representing
+	// the number of seconds from 1970-01-01T0:0:0Z as measured in UTC until the
+	// date/time. Userinfo responses usually don't contain it, the proxy takes it
+	// from the access token instead.
+	Iat int64 `json:"iat,omitempty"`
+
+	// Time when the End-User authentication occurred. Its value is a JSON number
+	// representing the number of seconds from 1970-01-01T0:0:0Z as measured in UTC
+	// until the date/time. It does not change when a token is refreshed, the proxy
+	// takes it from the access token if the userinfo response doesn't contain it.
+	AuthTime int64 `json:"auth_time,omitempty"`
+
 	// Subject - Identifier for the End-User at the Issuer.
 	Sub string `json:"sub,omitempty"`
 
@@ -0,0 +1,190 @@
+package signedurl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// KeyringVersion is the current version of the persisted keyring format.
+	KeyringVersion = 2
+
+	// maxKeysWithoutID limits how many keys are tried to verify a url without a key id
+	maxKeysWithoutID = 3
+)
+
+// ErrNoKey is returned when a keyring does not contain a usable signing key.
+var ErrNoKey = errors.New("no valid signing key found")
+
+// Key is a single signing key of a user.
+type Key struct {
+	// ID identifies the key, it is sent along with a signed url as OC-KeyID.
+	ID string `json:"id"`
+	// Secret is the hex encoded key material. The hex string itself is used as the key when signing, which keeps
+	// signatures compatible with oc10.
+	Secret string `json:"secret"`
+	// CreatedAt is the time the key was generated.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is set when the key was rotated. A zero value means the key does not expire.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// ValidAt checks if the key may be used to verify a signature at the given time.
+func (k Key) ValidAt(t time.Time) bool {
+	return k.ExpiresAt.IsZero() || t.Before(k.ExpiresAt)
+}
+
+// Keyring holds all signing keys of a user. The first key without an expiry is the current one, all other keys are
+// only kept to verify urls that were signed before the last rotation.
+type Keyring struct {
+	Version int   `json:"version"`
+	Keys    []Key `json:"keys"`
+}
+
+// NewKey generates a new random signing key.
+func NewKey(now time.Time) (Key, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, err
+	}
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, err
+	}
+	return Key{
+		ID:        hex.EncodeToString(id),
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: now,
+	}, nil
+}
+
+// NewKeyring creates a keyring with a freshly generated key.
+func NewKeyring(now time.Time) (*Keyring, error) {
+	k, err := NewKey(now)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{
+		Version: KeyringVersion,
+		Keys:    []Key{k},
+	}, nil
+}
+
+// ParseKeyring parses a persisted keyring. Values written before keyrings were introduced only contain the plain
+// hex encoded signing key. They are returned as a keyring with a single key with an empty id. Anything that looks
+// like json but can't be parsed is an error, so a corrupted keyring never ends up being used as a signing key.
+func ParseKeyring(b []byte) (*Keyring, error) {
+	if len(b) == 0 {
+		return nil, ErrNoKey
+	}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return &Keyring{
+			Version: KeyringVersion,
+			Keys:    []Key{{Secret: string(b)}},
+		}, nil
+	}
+	kr := &Keyring{}
+	if err := json.Unmarshal(b, kr); err != nil {
+		return nil, fmt.Errorf("could not parse keyring: %w", err)
+	}
+	if kr.Version == 0 || kr.Version > KeyringVersion {
+		return nil, fmt.Errorf("unsupported keyring version %d", kr.Version)
+	}
+	return kr, nil
+}
+
+// Marshal serializes the keyring for persisting it.
+func (kr *Keyring) Marshal() ([]byte, error) {
+	kr.Version = KeyringVersion
+	return json.Marshal(kr)
+}
+
+// Current returns the key that should be used to sign new urls.
+func (kr *Keyring) Current() (Key, error) {
+	for _, k := range kr.Keys {
+		if k.Secret != "" && k.ExpiresAt.IsZero() {
+			return k, nil
+		}
+	}
+	return Key{}, ErrNoKey
+}
+
+// Revoke removes the key with the given id. It returns false if the keyring does not contain the key.
+func (kr *Keyring) Revoke(id string) bool {
+	for i, k := range kr.Keys {
+		if k.ID == id {
+			kr.Keys = append(kr.Keys[:i], kr.Keys[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the keys which may have signed a url with the given key id, if they are still valid at the given
+// time. Urls without a key id were signed before keyrings were introduced or by clients with the key of the
+// signing-key endpoint. For them the current key is returned first, followed by the newest previous keys, at most
+// maxKeysWithoutID keys, because every key tried costs a full signature computation.
+func (kr *Keyring) Lookup(id string, now time.Time) ([]Key, error) {
+	var keys []Key
+	if id == "" {
+		if c, err := kr.Current(); err == nil {
+			keys = append(keys, c)
+		}
+	}
+	for _, k := range kr.Keys {
+		if k.Secret == "" || !k.ValidAt(now) {
+			continue
+		}
+		if id != "" && k.ID == id {
+			return []Key{k}, nil
+		}
+		if id == "" && k.ExpiresAt.After(now) && len(keys) < maxKeysWithoutID {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	return keys, nil
+}
+
+// NeedsRotation checks if the current key is older than the given rotation interval. An interval <= 0 disables
+// rotation.
+func (kr *Keyring) NeedsRotation(now time.Time, interval time.Duration) bool {
+	c, err := kr.Current()
+	if err != nil {
+		return true
+	}
+	if interval <= 0 {
+		return false
+	}
+	return !c.CreatedAt.Add(interval).After(now)
+}
+
+// Rotate generates a new current key. The previous keys stay valid for the grace period, keys whose grace period
+// has passed are dropped.
+func (kr *Keyring) Rotate(now time.Time, grace time.Duration) (Key, error) {
+	nk, err := NewKey(now)
+	if err != nil {
+		return Key{}, err
+	}
+	keys := []Key{nk}
+	for _, k := range kr.Keys {
+		if k.Secret == "" {
+			continue
+		}
+		if k.ExpiresAt.IsZero() || k.ExpiresAt.After(now.Add(grace)) {
+			k.ExpiresAt = now.Add(grace)
+		}
+		if k.ValidAt(now) {
+			keys = append(keys, k)
+		}
+	}
+	kr.Keys = keys
+	return nk, nil
+}
@@ -0,0 +1,200 @@
+// Package signedurl implements the pre-signed url scheme shared by the ocs service, which hands out signed urls and
+// signing keys, and the proxy, which verifies them.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// AlgorithmPBKDF2 is the oc10 compatible signature algorithm. It is expensive to compute and used when a
+	// request does not specify OC-Algo.
+	AlgorithmPBKDF2 = "PBKDF2/10000-SHA512"
+	// AlgorithmHMACSHA256 is a cheaper signature algorithm.
+	AlgorithmHMACSHA256 = "HMAC-SHA256"
+
+	// MaxExpires is the maximum number of seconds a signed url may be valid.
+	MaxExpires = 604800
+)
+
+// Query parameters used by signed urls.
+const (
+	ParamSignature  = "OC-Signature"
+	ParamCredential = "OC-Credential"
+	ParamDate       = "OC-Date"
+	ParamExpires    = "OC-Expires"
+	ParamVerb       = "OC-Verb"
+	ParamAlgorithm  = "OC-Algo"
+	ParamKeyID      = "OC-KeyID"
+)
+
+// Algorithms lists the supported signature algorithms.
+var Algorithms = []string{AlgorithmPBKDF2, AlgorithmHMACSHA256}
+
+// Params describe what a signed url grants access to.
+type Params struct {
+	// Credential is the username of the user that signed the url.
+	Credential string
+	// Verb is the http method the url is valid for.
+	Verb string
+	// Date is the time the url was signed.
+	Date time.Time
+	// Expires is the validity of the url in seconds after Date.
+	Expires int
+	// Algorithm used to compute the signature, defaults to AlgorithmPBKDF2.
+	Algorithm string
+}
+
+// Sign returns the url with all OC-* parameters and the signature added.
+func Sign(u *url.URL, p Params, key Key) (string, error) {
+	if p.Expires < 1 || p.Expires > MaxExpires {
+		return "", fmt.Errorf("expires must be between 1 and %d seconds", MaxExpires)
+	}
+	if p.Algorithm == "" {
+		p.Algorithm = AlgorithmPBKDF2
+	}
+
+	su := *u
+	q := su.Query()
+	q.Del(ParamSignature)
+	q.Set(ParamCredential, p.Credential)
+	q.Set(ParamDate, p.Date.UTC().Format(time.RFC3339))
+	q.Set(ParamExpires, strconv.Itoa(p.Expires))
+	q.Set(ParamVerb, strings.ToUpper(p.Verb))
+	q.Set(ParamAlgorithm, p.Algorithm)
+	if key.ID != "" {
+		q.Set(ParamKeyID, key.ID)
+	}
+	su.RawQuery = q.Encode()
+
+	signature, err := CreateSignature(p.Algorithm, su.String(), []byte(key.Secret))
+	if err != nil {
+		return "", err
+	}
+	q.Set(ParamSignature, signature)
+	su.RawQuery = q.Encode()
+	return su.String(), nil
+}
+
+// CreateSignature computes the hex encoded signature of the url with the given algorithm.
+func CreateSignature(algorithm, url string, signingKey []byte) (string, error) {
+	switch algorithm {
+	case AlgorithmPBKDF2, "":
+		// the oc10 signature check: $hash = \hash_pbkdf2("sha512", $url, $signingKey, 10000, 64, false);
+		// - sets the length of the output string to 64
+		// - sets raw output to false ->  if raw_output is FALSE length corresponds to twice the byte-length of the derived key (as every byte of the key is returned as two hexits).
+		// TODO change to length 128 in oc10?
+		// fo golangs pbkdf2.Key we need to use 32 because it will be encoded into 64 hexits later
+		hash := pbkdf2.Key([]byte(url), signingKey, 10000, 32, sha512.New)
+		return hex.EncodeToString(hash), nil
+	case AlgorithmHMACSHA256:
+		mac := hmac.New(sha256.New, signingKey)
+		mac.Write([]byte(url))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %s", algorithm)
+	}
+}
+
+// VerifySignature checks the signature of the url in constant time.
+func VerifySignature(algorithm, url string, signingKey []byte, signature string) (bool, error) {
+	expected, err := CreateSignature(algorithm, url, signingKey)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(expected), []byte(signature)), nil
+}
+
+// TrustedProxies are the reverse proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honoured. The
+// headers of other clients are ignored, they could make a url signed for another host look valid.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of ip addresses and cidr ranges.
+func ParseTrustedProxies(addrs []string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, a := range addrs {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if !strings.Contains(a, "/") {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", a)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", a, err)
+		}
+		proxies = append(proxies, n)
+	}
+	return proxies, nil
+}
+
+// Trusts checks if the request was sent by one of the trusted proxies.
+func (t TrustedProxies) Trusts(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range t {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestScheme returns the scheme the client used to send the request. X-Forwarded-Proto of a trusted proxy takes
+// precedence so the scheme is detected correctly behind a TLS terminating reverse proxy.
+func RequestScheme(r *http.Request, trusted TrustedProxies) string {
+	if r.URL.IsAbs() {
+		return r.URL.Scheme
+	}
+	if fp := r.Header.Get("X-Forwarded-Proto"); fp != "" && trusted.Trusts(r) {
+		fp = strings.ToLower(strings.TrimSpace(strings.Split(fp, ",")[0]))
+		if fp == "http" || fp == "https" {
+			return fp
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// RequestURL reconstructs the absolute url of a request as seen by the client.
+func RequestURL(r *http.Request, trusted TrustedProxies) *url.URL {
+	u := *r.URL
+	if !u.IsAbs() {
+		u.Scheme = RequestScheme(r, trusted)
+		u.Host = r.Host
+		if fh := r.Header.Get("X-Forwarded-Host"); fh != "" && trusted.Trusts(r) {
+			u.Host = strings.TrimSpace(strings.Split(fh, ",")[0])
+		}
+	}
+	return &u
+}
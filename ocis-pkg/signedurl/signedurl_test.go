@@ -0,0 +1,242 @@
+package signedurl
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSignature(t *testing.T) {
+	s, err := CreateSignature(AlgorithmPBKDF2, "something", []byte("somerandomkey"))
+	assert.NoError(t, err)
+	assert.Equal(t, "27d2ebea381384af3179235114801dcd00f91e46f99fca72575301cf3948101d", s)
+
+	s, err = CreateSignature(AlgorithmHMACSHA256, "something", []byte("somerandomkey"))
+	assert.NoError(t, err)
+	assert.Len(t, s, 64)
+
+	_, err = CreateSignature("MD5", "something", []byte("somerandomkey"))
+	assert.Error(t, err)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2020, 2, 2, 12, 30, 0, 0, time.UTC)
+	key, err := NewKey(now)
+	assert.NoError(t, err)
+
+	for _, algo := range Algorithms {
+		u, _ := url.Parse("https://example.com/remote.php/dav/files/einstein/file.jpg")
+		signed, err := Sign(u, Params{
+			Credential: "einstein",
+			Verb:       "get",
+			Date:       now,
+			Expires:    60,
+			Algorithm:  algo,
+		}, key)
+		assert.NoError(t, err)
+
+		su, _ := url.Parse(signed)
+		q := su.Query()
+		assert.Equal(t, "GET", q.Get(ParamVerb))
+		assert.Equal(t, key.ID, q.Get(ParamKeyID))
+		assert.Equal(t, algo, q.Get(ParamAlgorithm))
+
+		signature := q.Get(ParamSignature)
+		q.Del(ParamSignature)
+		su.RawQuery = q.Encode()
+		ok, err := VerifySignature(algo, su.String(), []byte(key.Secret), signature)
+		assert.NoError(t, err)
+		assert.True(t, ok, algo)
+
+		q.Set(ParamCredential, "marie")
+		su.RawQuery = q.Encode()
+		ok, _ = VerifySignature(algo, su.String(), []byte(key.Secret), signature)
+		assert.False(t, ok, algo)
+	}
+}
+
+func TestSignRejectsInvalidExpiry(t *testing.T) {
+	u, _ := url.Parse("https://example.com/file.jpg")
+	for _, e := range []int{0, -1, MaxExpires + 1} {
+		_, err := Sign(u, Params{Credential: "einstein", Verb: "GET", Date: time.Now(), Expires: e}, Key{Secret: "key"})
+		assert.Error(t, err)
+	}
+}
+
+func TestRequestScheme(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		url        string
+		remoteAddr string
+		header     string
+		tls        bool
+		expected   string
+	}{
+		{"/file.jpg", "10.0.0.1:1234", "", false, "http"},
+		{"/file.jpg", "10.0.0.1:1234", "", true, "https"},
+		{"/file.jpg", "10.0.0.1:1234", "https", false, "https"},
+		{"/file.jpg", "10.0.0.1:1234", "https, http", false, "https"},
+		{"/file.jpg", "10.0.0.1:1234", "HTTP", true, "http"},
+		{"/file.jpg", "10.0.0.1:1234", "gopher", true, "https"},
+		// the headers of other clients are ignored
+		{"/file.jpg", "192.0.2.1:1234", "https", false, "http"},
+		{"/file.jpg", "192.0.2.1:1234", "http", true, "https"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", tt.url, nil)
+		r.RemoteAddr = tt.remoteAddr
+		r.TLS = nil
+		if tt.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		if tt.header != "" {
+			r.Header.Set("X-Forwarded-Proto", tt.header)
+		}
+		assert.Equal(t, tt.expected, RequestScheme(r, trusted), tt)
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"127.0.0.1", "::1"})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/remote.php/webdav/file.jpg?x=1", nil)
+	r.Host = "internal:9200"
+	r.Header.Set("X-Forwarded-Host", "cloud.example.com, internal")
+	r.Header.Set("X-Forwarded-Proto", "https")
+
+	r.RemoteAddr = "[::1]:1234"
+	assert.Equal(t, "https://cloud.example.com/remote.php/webdav/file.jpg?x=1", RequestURL(r, trusted).String())
+	r.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "http://internal:9200/remote.php/webdav/file.jpg?x=1", RequestURL(r, trusted).String())
+	assert.Equal(t, "http://internal:9200/remote.php/webdav/file.jpg?x=1", RequestURL(r, nil).String())
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.1", "fd00::/8", " "})
+	assert.NoError(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.example.com"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.1/33"})
+	assert.Error(t, err)
+}
+
+func TestParseLegacyKeyring(t *testing.T) {
+	kr, err := ParseKeyring([]byte("abcdef"))
+	assert.NoError(t, err)
+	keys, err := kr.Lookup("", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{{Secret: "abcdef"}}, keys)
+
+	// urls signed with the legacy key stay valid during the grace period after a rotation
+	now := time.Now()
+	current, err := kr.Rotate(now, time.Hour)
+	assert.NoError(t, err)
+	keys, err = kr.Lookup("", now)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{current, {Secret: "abcdef", ExpiresAt: now.Add(time.Hour)}}, keys)
+
+	_, err = ParseKeyring([]byte{})
+	assert.Equal(t, ErrNoKey, err)
+}
+
+func TestParseCorruptedKeyring(t *testing.T) {
+	kr, _ := NewKeyring(time.Now())
+	b, _ := kr.Marshal()
+
+	_, err := ParseKeyring(b[:len(b)/2])
+	assert.Error(t, err)
+	_, err = ParseKeyring([]byte(` {"keys": []}`))
+	assert.Error(t, err)
+	_, err = ParseKeyring([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestLookupWithoutKeyIDLimitsKeys(t *testing.T) {
+	now := time.Date(2020, 2, 2, 12, 30, 0, 0, time.UTC)
+	kr, _ := NewKeyring(now)
+	for i := 0; i < 5; i++ {
+		_, _ = kr.Rotate(now, time.Hour)
+	}
+	assert.Len(t, kr.Keys, 6)
+
+	keys, err := kr.Lookup("", now)
+	assert.NoError(t, err)
+	assert.Len(t, keys, maxKeysWithoutID)
+	current, _ := kr.Current()
+	assert.Equal(t, current, keys[0])
+	assert.Equal(t, kr.Keys[1], keys[1])
+
+	// keys with an id are found even if they are too old to be tried without one
+	keys, err = kr.Lookup(kr.Keys[5].ID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{kr.Keys[5]}, keys)
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Date(2020, 2, 2, 12, 30, 0, 0, time.UTC)
+	kr, err := NewKeyring(now)
+	assert.NoError(t, err)
+	old, _ := kr.Current()
+
+	assert.False(t, kr.NeedsRotation(now.Add(time.Hour), 0))
+	assert.False(t, kr.NeedsRotation(now.Add(time.Hour), 2*time.Hour))
+	assert.True(t, kr.NeedsRotation(now.Add(2*time.Hour), 2*time.Hour))
+
+	nk, err := kr.Rotate(now.Add(2*time.Hour), time.Hour)
+	assert.NoError(t, err)
+	assert.NotEqual(t, old.ID, nk.ID)
+	c, _ := kr.Current()
+	assert.Equal(t, nk.ID, c.ID)
+
+	// the old key is valid during the grace period
+	_, err = kr.Lookup(old.ID, now.Add(150*time.Minute))
+	assert.NoError(t, err)
+	_, err = kr.Lookup(old.ID, now.Add(3*time.Hour))
+	assert.Equal(t, ErrNoKey, err)
+
+	// urls without a key id are checked against all valid keys
+	keys, err := kr.Lookup("", now.Add(150*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	keys, err = kr.Lookup("", now.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{c}, keys)
+
+	// expired keys are dropped on the next rotation
+	b, err := kr.Marshal()
+	assert.NoError(t, err)
+	kr, err = ParseKeyring(b)
+	assert.NoError(t, err)
+	assert.Len(t, kr.Keys, 2)
+	_, err = kr.Rotate(now.Add(4*time.Hour), time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, kr.Keys, 2)
+	_, err = kr.Lookup(old.ID, now.Add(4*time.Hour))
+	assert.Equal(t, ErrNoKey, err)
+}
+
+func TestKeyringRevoke(t *testing.T) {
+	now := time.Date(2020, 2, 2, 12, 30, 0, 0, time.UTC)
+	kr, _ := NewKeyring(now)
+	old, _ := kr.Current()
+	current, _ := kr.Rotate(now, time.Hour)
+
+	assert.False(t, kr.Revoke("unknown"))
+	assert.True(t, kr.Revoke(old.ID))
+	_, err := kr.Lookup(old.ID, now)
+	assert.Equal(t, ErrNoKey, err)
+
+	// revoking the current key leaves the keyring without a key to sign with
+	assert.True(t, kr.Revoke(current.ID))
+	_, err = kr.Current()
+	assert.Equal(t, ErrNoKey, err)
+	assert.True(t, kr.NeedsRotation(now, 0))
+}
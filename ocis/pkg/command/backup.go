@@ -0,0 +1,152 @@
+// +build !simple
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/micro/cli/v2"
+	accounts "github.com/owncloud/ocis/accounts/pkg/service/v0"
+	"github.com/owncloud/ocis/ocis-pkg/backup"
+	"github.com/owncloud/ocis/ocis-pkg/registry"
+	"github.com/owncloud/ocis/ocis/pkg/config"
+	"github.com/owncloud/ocis/ocis/pkg/flagset"
+	"github.com/owncloud/ocis/ocis/pkg/register"
+	"github.com/owncloud/ocis/ocis/pkg/version"
+	store "github.com/owncloud/ocis/store/pkg/service/v0"
+)
+
+// BackupCommand is the entrypoint for the backup command.
+func BackupCommand(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "backup",
+		Usage:    "Back up and restore the data of the ocis services",
+		Category: "Runtime",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "Write the data of the services to an archive, the services must not be running",
+				Flags: flagset.BackupWithConfig(cfg),
+				Action: func(c *cli.Context) error {
+					if err := checkStopped(cfg); err != nil {
+						fmt.Println(fmt.Errorf("could not create the backup %w", err))
+						return err
+					}
+
+					file := c.String("file")
+					f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+					if err != nil {
+						fmt.Println(fmt.Errorf("could not create the archive %w", err))
+						return err
+					}
+
+					m, err := backup.Create(f, version.String, backupSources(c, cfg)...)
+					if cerr := f.Close(); err == nil {
+						err = cerr
+					}
+					if err != nil {
+						os.Remove(file)
+						fmt.Println(fmt.Errorf("could not create the backup %w", err))
+						return err
+					}
+
+					fmt.Printf("backed up %d files of %v to %s\n", len(m.Files), m.Sources, file)
+					return nil
+				},
+			},
+			{
+				Name:  "restore",
+				Usage: "Restore an archive into an empty instance and rebuild the indexes, the services must not be running",
+				Flags: flagset.BackupWithConfig(cfg),
+				Action: func(c *cli.Context) error {
+					if err := checkStopped(cfg); err != nil {
+						fmt.Println(fmt.Errorf("could not restore the backup %w", err))
+						return err
+					}
+
+					f, err := os.Open(c.String("file"))
+					if err != nil {
+						fmt.Println(fmt.Errorf("could not open the archive %w", err))
+						return err
+					}
+					defer f.Close()
+
+					m, err := backup.Restore(f, backupSources(c, cfg)...)
+					if err != nil {
+						fmt.Println(fmt.Errorf("could not restore the backup %w", err))
+						return err
+					}
+
+					fmt.Printf("restored %v from the backup of %s\n", m.Sources, m.Created.Format("2006-01-02 15:04:05 MST"))
+					return nil
+				},
+			},
+			{
+				Name:  "verify",
+				Usage: "Check that an archive is complete and its checksums match",
+				Flags: flagset.VerifyBackupWithConfig(cfg),
+				Action: func(c *cli.Context) error {
+					f, err := os.Open(c.String("file"))
+					if err != nil {
+						fmt.Println(fmt.Errorf("could not open the archive %w", err))
+						return err
+					}
+					defer f.Close()
+
+					m, err := backup.Verify(f)
+					if err != nil {
+						fmt.Println(fmt.Errorf("the backup is damaged %w", err))
+						return err
+					}
+
+					fmt.Printf("the backup of %s (ocis %s) with %d files of %v is valid\n", m.Created.Format("2006-01-02 15:04:05 MST"), m.OcisVersion, len(m.Files), m.Sources)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// checkStopped refuses to back up or restore the data while one of the services keeping it is running.
+func checkStopped(cfg *config.Config) error {
+	accountsCfg := configureAccounts(cfg)
+	settingsCfg := configureSettings(cfg)
+	storeCfg := configureStore(cfg)
+	idpCfg := configureIDP(cfg)
+	return backup.CheckStopped(*registry.GetRegistry(),
+		accountsCfg.GRPC.Namespace+"."+accountsCfg.Server.Name,
+		settingsCfg.GRPC.Namespace+"."+settingsCfg.Service.Name,
+		storeCfg.Service.Namespace+"."+storeCfg.Service.Name,
+		idpCfg.Service.Namespace+"."+idpCfg.Service.Name,
+	)
+}
+
+// backupSources returns the sources of the data kept by the services. The store and the accounts are read and
+// written through their storage, the other services keep plain files. The thumbnails are left out, they are a cache
+// which is filled again on demand.
+func backupSources(c *cli.Context, cfg *config.Config) []backup.Source {
+	logger := NewLogger(cfg)
+	idp := configureIDP(cfg)
+	idpFiles := map[string]string{
+		"identifier-registration.yaml": idp.IDP.IdentifierRegistrationConf,
+		"scopes.yaml":                  idp.IDP.IdentifierScopesConf,
+		"encryption-secret":            idp.IDP.EncryptionSecretFile,
+	}
+	for i, key := range c.StringSlice("idp-signing-private-key") {
+		idpFiles["signing-private-key-"+strconv.Itoa(i)] = key
+	}
+
+	return []backup.Source{
+		store.NewBackupSource(store.Logger(logger), store.Config(configureStore(cfg))),
+		accounts.NewBackupSource(accounts.Logger(logger), accounts.Config(configureAccounts(cfg))),
+		backup.Dir("settings", configureSettings(cfg).Service.DataPath),
+		backup.Files("idp", idpFiles),
+		backup.Dir("idp-validation-keys", idp.IDP.ValidationKeysPath),
+	}
+}
+
+func init() {
+	register.AddCommand(BackupCommand)
+}
@@ -20,6 +20,7 @@ func ProxyCommand(cfg *config.Config) *cli.Command {
 		Category: "Extensions",
 		Flags:    flagset.ServerWithConfig(cfg.Proxy),
 		Subcommands: []*cli.Command{
+			command.SigningKeys(cfg.Proxy),
 			command.PrintVersion(cfg.Proxy),
 		},
 		Action: func(c *cli.Context) error {
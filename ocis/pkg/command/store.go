@@ -20,6 +20,9 @@ func StoreCommand(cfg *config.Config) *cli.Command {
 		Category: "Extensions",
 		Flags:    flagset.ServerWithConfig(cfg.Store),
 		Subcommands: []*cli.Command{
+			command.Reindex(cfg.Store),
+			command.Migrate(cfg.Store),
+			command.Reencrypt(cfg.Store),
 			command.PrintVersion(cfg.Store),
 		},
 		Action: func(c *cli.Context) error {
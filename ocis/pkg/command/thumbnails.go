@@ -21,6 +21,7 @@ func ThumbnailsCommand(cfg *config.Config) *cli.Command {
 		Category: "Extensions",
 		Flags:    flagset.ServerWithConfig(cfg.Thumbnails),
 		Subcommands: []*cli.Command{
+			command.GC(cfg.Thumbnails),
 			command.PrintVersion(cfg.Thumbnails),
 		},
 		Action: func(c *cli.Context) error {
@@ -43,6 +44,10 @@ func configureThumbnails(cfg *config.Config) *svcconfig.Config {
 		cfg.Thumbnails.Tracing.Collector = cfg.Tracing.Collector
 	}
 
+	if cfg.TokenManager.JWTSecret != "" {
+		cfg.Thumbnails.TokenManager.JWTSecret = cfg.TokenManager.JWTSecret
+	}
+
 	return cfg.Thumbnails
 }
 
@@ -3,6 +3,7 @@ package flagset
 import (
 	"github.com/micro/cli/v2"
 	"github.com/owncloud/ocis/ocis/pkg/config"
+	storeflagset "github.com/owncloud/ocis/store/pkg/flagset"
 )
 
 // RootWithConfig applies cfg to the root flagset
@@ -145,3 +146,133 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 		},
 	}
 }
+
+// BackupWithConfig applies cfg to the flagset of the backup create and restore commands. The flags locate the data of
+// the services and use the same environment variables as the services.
+func BackupWithConfig(cfg *config.Config) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "Path to the backup archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:        "accounts-storage-disk-path",
+			Value:       "",
+			Usage:       "Path on the local disk, e.g. /var/tmp/ocis/accounts, the cs3 storage is used if empty",
+			EnvVars:     []string{"ACCOUNTS_STORAGE_DISK_PATH"},
+			Destination: &cfg.Accounts.Repo.Disk.Path,
+		},
+		&cli.StringFlag{
+			Name:        "accounts-storage-cs3-provider-addr",
+			Value:       "localhost:9215",
+			Usage:       "bind address for the metadata storage provider",
+			EnvVars:     []string{"ACCOUNTS_STORAGE_CS3_PROVIDER_ADDR"},
+			Destination: &cfg.Accounts.Repo.CS3.ProviderAddr,
+		},
+		&cli.StringFlag{
+			Name:        "accounts-storage-cs3-data-url",
+			Value:       "http://localhost:9216",
+			Usage:       "http endpoint of the metadata storage",
+			EnvVars:     []string{"ACCOUNTS_STORAGE_CS3_DATA_URL"},
+			Destination: &cfg.Accounts.Repo.CS3.DataURL,
+		},
+		&cli.StringFlag{
+			Name:        "accounts-storage-cs3-data-prefix",
+			Value:       "data",
+			Usage:       "path prefix for the http endpoint of the metadata storage, without leading slash",
+			EnvVars:     []string{"ACCOUNTS_STORAGE_CS3_DATA_PREFIX"},
+			Destination: &cfg.Accounts.Repo.CS3.DataPrefix,
+		},
+		&cli.Int64Flag{
+			Name:        "accounts-uid-index-lower-bound",
+			Value:       0,
+			Usage:       "define a starting point for the account UID",
+			EnvVars:     []string{"ACCOUNTS_UID_INDEX_LOWER_BOUND"},
+			Destination: &cfg.Accounts.Index.UID.Lower,
+		},
+		&cli.Int64Flag{
+			Name:        "accounts-gid-index-lower-bound",
+			Value:       1000,
+			Usage:       "define a starting point for the account GID",
+			EnvVars:     []string{"ACCOUNTS_GID_INDEX_LOWER_BOUND"},
+			Destination: &cfg.Accounts.Index.GID.Lower,
+		},
+		&cli.Int64Flag{
+			Name:        "accounts-uid-index-upper-bound",
+			Value:       0,
+			Usage:       "define an ending point for the account UID",
+			EnvVars:     []string{"ACCOUNTS_UID_INDEX_UPPER_BOUND"},
+			Destination: &cfg.Accounts.Index.UID.Upper,
+		},
+		&cli.Int64Flag{
+			Name:        "accounts-gid-index-upper-bound",
+			Value:       1000,
+			Usage:       "define an ending point for the account GID",
+			EnvVars:     []string{"ACCOUNTS_GID_INDEX_UPPER_BOUND"},
+			Destination: &cfg.Accounts.Index.GID.Upper,
+		},
+		&cli.StringFlag{
+			Name:        "settings-data-path",
+			Value:       "/var/tmp/ocis/settings",
+			Usage:       "Mount path for the settings storage",
+			EnvVars:     []string{"SETTINGS_DATA_PATH"},
+			Destination: &cfg.Settings.Service.DataPath,
+		},
+		&cli.StringFlag{
+			Name:        "store-data-path",
+			Value:       "/var/tmp/ocis/store",
+			Usage:       "location of the store data path",
+			EnvVars:     []string{"STORE_DATA_PATH"},
+			Destination: &cfg.Store.Datapath,
+		},
+		&cli.StringFlag{
+			Name:        "idp-identifier-registration-conf",
+			Value:       "./config/identifier-registration.yaml",
+			Usage:       "Path to a identifier-registration.yaml configuration file",
+			EnvVars:     []string{"IDP_IDENTIFIER_REGISTRATION_CONF"},
+			Destination: &cfg.IDP.IDP.IdentifierRegistrationConf,
+		},
+		&cli.StringFlag{
+			Name:        "idp-identifier-scopes-conf",
+			Value:       "",
+			Usage:       "Path to a scopes.yaml configuration file",
+			EnvVars:     []string{"IDP_IDENTIFIER_SCOPES_CONF"},
+			Destination: &cfg.IDP.IDP.IdentifierScopesConf,
+		},
+		&cli.StringFlag{
+			Name:        "idp-encryption-secret",
+			Value:       "",
+			Usage:       "Full path to a file containing the encryption secret key",
+			EnvVars:     []string{"IDP_ENCRYPTION_SECRET"},
+			Destination: &cfg.IDP.IDP.EncryptionSecretFile,
+		},
+		&cli.StringFlag{
+			Name:        "idp-validation-keys-path",
+			Value:       "",
+			Usage:       "Full path to a folder containg PEM encoded private or public key files used for token validaton",
+			EnvVars:     []string{"IDP_VALIDATION_KEYS_PATH"},
+			Destination: &cfg.IDP.IDP.ValidationKeysPath,
+		},
+		&cli.StringSliceFlag{
+			Name:    "idp-signing-private-key",
+			Usage:   "Full path to PEM encoded private key file",
+			EnvVars: []string{"IDP_SIGNING_PRIVATE_KEY"},
+			Value:   nil,
+		},
+	}
+	return append(flags, storeflagset.BackendWithConfig(cfg.Store)...)
+}
+
+// VerifyBackupWithConfig applies cfg to the flagset of the backup verify command.
+func VerifyBackupWithConfig(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "file",
+			Aliases:  []string{"f"},
+			Usage:    "Path to the backup archive",
+			Required: true,
+		},
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/oklog/run"
 	openzipkin "github.com/openzipkin/zipkin-go"
 	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
 	"github.com/owncloud/ocis/ocs/pkg/config"
 	"github.com/owncloud/ocis/ocs/pkg/flagset"
 	"github.com/owncloud/ocis/ocs/pkg/metrics"
@@ -33,6 +34,10 @@ func Server(cfg *config.Config) *cli.Command {
 			if cfg.HTTP.Root != "/" {
 				cfg.HTTP.Root = strings.TrimSuffix(cfg.HTTP.Root, "/")
 			}
+			cfg.TrustedProxies = c.StringSlice("trusted-proxies")
+			if _, err := signedurl.ParseTrustedProxies(cfg.TrustedProxies); err != nil {
+				return err
+			}
 
 			return ParseConfig(c, cfg)
 		},
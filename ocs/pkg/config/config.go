@@ -42,6 +42,16 @@ type TokenManager struct {
 	JWTSecret string
 }
 
+// SigningKeys configures the signing keys used for pre-signed urls.
+type SigningKeys struct {
+	// RotationInterval in seconds after which a new signing key is generated, 0 disables rotation
+	RotationInterval int
+	// GracePeriod in seconds during which rotated keys can still be used to verify urls
+	GracePeriod int
+	// Algorithm used when signing urls on behalf of a user
+	Algorithm string
+}
+
 // Config combines all available configuration parts.
 type Config struct {
 	File         string
@@ -51,6 +61,10 @@ type Config struct {
 	Tracing      Tracing
 	TokenManager TokenManager
 	Service      Service
+	SigningKeys  SigningKeys
+	// TrustedProxies are the addresses of the reverse proxies, only their X-Forwarded-Proto and X-Forwarded-Host
+	// headers are used to reconstruct the urls the clients requested
+	TrustedProxies []string
 }
 
 // New initializes a new configuration with or without defaults.
@@ -152,6 +152,34 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			EnvVars:     []string{"OCS_JWT_SECRET"},
 			Destination: &cfg.TokenManager.JWTSecret,
 		},
+
+		&cli.IntFlag{
+			Name:        "signing-key-rotation-interval",
+			Value:       2592000,
+			Usage:       "Interval in seconds after which the signing key of a user is rotated, 0 disables rotation",
+			EnvVars:     []string{"OCS_SIGNING_KEY_ROTATION_INTERVAL"},
+			Destination: &cfg.SigningKeys.RotationInterval,
+		},
+		&cli.IntFlag{
+			Name:        "signing-key-grace-period",
+			Value:       604800,
+			Usage:       "Time in seconds a rotated signing key stays valid for verifying urls",
+			EnvVars:     []string{"OCS_SIGNING_KEY_GRACE_PERIOD"},
+			Destination: &cfg.SigningKeys.GracePeriod,
+		},
+		&cli.StringFlag{
+			Name:        "signed-url-algorithm",
+			Value:       "HMAC-SHA256",
+			Usage:       "Signature algorithm for urls signed by the server, either HMAC-SHA256 or PBKDF2/10000-SHA512",
+			EnvVars:     []string{"OCS_SIGNED_URL_ALGORITHM"},
+			Destination: &cfg.SigningKeys.Algorithm,
+		},
+		&cli.StringSliceFlag{
+			Name:    "trusted-proxies",
+			Value:   cli.NewStringSlice("127.0.0.1", "::1"),
+			Usage:   "--trusted-proxies 127.0.0.1 [--trusted-proxies 10.0.0.0/8], reverse proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honoured",
+			EnvVars: []string{"OCS_TRUSTED_PROXIES"},
+		},
 	}
 }
 
@@ -397,6 +397,30 @@ func assertStatusCode(t *testing.T, statusCode int, res *httptest.ResponseRecord
 	}
 }
 
+type AppToken struct {
+	ID    string `json:"id" xml:"id"`
+	Label string `json:"label" xml:"label"`
+	Scope string `json:"scope" xml:"scope"`
+	Token string `json:"token" xml:"token"`
+}
+
+type AppTokenResponse struct {
+	Ocs struct {
+		Meta Meta     `json:"meta" xml:"meta"`
+		Data AppToken `json:"data" xml:"data"`
+	} `json:"ocs" xml:"ocs"`
+}
+
+type ListAppTokensResponse struct {
+	Ocs struct {
+		Meta Meta `json:"meta" xml:"meta"`
+		Data struct {
+			User   string     `json:"user" xml:"user"`
+			Tokens []AppToken `json:"tokens" xml:"tokens>element"`
+		} `json:"data" xml:"data"`
+	} `json:"ocs" xml:"ocs"`
+}
+
 type GetGroupsResponse struct {
 	Ocs struct {
 		Meta Meta `json:"meta" xml:"meta"`
@@ -1516,6 +1540,69 @@ func TestGetUserSigningKey(t *testing.T) {
 	}
 }
 
+func TestAppTokens(t *testing.T) {
+	for _, ocsVersion := range ocsVersions {
+		for _, format := range formats {
+			formatpart := getFormatString(format)
+			endpoint := fmt.Sprintf("/%v/cloud/user/app-tokens%v", ocsVersion, formatpart)
+			einstein := &User{ID: userIDEinstein}
+
+			res, err := sendRequest("POST", endpoint, "label=sync+client&scope=read-only", einstein, []string{ssvc.BundleUUIDRoleUser})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var created AppTokenResponse
+			if format == "json" {
+				err = json.Unmarshal(res.Body.Bytes(), &created)
+			} else {
+				err = xml.Unmarshal(res.Body.Bytes(), &created.Ocs)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStatusCode(t, 200, res, ocsVersion)
+			assert.True(t, created.Ocs.Meta.Success(ocsVersion), unsuccessfulResponseText)
+			assert.Equal(t, "sync client", created.Ocs.Data.Label)
+			assert.Equal(t, "read-only", created.Ocs.Data.Scope)
+			assert.NotEmpty(t, created.Ocs.Data.Token)
+
+			res, err = sendRequest("GET", endpoint, "", einstein, []string{ssvc.BundleUUIDRoleUser})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var list ListAppTokensResponse
+			if format == "json" {
+				err = json.Unmarshal(res.Body.Bytes(), &list)
+			} else {
+				err = xml.Unmarshal(res.Body.Bytes(), &list.Ocs)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, list.Ocs.Meta.Success(ocsVersion), unsuccessfulResponseText)
+			assert.Len(t, list.Ocs.Data.Tokens, 1)
+			assert.Equal(t, created.Ocs.Data.ID, list.Ocs.Data.Tokens[0].ID)
+			assert.Empty(t, list.Ocs.Data.Tokens[0].Token)
+
+			deleteEndpoint := fmt.Sprintf("/%v/cloud/user/app-tokens/%v%v", ocsVersion, created.Ocs.Data.ID, formatpart)
+			res, err = sendRequest("DELETE", deleteEndpoint, "", einstein, []string{ssvc.BundleUUIDRoleUser})
+			if err != nil {
+				t.Fatal(err)
+			}
+			response := assertEmptyResponse(t, format, res)
+			assert.True(t, response.Ocs.Meta.Success(ocsVersion), unsuccessfulResponseText)
+
+			res, err = sendRequest("DELETE", deleteEndpoint, "", einstein, []string{ssvc.BundleUUIDRoleUser})
+			if err != nil {
+				t.Fatal(err)
+			}
+			response = assertEmptyResponse(t, format, res)
+			assertStatusCode(t, 404, res, ocsVersion)
+			assert.Equal(t, 998, response.Ocs.Meta.StatusCode)
+		}
+	}
+}
+
 func AddUserToGroup(userid, groupid string) error {
 	res, err := sendRequest(
 		"POST",
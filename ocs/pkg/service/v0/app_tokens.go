@@ -0,0 +1,130 @@
+package svc
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	merrors "github.com/micro/go-micro/v2/errors"
+	accounts "github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/ocs/pkg/service/v0/data"
+	"github.com/owncloud/ocis/ocs/pkg/service/v0/response"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListAppTokens lists the app tokens of a user
+func (o Ocs) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := o.getAccountService().ListAppTokens(r.Context(), &accounts.ListAppTokensRequest{
+		AccountId: userID,
+	})
+	if err != nil {
+		o.renderAppTokenError(w, r, err)
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not list app tokens")
+		return
+	}
+
+	d := &data.AppTokens{
+		User:   userID,
+		Tokens: make([]*data.AppToken, 0, len(res.AppTokens)),
+	}
+	for _, t := range res.AppTokens {
+		d.Tokens = append(d.Tokens, newAppTokenData(t))
+	}
+	render.Render(w, r, response.DataRender(d))
+}
+
+// CreateAppToken creates an app token for a user. The token can be used as password for basic auth and is only
+// returned in this response. The optional expires parameter sets the validity in seconds.
+func (o Ocs) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	req := &accounts.CreateAppTokenRequest{
+		AccountId: userID,
+		Label:     r.PostFormValue("label"),
+		Scope:     r.PostFormValue("scope"),
+	}
+	if e := r.PostFormValue("expires"); e != "" {
+		expires, err := strconv.Atoi(e)
+		if err != nil || expires < 1 {
+			render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "expires must be a positive number of seconds"))
+			return
+		}
+		req.ExpirationDateTime = timestamppb.New(time.Now().Add(time.Duration(expires) * time.Second))
+	}
+
+	res, err := o.getAccountService().CreateAppToken(r.Context(), req)
+	if err != nil {
+		o.renderAppTokenError(w, r, err)
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not create app token")
+		return
+	}
+
+	o.logger.Debug().Str("userid", userID).Str("tokenid", res.AppToken.Id).Msg("created app token")
+	d := newAppTokenData(res.AppToken)
+	d.Token = res.Token
+	render.Render(w, r, response.DataRender(d))
+}
+
+// DeleteAppToken revokes the app token given by the tokenid url parameter
+func (o Ocs) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	tokenID := chi.URLParam(r, "tokenid")
+	_, err := o.getAccountService().DeleteAppToken(r.Context(), &accounts.DeleteAppTokenRequest{
+		AccountId: userID,
+		Id:        tokenID,
+	})
+	if err != nil {
+		o.renderAppTokenError(w, r, err)
+		o.logger.Error().Err(err).Str("userid", userID).Str("tokenid", tokenID).Msg("could not delete app token")
+		return
+	}
+
+	o.logger.Debug().Str("userid", userID).Str("tokenid", tokenID).Msg("deleted app token")
+	render.Render(w, r, response.DataRender(struct{}{}))
+}
+
+func (o Ocs) renderAppTokenError(w http.ResponseWriter, r *http.Request, err error) {
+	merr := merrors.FromError(err)
+	switch merr.Code {
+	case http.StatusNotFound:
+		render.Render(w, r, response.ErrRender(data.MetaNotFound.StatusCode, merr.Detail))
+	case http.StatusBadRequest:
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, merr.Detail))
+	case http.StatusForbidden:
+		render.Render(w, r, response.ErrRender(data.MetaForbidden.StatusCode, merr.Detail))
+	default:
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, err.Error()))
+	}
+}
+
+func newAppTokenData(t *accounts.AppToken) *data.AppToken {
+	d := &data.AppToken{
+		ID:    t.Id,
+		Label: t.Label,
+		Scope: t.Scope,
+	}
+	if t.CreatedDateTime != nil {
+		d.Created = t.CreatedDateTime.AsTime().UTC().Format(time.RFC3339)
+	}
+	if t.LastUsedDateTime != nil {
+		d.LastUsed = t.LastUsedDateTime.AsTime().UTC().Format(time.RFC3339)
+	}
+	if t.ExpirationDateTime != nil {
+		d.Expires = t.ExpirationDateTime.AsTime().UTC().Format(time.RFC3339)
+	}
+	return d
+}
@@ -30,4 +30,43 @@ type Quota struct {
 type SigningKey struct {
 	User       string `json:"user" xml:"user"`
 	SigningKey string `json:"signing-key" xml:"signing-key"`
+	KeyID      string `json:"key-id" xml:"key-id"`
+}
+
+// SigningKeys holds the Payload for a ListSigningKeys response
+type SigningKeys struct {
+	User string            `json:"user" xml:"user"`
+	Keys []*SigningKeyInfo `json:"keys" xml:"keys>element"`
+}
+
+// SigningKeyInfo describes a signing key without its key material
+type SigningKeyInfo struct {
+	ID      string `json:"id" xml:"id"`
+	Created string `json:"created,omitempty" xml:"created,omitempty"`
+	Expires string `json:"expires,omitempty" xml:"expires,omitempty"`
+	Current bool   `json:"current" xml:"current"`
+}
+
+// SignedURL holds the Payload for a GetSignedURL response
+type SignedURL struct {
+	URL     string `json:"url" xml:"url"`
+	Verb    string `json:"verb" xml:"verb"`
+	Expires string `json:"expires" xml:"expires"`
+}
+
+// AppTokens holds the Payload for a ListAppTokens response
+type AppTokens struct {
+	User   string      `json:"user" xml:"user"`
+	Tokens []*AppToken `json:"tokens" xml:"tokens>element"`
+}
+
+// AppToken describes an app token. Token is only set once, when the app token is created.
+type AppToken struct {
+	ID       string `json:"id" xml:"id"`
+	Label    string `json:"label" xml:"label"`
+	Scope    string `json:"scope" xml:"scope"`
+	Created  string `json:"created,omitempty" xml:"created,omitempty"`
+	LastUsed string `json:"last-used,omitempty" xml:"last-used,omitempty"`
+	Expires  string `json:"expires,omitempty" xml:"expires,omitempty"`
+	Token    string `json:"token,omitempty" xml:"token,omitempty"`
 }
@@ -15,11 +15,13 @@ import (
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	opkgm "github.com/owncloud/ocis/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/ocis-pkg/roles"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
 	"github.com/owncloud/ocis/ocs/pkg/config"
 	ocsm "github.com/owncloud/ocis/ocs/pkg/middleware"
 	"github.com/owncloud/ocis/ocs/pkg/service/v0/data"
 	"github.com/owncloud/ocis/ocs/pkg/service/v0/response"
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
+	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
 )
 
 var defaultClient = grpc.NewClient()
@@ -52,11 +54,17 @@ func NewService(opts ...Option) Service {
 		roleManager = &m
 	}
 
+	trustedProxies, err := signedurl.ParseTrustedProxies(options.Config.TrustedProxies)
+	if err != nil {
+		options.Logger.Fatal().Err(err).Msg("invalid trusted proxies")
+	}
+
 	svc := Ocs{
-		config:      options.Config,
-		mux:         m,
-		RoleManager: roleManager,
-		logger:      options.Logger,
+		config:         options.Config,
+		mux:            m,
+		RoleManager:    roleManager,
+		logger:         options.Logger,
+		trustedProxies: trustedProxies,
 	}
 
 	requireUser := ocsm.RequireUser()
@@ -87,6 +95,18 @@ func NewService(opts ...Option) Service {
 				r.Route("/user", func(r chi.Router) {
 					r.With(requireSelfOrAdmin).Get("/", svc.GetSelf)
 					r.Get("/signing-key", svc.GetSigningKey)
+					r.Get("/signed-url", svc.GetSignedURL)
+					r.Route("/signing-keys", func(r chi.Router) {
+						r.Get("/", svc.ListSigningKeys)
+						r.Post("/", svc.RotateSigningKey)
+						r.Delete("/", svc.DeleteSigningKeys)
+						r.Delete("/{keyid}", svc.DeleteSigningKeys)
+					})
+					r.Route("/app-tokens", func(r chi.Router) {
+						r.Get("/", svc.ListAppTokens)
+						r.Post("/", svc.CreateAppToken)
+						r.Delete("/{tokenid}", svc.DeleteAppToken)
+					})
 				})
 
 				// for /users endpoints see https://github.com/owncloud/core/blob/master/apps/provisioning_api/appinfo/routes.php#L44-L56
@@ -107,6 +127,19 @@ func NewService(opts ...Option) Service {
 						r.With(requireAdmin).Delete("/", svc.RemoveFromGroup)
 					})
 
+					r.Route("/{userid}/signing-keys", func(r chi.Router) {
+						r.With(requireSelfOrAdmin).Get("/", svc.ListSigningKeys)
+						r.With(requireSelfOrAdmin).Post("/", svc.RotateSigningKey)
+						r.With(requireSelfOrAdmin).Delete("/", svc.DeleteSigningKeys)
+						r.With(requireSelfOrAdmin).Delete("/{keyid}", svc.DeleteSigningKeys)
+					})
+
+					r.Route("/{userid}/app-tokens", func(r chi.Router) {
+						r.With(requireSelfOrAdmin).Get("/", svc.ListAppTokens)
+						r.With(requireSelfOrAdmin).Post("/", svc.CreateAppToken)
+						r.With(requireSelfOrAdmin).Delete("/{tokenid}", svc.DeleteAppToken)
+					})
+
 					r.Route("/{userid}/subadmins", func(r chi.Router) {
 						r.With(requireAdmin).Post("/", svc.NotImplementedStub)
 						r.With(requireSelfOrAdmin).Get("/", svc.NotImplementedStub)
@@ -139,6 +172,8 @@ type Ocs struct {
 	RoleService settings.RoleService
 	RoleManager *roles.Manager
 	mux         *chi.Mux
+	// trustedProxies may set the forwarded headers of signed urls
+	trustedProxies signedurl.TrustedProxies
 }
 
 // ServeHTTP implements the Service interface.
@@ -159,6 +194,10 @@ func (o Ocs) getGroupsService() accounts.GroupsService {
 	return accounts.NewGroupsService("com.owncloud.api.accounts", defaultClient)
 }
 
+func (o Ocs) getStoreService() storepb.StoreService {
+	return storepb.NewStoreService("com.owncloud.api.store", defaultClient)
+}
+
 // NotImplementedStub returns a not implemented error
 func (o Ocs) NotImplementedStub(w http.ResponseWriter, r *http.Request) {
 	render.Render(w, r, response.ErrRender(data.MetaUnknownError.StatusCode, "Not implemented"))
@@ -0,0 +1,328 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	merrors "github.com/micro/go-micro/v2/errors"
+	accounts "github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/ocs/pkg/service/v0/data"
+	"github.com/owncloud/ocis/ocs/pkg/service/v0/response"
+	"github.com/owncloud/ocis/store/pkg/signingkeys"
+)
+
+// defaultSignedURLExpiry is used when a signed url is requested without an expiry
+const defaultSignedURLExpiry = 600
+
+// errSigningKeyNotFound is returned by keyring updates when the key to revoke does not exist
+var errSigningKeyNotFound = errors.New("signing key not found")
+
+// GetSigningKey returns the signing key for the current user. It will create it on the fly if it does not exist
+// The signing key is part of the user settings and is used by the proxy to authenticate requests
+// Currently, the username is used as the OC-Credential
+func (o Ocs) GetSigningKey(w http.ResponseWriter, r *http.Request) {
+	u, ok := user.ContextGetUser(r.Context())
+	if !ok {
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "missing user in context"))
+		return
+	}
+
+	// use the user's UUID
+	userID := u.Id.OpaqueId
+
+	key, err := o.currentSigningKey(r.Context(), userID)
+	if _, ok := err.(signingkeys.ReadError); ok {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not read signing keys")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
+		return
+	}
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not persist signing key")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not persist signing key"))
+		return
+	}
+
+	render.Render(w, r, response.DataRender(&data.SigningKey{
+		User:       userID,
+		SigningKey: key.Secret,
+		KeyID:      key.ID,
+	}))
+}
+
+// GetSignedURL signs a url for the given path on behalf of the current user, so clients don't need to compute the
+// signature themselves.
+func (o Ocs) GetSignedURL(w http.ResponseWriter, r *http.Request) {
+	u, ok := user.ContextGetUser(r.Context())
+	if !ok {
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "missing user in context"))
+		return
+	}
+
+	q := r.URL.Query()
+	target, err := url.Parse(q.Get("path"))
+	if err != nil || target.IsAbs() || !strings.HasPrefix(target.Path, "/") {
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "path must be an absolute path"))
+		return
+	}
+
+	verb := strings.ToUpper(q.Get("verb"))
+	if verb == "" {
+		verb = http.MethodGet
+	}
+
+	expires := defaultSignedURLExpiry
+	if e := q.Get("expires"); e != "" {
+		if expires, err = strconv.Atoi(e); err != nil || expires < 1 || expires > signedurl.MaxExpires {
+			render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "expires must be a number of seconds between 1 and "+strconv.Itoa(signedurl.MaxExpires)))
+			return
+		}
+	}
+
+	algorithm := q.Get("algorithm")
+	if algorithm == "" {
+		algorithm = o.config.SigningKeys.Algorithm
+	}
+	if !isSupportedAlgorithm(algorithm) {
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "unsupported signature algorithm"))
+		return
+	}
+
+	key, err := o.currentSigningKey(r.Context(), u.Id.OpaqueId)
+	if _, ok := err.(signingkeys.ReadError); ok {
+		o.logger.Error().Err(err).Str("userid", u.Id.OpaqueId).Msg("could not read signing keys")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
+		return
+	}
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", u.Id.OpaqueId).Msg("could not persist signing key")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not persist signing key"))
+		return
+	}
+
+	base := signedurl.RequestURL(r, o.trustedProxies)
+	base.Path, base.RawPath, base.RawQuery = "", "", ""
+	now := time.Now()
+	signed, err := signedurl.Sign(base.ResolveReference(target), signedurl.Params{
+		Credential: u.Username,
+		Verb:       verb,
+		Date:       now,
+		Expires:    expires,
+		Algorithm:  algorithm,
+	}, key)
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", u.Id.OpaqueId).Msg("could not sign url")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not sign url"))
+		return
+	}
+
+	render.Render(w, r, response.DataRender(&data.SignedURL{
+		URL:     signed,
+		Verb:    verb,
+		Expires: now.Add(time.Duration(expires) * time.Second).UTC().Format(time.RFC3339),
+	}))
+}
+
+// ListSigningKeys lists the signing keys of a user without exposing the key material
+func (o Ocs) ListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	keyring, _, err := o.signingKeyrings().Read(r.Context(), userID)
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not read signing keys")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
+		return
+	}
+
+	render.Render(w, r, response.DataRender(newSigningKeysData(userID, keyring)))
+}
+
+// RotateSigningKey generates a new signing key for a user. The previous keys stay valid for the configured grace
+// period, which can be overridden with the grace query parameter. Use grace=0 to invalidate them immediately.
+func (o Ocs) RotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	grace := o.config.SigningKeys.GracePeriod
+	if g := r.URL.Query().Get("grace"); g != "" {
+		var err error
+		if grace, err = strconv.Atoi(g); err != nil || grace < 0 {
+			render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "grace must be a positive number of seconds"))
+			return
+		}
+	}
+
+	keyring, err := o.signingKeyrings().Update(r.Context(), userID, func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+		if keyring == nil {
+			keyring = &signedurl.Keyring{}
+		}
+		_, err := keyring.Rotate(time.Now(), time.Duration(grace)*time.Second)
+		return keyring, true, err
+	})
+	if _, ok := err.(signingkeys.ReadError); ok {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not read signing keys")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
+		return
+	}
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not persist signing key")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not persist signing key"))
+		return
+	}
+
+	o.logger.Debug().Str("userid", userID).Msg("rotated signing key")
+	render.Render(w, r, response.DataRender(newSigningKeysData(userID, keyring)))
+}
+
+// DeleteSigningKeys revokes the signing key given by the keyid url parameter or, without it, all signing keys of a
+// user. The proxy reads the keys on every request, so urls signed with a revoked key are rejected immediately.
+func (o Ocs) DeleteSigningKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := o.targetAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	keyID := chi.URLParam(r, "keyid")
+	if keyID == "" {
+		if err := o.signingKeyrings().Delete(r.Context(), userID); err != nil {
+			o.logger.Error().Err(err).Str("userid", userID).Msg("could not delete signing keys")
+			render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not delete signing keys"))
+			return
+		}
+		o.logger.Debug().Str("userid", userID).Msg("revoked all signing keys")
+		render.Render(w, r, response.DataRender(struct{}{}))
+		return
+	}
+
+	_, err := o.signingKeyrings().Update(r.Context(), userID, func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+		if keyring == nil || !keyring.Revoke(keyID) {
+			return nil, false, errSigningKeyNotFound
+		}
+		return keyring, true, nil
+	})
+	if _, ok := err.(signingkeys.ReadError); ok {
+		o.logger.Error().Err(err).Str("userid", userID).Msg("could not read signing keys")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
+		return
+	}
+	if err == errSigningKeyNotFound {
+		render.Render(w, r, response.ErrRender(data.MetaNotFound.StatusCode, "The requested signing key could not be found"))
+		return
+	}
+	if err != nil {
+		o.logger.Error().Err(err).Str("userid", userID).Str("keyid", keyID).Msg("could not revoke signing key")
+		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not revoke signing key"))
+		return
+	}
+
+	o.logger.Debug().Str("userid", userID).Str("keyid", keyID).Msg("revoked signing key")
+	render.Render(w, r, response.DataRender(struct{}{}))
+}
+
+// targetAccountID resolves the account id of the user whose signing keys or app tokens are managed. The userid url
+// parameter may be an account id or a username, without it this is the current user. It renders an error response and returns false if the user cannot be
+// resolved.
+func (o Ocs) targetAccountID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	u, ok := user.ContextGetUser(r.Context())
+	if !ok || u.Id == nil || u.Id.OpaqueId == "" {
+		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "missing user in context"))
+		return "", false
+	}
+
+	userid := chi.URLParam(r, "userid")
+	if userid == "" || userid == u.Id.OpaqueId || userid == u.Username {
+		return u.Id.OpaqueId, true
+	}
+
+	// the userid may be an account id or a username
+	account, err := o.getAccountService().GetAccount(r.Context(), &accounts.GetAccountRequest{
+		Id: userid,
+	})
+	if err != nil && merrors.FromError(err).Code == http.StatusNotFound {
+		account, err = o.fetchAccountByUsername(r.Context(), userid)
+	}
+	if err != nil {
+		if merrors.FromError(err).Code == http.StatusNotFound {
+			render.Render(w, r, response.ErrRender(data.MetaNotFound.StatusCode, "The requested user could not be found"))
+		} else {
+			render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, err.Error()))
+		}
+		o.logger.Error().Err(err).Str("userid", userid).Msg("could not get account for user")
+		return "", false
+	}
+	return account.Id, true
+}
+
+func newSigningKeysData(userID string, keyring *signedurl.Keyring) *data.SigningKeys {
+	d := &data.SigningKeys{
+		User: userID,
+		Keys: []*data.SigningKeyInfo{},
+	}
+	if keyring == nil {
+		return d
+	}
+
+	current, _ := keyring.Current()
+	for _, k := range keyring.Keys {
+		info := &data.SigningKeyInfo{
+			ID:      k.ID,
+			Current: k.ID == current.ID && k.ExpiresAt.IsZero(),
+		}
+		if !k.CreatedAt.IsZero() {
+			info.Created = k.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		if !k.ExpiresAt.IsZero() {
+			info.Expires = k.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		d.Keys = append(d.Keys, info)
+	}
+	return d
+}
+
+func isSupportedAlgorithm(algorithm string) bool {
+	for _, a := range signedurl.Algorithms {
+		if a == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// currentSigningKey returns the key that should be used to sign urls for the user. It creates the keyring if it
+// does not exist yet and rotates the key when the rotation interval has passed. When several requests do this
+// concurrently only one of them writes a new key, the others use it.
+func (o Ocs) currentSigningKey(ctx context.Context, userID string) (signedurl.Key, error) {
+	keyring, err := o.signingKeyrings().Update(ctx, userID, func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+		now := time.Now()
+		if keyring == nil {
+			keyring, err := signedurl.NewKeyring(now)
+			return keyring, true, err
+		}
+		if keyring.NeedsRotation(now, time.Duration(o.config.SigningKeys.RotationInterval)*time.Second) {
+			_, err := keyring.Rotate(now, time.Duration(o.config.SigningKeys.GracePeriod)*time.Second)
+			return keyring, true, err
+		}
+		return keyring, false, nil
+	})
+	if err != nil {
+		return signedurl.Key{}, err
+	}
+	return keyring.Current()
+}
+
+func (o Ocs) signingKeyrings() signingkeys.Keyrings {
+	return signingkeys.New(o.getStoreService())
+}
@@ -2,8 +2,6 @@ package svc
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -15,12 +13,10 @@ import (
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
-	"github.com/micro/go-micro/v2/client/grpc"
 	merrors "github.com/micro/go-micro/v2/errors"
 	accounts "github.com/owncloud/ocis/accounts/pkg/proto/v0"
 	"github.com/owncloud/ocis/ocs/pkg/service/v0/data"
 	"github.com/owncloud/ocis/ocs/pkg/service/v0/response"
-	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
 )
 
 // GetSelf returns the currently logged in user
@@ -410,78 +406,6 @@ func (o Ocs) DisableUser(w http.ResponseWriter, r *http.Request) {
 	render.Render(w, r, response.DataRender(struct{}{}))
 }
 
-// GetSigningKey returns the signing key for the current user. It will create it on the fly if it does not exist
-// The signing key is part of the user settings and is used by the proxy to authenticate requests
-// Currently, the username is used as the OC-Credential
-func (o Ocs) GetSigningKey(w http.ResponseWriter, r *http.Request) {
-	u, ok := user.ContextGetUser(r.Context())
-	if !ok {
-		//o.logger.Error().Msg("missing user in context")
-		render.Render(w, r, response.ErrRender(data.MetaBadRequest.StatusCode, "missing user in context"))
-		return
-	}
-
-	// use the user's UUID
-	userID := u.Id.OpaqueId
-
-	c := storepb.NewStoreService("com.owncloud.api.store", grpc.NewClient())
-	res, err := c.Read(r.Context(), &storepb.ReadRequest{
-		Options: &storepb.ReadOptions{
-			Database: "proxy",
-			Table:    "signing-keys",
-		},
-		Key: userID,
-	})
-	if err == nil && len(res.Records) > 0 {
-		render.Render(w, r, response.DataRender(&data.SigningKey{
-			User:       userID,
-			SigningKey: string(res.Records[0].Value),
-		}))
-		return
-	}
-	if err != nil {
-		e := merrors.Parse(err.Error())
-		if e.Code == http.StatusNotFound {
-			// not found is ok, so we can continue and generate the key on the fly
-		} else {
-			render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "error reading from store"))
-			return
-		}
-	}
-
-	// try creating it
-	key := make([]byte, 64)
-	_, err = rand.Read(key[:])
-	if err != nil {
-		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not generate signing key"))
-		return
-	}
-	signingKey := hex.EncodeToString(key)
-
-	_, err = c.Write(r.Context(), &storepb.WriteRequest{
-		Options: &storepb.WriteOptions{
-			Database: "proxy",
-			Table:    "signing-keys",
-		},
-		Record: &storepb.Record{
-			Key:   userID,
-			Value: []byte(signingKey),
-			// TODO Expiry?
-		},
-	})
-
-	if err != nil {
-		//o.logger.Error().Err(err).Msg("error writing key")
-		render.Render(w, r, response.ErrRender(data.MetaServerError.StatusCode, "could not persist signing key"))
-		return
-	}
-
-	render.Render(w, r, response.DataRender(&data.SigningKey{
-		User:       userID,
-		SigningKey: signingKey,
-	}))
-}
-
 // ListUsers lists the users
 func (o Ocs) ListUsers(w http.ResponseWriter, r *http.Request) {
 	search := r.URL.Query().Get("search")
@@ -40,6 +40,7 @@ func Execute() error {
 		Commands: []*cli.Command{
 			Server(cfg),
 			Health(cfg),
+			SigningKeys(cfg),
 			PrintVersion(cfg),
 		},
 	}
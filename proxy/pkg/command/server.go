@@ -23,8 +23,12 @@ import (
 	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
 	acc "github.com/owncloud/ocis/accounts/pkg/proto/v0"
 	"github.com/owncloud/ocis/ocis-pkg/conversions"
+	"github.com/owncloud/ocis/ocis-pkg/events"
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/roles"
 	"github.com/owncloud/ocis/ocis-pkg/service/grpc"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 	"github.com/owncloud/ocis/proxy/pkg/cs3"
 	"github.com/owncloud/ocis/proxy/pkg/flagset"
@@ -51,6 +55,11 @@ func Server(cfg *config.Config) *cli.Command {
 				cfg.HTTP.Root = strings.TrimSuffix(cfg.HTTP.Root, "/")
 			}
 			cfg.PreSignedURL.AllowedHTTPMethods = ctx.StringSlice("presignedurl-allow-method")
+			cfg.PreSignedURL.AllowedAlgorithms = ctx.StringSlice("presignedurl-allow-algorithm")
+			cfg.TrustedProxies = ctx.StringSlice("trusted-proxies")
+			if _, err := signedurl.ParseTrustedProxies(cfg.TrustedProxies); err != nil {
+				return err
+			}
 
 			if err := loadUserAgent(ctx, cfg); err != nil {
 				return err
@@ -164,9 +173,17 @@ func Server(cfg *config.Config) *cli.Command {
 
 			metrics.BuildInfo.WithLabelValues(cfg.Service.Version).Set(1)
 
+			roleManager := roles.NewManager(
+				roles.CacheSize(1024),
+				roles.CacheTTL(time.Hour*24*7),
+				roles.Logger(logger),
+				roles.RoleService(settings.NewRoleService("com.owncloud.api.settings", grpc.DefaultClient)),
+			)
+
 			rp := proxy.NewMultiHostReverseProxy(
 				proxy.Logger(logger),
 				proxy.Config(cfg),
+				proxy.RoleManager(&roleManager),
 			)
 
 			{
@@ -293,7 +310,15 @@ func loadMiddlewares(ctx context.Context, l log.Logger, cfg *config.Config) alic
 		Timeout: time.Second * 10,
 	}
 
-	return alice.New(
+	trustedProxies, err := signedurl.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		l.Fatal().Err(err).Msg("invalid trusted proxies")
+	}
+
+	// the account resolver invalidates cached userinfo of tokens that were issued before the sessions were revoked
+	userinfoCache := sync.NewCache(cfg.OIDC.UserinfoCache.Size)
+
+	chain := alice.New(
 		middleware.HTTPSRedirect,
 		middleware.Authentication(
 			// OIDC Options
@@ -309,6 +334,7 @@ func loadMiddlewares(ctx context.Context, l log.Logger, cfg *config.Config) alic
 			middleware.HTTPClient(oidcHTTPClient),
 			middleware.TokenCacheSize(cfg.OIDC.UserinfoCache.Size),
 			middleware.TokenCacheTTL(time.Second*time.Duration(cfg.OIDC.UserinfoCache.TTL)),
+			middleware.UserinfoCache(&userinfoCache),
 
 			// basic Options
 			middleware.Logger(l),
@@ -320,6 +346,7 @@ func loadMiddlewares(ctx context.Context, l log.Logger, cfg *config.Config) alic
 		middleware.SignedURLAuth(
 			middleware.Logger(l),
 			middleware.PreSignedURLConfig(cfg.PreSignedURL),
+			middleware.TrustedProxies(trustedProxies),
 			middleware.UserProvider(userProvider),
 			middleware.Store(storeClient),
 		),
@@ -328,6 +355,9 @@ func loadMiddlewares(ctx context.Context, l log.Logger, cfg *config.Config) alic
 			middleware.UserProvider(userProvider),
 			middleware.TokenManagerConfig(cfg.TokenManager),
 			middleware.AutoprovisionAccounts(cfg.AutoprovisionAccounts),
+			middleware.UserinfoCache(&userinfoCache),
+			middleware.TokenCacheTTL(time.Second*time.Duration(cfg.OIDC.UserinfoCache.TTL)),
+			middleware.MaxTokenLifetime(time.Second*time.Duration(cfg.OIDC.MaxTokenLifetime)),
 		),
 		middleware.CreateHome(
 			middleware.Logger(l),
@@ -335,6 +365,16 @@ func loadMiddlewares(ctx context.Context, l log.Logger, cfg *config.Config) alic
 			middleware.RevaGatewayClient(revaClient),
 		),
 	)
+
+	if cfg.PublishUploadEvents {
+		chain = chain.Append(middleware.UploadEvents(
+			middleware.Logger(l),
+			middleware.TokenManagerConfig(cfg.TokenManager),
+			middleware.EventsClient(events.NewClient()),
+		))
+	}
+
+	return chain
 }
 
 // loadUserAgent reads the proxy-user-agent-lock-in, since it is a string flag, and attempts to construct a map of
@@ -0,0 +1,160 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/micro/cli/v2"
+	tw "github.com/olekukonko/tablewriter"
+	"github.com/owncloud/ocis/ocis-pkg/service/grpc"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/proxy/pkg/config"
+	"github.com/owncloud/ocis/proxy/pkg/flagset"
+	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/signingkeys"
+)
+
+// SigningKeys is the entrypoint for the commands managing the pre-signed url signing keys.
+func SigningKeys(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "signing-keys",
+		Usage: "Manage the signing keys of pre-signed urls",
+		Subcommands: []*cli.Command{
+			ListSigningKeys(cfg),
+			RotateSigningKey(cfg),
+			DeleteSigningKeys(cfg),
+		},
+	}
+}
+
+// ListSigningKeys lists the signing keys of a user.
+func ListSigningKeys(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "list",
+		Usage:     "List the signing keys of a user",
+		ArgsUsage: "user-id",
+		Aliases:   []string{"ls"},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				fmt.Println("Please provide a user-id")
+				os.Exit(1)
+			}
+
+			keyring, _, err := signingKeyrings().Read(c.Context, c.Args().First())
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not read signing keys %w", err))
+				return err
+			}
+
+			buildSigningKeysTable(keyring).Render()
+			return nil
+		},
+	}
+}
+
+// RotateSigningKey generates a new signing key for a user.
+func RotateSigningKey(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:      "rotate",
+		Usage:     "Generate a new signing key for a user",
+		ArgsUsage: "user-id",
+		Flags:     flagset.RotateSigningKeyWithConfig(cfg),
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				fmt.Println("Please provide a user-id")
+				os.Exit(1)
+			}
+			if cfg.PreSignedURL.SigningKeyGracePeriod < 0 {
+				fmt.Println("The grace period must not be negative")
+				os.Exit(1)
+			}
+			grace := time.Duration(cfg.PreSignedURL.SigningKeyGracePeriod) * time.Second
+
+			keyring, err := signingKeyrings().Update(c.Context, c.Args().First(), func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+				if keyring == nil {
+					keyring = &signedurl.Keyring{}
+				}
+				_, err := keyring.Rotate(time.Now(), grace)
+				return keyring, true, err
+			})
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not rotate signing key %w", err))
+				return err
+			}
+
+			buildSigningKeysTable(keyring).Render()
+			return nil
+		},
+	}
+}
+
+// DeleteSigningKeys revokes one or all signing keys of a user.
+func DeleteSigningKeys(cfg *config.Config) *cli.Command {
+	var keyID string
+	return &cli.Command{
+		Name:      "delete",
+		Usage:     "Revoke the signing keys of a user",
+		ArgsUsage: "user-id",
+		Aliases:   []string{"rm"},
+		Flags:     flagset.DeleteSigningKeyWithConfig(cfg, &keyID),
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				fmt.Println("Please provide a user-id")
+				os.Exit(1)
+			}
+			userID := c.Args().First()
+
+			if keyID == "" {
+				if err := signingKeyrings().Delete(c.Context, userID); err != nil {
+					fmt.Println(fmt.Errorf("could not delete signing keys %w", err))
+					return err
+				}
+				return nil
+			}
+
+			_, err := signingKeyrings().Update(c.Context, userID, func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+				if keyring == nil || !keyring.Revoke(keyID) {
+					return nil, false, fmt.Errorf("signing key %s not found", keyID)
+				}
+				return keyring, true, nil
+			})
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not revoke signing key %w", err))
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// buildSigningKeysTable creates an ascii table for printing on the cli
+func buildSigningKeysTable(keyring *signedurl.Keyring) *tw.Table {
+	table := tw.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Id", "Created", "Expires", "Current"})
+	table.SetAutoFormatHeaders(false)
+	if keyring == nil {
+		return table
+	}
+	current, _ := keyring.Current()
+	for _, k := range keyring.Keys {
+		var created, expires string
+		if !k.CreatedAt.IsZero() {
+			created = k.CreatedAt.Format(time.RFC3339)
+		}
+		if !k.ExpiresAt.IsZero() {
+			expires = k.ExpiresAt.Format(time.RFC3339)
+		}
+		table.Append([]string{
+			k.ID,
+			created,
+			expires,
+			strconv.FormatBool(k.ID == current.ID && k.ExpiresAt.IsZero())})
+	}
+	return table
+}
+
+func signingKeyrings() signingkeys.Keyrings {
+	return signingkeys.New(storepb.NewStoreService("com.owncloud.api.store", grpc.DefaultClient))
+}
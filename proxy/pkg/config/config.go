@@ -57,6 +57,16 @@ type Route struct {
 	Endpoint    string
 	Backend     string
 	ApacheVHost bool `mapstructure:"apache-vhost"`
+	// Authorization restricts the route to users with certain roles or permissions
+	Authorization *RouteAuthorization
+}
+
+// RouteAuthorization defines the rules a request has to fulfill before it is proxied to the backend of a route
+type RouteAuthorization struct {
+	// Roles lists role ids, the user needs to have at least one of them
+	Roles []string
+	// Permissions lists permission ids, the roles of the user need to grant all of them
+	Permissions []string
 }
 
 // RouteType defines the type of a route
@@ -119,6 +129,10 @@ type Config struct {
 	AutoprovisionAccounts bool
 	EnableBasicAuth       bool
 	InsecureBackends      bool
+	PublishUploadEvents   bool
+	// TrustedProxies are the addresses of reverse proxies in front of the proxy, only their X-Forwarded-Proto and
+	// X-Forwarded-Host headers are honoured
+	TrustedProxies []string
 }
 
 // OIDC is the config for the OpenID-Connect middleware. If set the proxy will try to authenticate every request
@@ -127,6 +141,8 @@ type OIDC struct {
 	Issuer        string
 	Insecure      bool
 	UserinfoCache Cache
+	// MaxTokenLifetime in seconds is the longest lifetime of the access tokens issued by the provider.
+	MaxTokenLifetime int
 }
 
 // PolicySelector is the toplevel-configuration for different selectors
@@ -148,7 +164,10 @@ type TokenManager struct {
 // PreSignedURL is the config for the presigned url middleware
 type PreSignedURL struct {
 	AllowedHTTPMethods []string
+	AllowedAlgorithms  []string
 	Enabled            bool
+	// SigningKeyGracePeriod in seconds the previous signing keys stay valid after a rotation
+	SigningKeyGracePeriod int
 }
 
 // MigrationSelectorConf is the config for the migration-selector
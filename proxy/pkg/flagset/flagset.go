@@ -223,6 +223,13 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			EnvVars:     []string{"PROXY_OIDC_USERINFO_CACHE_SIZE"},
 			Destination: &cfg.OIDC.UserinfoCache.Size,
 		},
+		&cli.IntFlag{
+			Name:        "oidc-max-token-lifetime",
+			Value:       86400,
+			Usage:       "Max lifetime in seconds of access tokens, tokens without an issue time are rejected for this long after the sessions of an account were revoked",
+			EnvVars:     []string{"PROXY_OIDC_MAX_TOKEN_LIFETIME"},
+			Destination: &cfg.OIDC.MaxTokenLifetime,
+		},
 
 		&cli.BoolFlag{
 			Name:        "autoprovision-accounts",
@@ -239,6 +246,18 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			Usage:   "--presignedurl-allow-method GET [--presignedurl-allow-method POST]",
 			EnvVars: []string{"PRESIGNEDURL_ALLOWED_METHODS"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "trusted-proxies",
+			Value:   cli.NewStringSlice(),
+			Usage:   "--trusted-proxies 10.0.0.1 [--trusted-proxies 192.168.0.0/16], reverse proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honoured",
+			EnvVars: []string{"PROXY_TRUSTED_PROXIES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "presignedurl-allow-algorithm",
+			Value:   cli.NewStringSlice("PBKDF2/10000-SHA512", "HMAC-SHA256"),
+			Usage:   "--presignedurl-allow-algorithm HMAC-SHA256 [--presignedurl-allow-algorithm PBKDF2/10000-SHA512]",
+			EnvVars: []string{"PRESIGNEDURL_ALLOWED_ALGORITHMS"},
+		},
 		&cli.BoolFlag{
 			Name:        "enable-presignedurls",
 			Value:       true,
@@ -256,6 +275,14 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			Destination: &cfg.EnableBasicAuth,
 		},
 
+		&cli.BoolFlag{
+			Name:        "publish-upload-events",
+			Value:       false,
+			Usage:       "publish an event for every file uploaded through webdav, e.g. to pregenerate thumbnails",
+			EnvVars:     []string{"PROXY_PUBLISH_UPLOAD_EVENTS"},
+			Destination: &cfg.PublishUploadEvents,
+		},
+
 		&cli.StringFlag{
 			Name:        "account-backend-type",
 			Value:       "accounts",
@@ -292,3 +319,28 @@ func ListProxyWithConfig(cfg *config.Config) []cli.Flag {
 		},
 	}
 }
+
+// RotateSigningKeyWithConfig applies the config to the signing key rotate command flags.
+func RotateSigningKeyWithConfig(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "grace-period",
+			Value:       604800,
+			Usage:       "Time in seconds the previous signing keys stay valid, 0 revokes them immediately",
+			EnvVars:     []string{"PROXY_SIGNING_KEY_GRACE_PERIOD", "OCS_SIGNING_KEY_GRACE_PERIOD"},
+			Destination: &cfg.PreSignedURL.SigningKeyGracePeriod,
+		},
+	}
+}
+
+// DeleteSigningKeyWithConfig applies the config to the signing key delete command flags.
+func DeleteSigningKeyWithConfig(cfg *config.Config, keyID *string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "key-id",
+			Value:       "",
+			Usage:       "Id of the signing key to revoke, revokes all keys of the user if empty",
+			Destination: keyID,
+		},
+	}
+}
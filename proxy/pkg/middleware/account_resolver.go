@@ -1,14 +1,19 @@
 package middleware
 
 import (
-	"github.com/owncloud/ocis/proxy/pkg/user/backend"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/owncloud/ocis/proxy/pkg/user/backend"
 
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	tokenPkg "github.com/cs3org/reva/pkg/token"
 	"github.com/cs3org/reva/pkg/token/manager/jwt"
 	revauser "github.com/cs3org/reva/pkg/user"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/ocis-pkg/oidc"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
 )
 
 // AccountResolver provides a middleware which mints a jwt and adds it to the proxied request based
@@ -32,6 +37,9 @@ func AccountResolver(optionSetters ...Option) func(next http.Handler) http.Handl
 			tokenManager:          tokenManager,
 			userProvider:          options.UserProvider,
 			autoProvisionAccounts: options.AutoprovisionAccounts,
+			userinfoCache:         options.UserinfoCache,
+			userinfoCacheTTL:      options.UserinfoCacheTTL,
+			maxTokenLifetime:      options.MaxTokenLifetime,
 		}
 	}
 }
@@ -42,6 +50,9 @@ type accountResolver struct {
 	tokenManager          tokenPkg.Manager
 	userProvider          backend.UserBackend
 	autoProvisionAccounts bool
+	userinfoCache         *sync.Cache
+	userinfoCacheTTL      time.Duration
+	maxTokenLifetime      time.Duration
 }
 
 func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -88,6 +99,17 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		// claims from basic auth have no issue time, the password is checked on every request
+		bearer := strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if bearer && m.issuedBeforeRevocation(claims, u) {
+			m.logger.Debug().Interface("claims", claims).Str("userid", u.Id.OpaqueId).Msg("token was issued before the sign in sessions were revoked")
+			if m.userinfoCache != nil {
+				revokeUserinfo(m.userinfoCache, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), m.userinfoCacheTTL)
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		m.logger.Debug().Interface("claims", claims).Interface("user", u).Msgf("associated claims with uuid")
 	}
 
@@ -103,3 +125,26 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	m.next.ServeHTTP(w, req)
 }
+
+// issuedBeforeRevocation checks if the token the claims were taken from was issued before the sign in sessions of the
+// user were revoked. Tokens issued in the same second as the revocation are rejected as well. Tokens without a known
+// issue time might have been issued before the revocation, they are rejected until all tokens issued before it have
+// expired. Tokens obtained with a refresh token are issued after the revocation, they are rejected if the user signed
+// in before the refresh tokens were revoked.
+func (m accountResolver) issuedBeforeRevocation(claims *oidc.StandardClaims, u *cs3.User) bool {
+	if validFrom, ok := backend.RefreshTokensValidFrom(u); ok {
+		if claims.AuthTime == 0 {
+			m.logger.Debug().Str("userid", u.Id.OpaqueId).Msg("token has no auth_time claim, revoked refresh tokens can't be detected")
+		} else if !time.Unix(claims.AuthTime, 0).After(validFrom) {
+			return true
+		}
+	}
+	validFrom, ok := backend.SignInSessionsValidFrom(u)
+	if !ok {
+		return false
+	}
+	if claims.Iat == 0 {
+		return time.Since(validFrom) < m.maxTokenLifetime
+	}
+	return !time.Unix(claims.Iat, 0).After(validFrom)
+}
@@ -3,16 +3,20 @@ package middleware
 import (
 	"context"
 	"github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/ocis-pkg/oidc"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 	"github.com/owncloud/ocis/proxy/pkg/user/backend"
 	"github.com/owncloud/ocis/proxy/pkg/user/backend/test"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestTokenIsAddedWithMailClaim(t *testing.T) {
@@ -104,6 +108,87 @@ func TestInternalServerErrorOnMissingMailAndUsername(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rw.Code)
 }
 
+func TestUnauthorizedOnRevokedSignInSessions(t *testing.T) {
+	now := time.Now()
+	userRevokedAt := func(validFrom time.Time) *userv1beta1.User {
+		return &userv1beta1.User{
+			Id:   &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+			Mail: "foo@example.com",
+			Opaque: &types.Opaque{
+				Map: map[string]*types.OpaqueEntry{
+					backend.SignInSessionsValidFromKey: {
+						Decoder: "plain",
+						Value:   []byte(strconv.FormatInt(validFrom.Unix(), 10)),
+					},
+					backend.RefreshTokensValidFromKey: {
+						Decoder: "plain",
+						Value:   []byte(strconv.FormatInt(validFrom.Unix(), 10)),
+					},
+				},
+			},
+		}
+	}
+	recently := now.Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		validFrom time.Time
+		iat       int64
+		authTime  int64
+		bearer    bool
+		expected  int
+	}{
+		{"issued before", recently, recently.Add(-time.Hour).Unix(), 0, true, http.StatusUnauthorized},
+		{"issued in the same second", recently, recently.Unix(), 0, true, http.StatusUnauthorized},
+		{"issued after", recently, recently.Add(time.Second).Unix(), 0, true, http.StatusOK},
+		{"unknown issue time", recently, 0, 0, true, http.StatusUnauthorized},
+		{"unknown issue time after all tokens expired", now.Add(-25 * time.Hour), 0, 0, true, http.StatusOK},
+		{"basic auth", recently, 0, 0, false, http.StatusOK},
+		{"refreshed after signing in before", recently, now.Unix(), recently.Add(-time.Hour).Unix(), true, http.StatusUnauthorized},
+		{"signed in after", recently, now.Unix(), recently.Add(time.Second).Unix(), true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := userRevokedAt(tt.validFrom)
+			cache := sync.NewCache(10)
+			sut := AccountResolver(
+				Logger(log.NewLogger()),
+				UserProvider(&test.UserBackendMock{
+					GetUserByClaimsFunc: func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, error) {
+						return u, nil
+					},
+				}),
+				TokenManagerConfig(config.TokenManager{JWTSecret: "secret"}),
+				UserinfoCache(&cache),
+				TokenCacheTTL(time.Minute),
+				MaxTokenLifetime(24*time.Hour),
+			)(mockHandler{})
+
+			req, rw := mockRequest(&oidc.StandardClaims{
+				Iss:      "https://idx.example.com",
+				Email:    "foo@example.com",
+				Iat:      tt.iat,
+				AuthTime: tt.authTime,
+			})
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer sometoken")
+			}
+
+			sut.ServeHTTP(rw, req)
+
+			assert.Equal(t, tt.expected, rw.Code)
+			if tt.expected == http.StatusUnauthorized {
+				// the cached userinfo of the token is replaced, so the oidc middleware rejects it right away
+				hit := cache.Load("sometoken")
+				assert.NotNil(t, hit)
+				assert.IsType(t, revokedToken{}, hit.V)
+				assert.Empty(t, req.Header.Get(token.TokenHeader))
+			}
+		})
+	}
+}
+
 func newMockAccountResolver(userBackendResult *userv1beta1.User, userBackendErr error) http.Handler {
 	mock := &test.UserBackendMock{
 		GetUserByClaimsFunc: func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, error) {
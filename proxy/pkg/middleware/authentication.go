@@ -115,6 +115,7 @@ func newOIDCAuth(options Options) func(http.Handler) http.Handler {
 		OIDCIss(options.OIDCIss),
 		TokenCacheSize(options.UserinfoCacheSize),
 		TokenCacheTTL(time.Second*time.Duration(options.UserinfoCacheTTL)),
+		UserinfoCache(options.UserinfoCache),
 		CredentialsByUserAgent(options.CredentialsByUserAgent),
 	)
 }
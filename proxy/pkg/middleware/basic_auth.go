@@ -2,15 +2,26 @@ package middleware
 
 import (
 	"fmt"
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	accounts "github.com/owncloud/ocis/accounts/pkg/proto/v0"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/ocis-pkg/oidc"
 	"github.com/owncloud/ocis/proxy/pkg/user/backend"
 	"net/http"
+	"path"
+	"regexp"
 	"strings"
 )
 
 const publicFilesEndpoint = "/remote.php/dav/public-files/"
 
+var (
+	// signingKeyEndpoint hands out the signing secret of the user, which allows to sign urls for any method
+	signingKeyEndpoint = regexp.MustCompile(`/v[12]\.php/cloud/user/signing-key$`)
+	// signedURLEndpoint signs a url for the method given in the verb parameter
+	signedURLEndpoint = regexp.MustCompile(`/v[12]\.php/cloud/user/signed-url$`)
+)
+
 // BasicAuth provides a middleware to check if BasicAuth is provided
 func BasicAuth(optionSetters ...Option) func(next http.Handler) http.Handler {
 	options := newOptions(optionSetters...)
@@ -65,6 +76,11 @@ func BasicAuth(optionSetters ...Option) func(next http.Handler) http.Handler {
 					return
 				}
 
+				if !h.scopeAllowsRequest(user, req) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
 				claims := &oidc.StandardClaims{
 					OcisID:            user.Id.OpaqueId,
 					Iss:               user.Id.Idp,
@@ -93,3 +109,42 @@ func (m basicAuth) isBasicAuth(req *http.Request) bool {
 	login, password, ok := req.BasicAuth()
 	return m.enabled && ok && login != "" && password != ""
 }
+
+// scopeAllowsRequest checks if the app token the user authenticated with may be used for the request. Read-only app
+// tokens are limited to methods that don't modify any data. Requests bypassing the proxy with a signed url are
+// limited too: read-only app tokens can't get the signing key and only sign urls for methods that don't modify data.
+func (m basicAuth) scopeAllowsRequest(user *cs3.User, req *http.Request) bool {
+	scope, ok := backend.AppTokenScope(user)
+	if !ok || scope != accounts.AppTokenScopeReadOnly {
+		return true
+	}
+	if !isReadOnlyMethod(req.Method) {
+		m.logger.Debug().Str("method", req.Method).Str("path", req.URL.Path).Msg("read-only app token used for a modifying request")
+		return false
+	}
+
+	p := path.Clean(req.URL.Path)
+	switch {
+	case signingKeyEndpoint.MatchString(p):
+		m.logger.Debug().Str("path", req.URL.Path).Msg("read-only app token used to get the signing key")
+		return false
+	case signedURLEndpoint.MatchString(p):
+		switch verb := strings.ToUpper(req.URL.Query().Get("verb")); verb {
+		case "", http.MethodGet, http.MethodHead, "PROPFIND":
+			return true
+		default:
+			m.logger.Debug().Str("verb", verb).Msg("read-only app token used to sign a url for a modifying request")
+			return false
+		}
+	}
+	return true
+}
+
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT":
+		return true
+	default:
+		return false
+	}
+}
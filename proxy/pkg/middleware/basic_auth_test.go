@@ -1,3 +1,61 @@
 package middleware
 
-/**/
+import (
+	"net/http/httptest"
+	"testing"
+
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	accounts "github.com/owncloud/ocis/accounts/pkg/proto/v0"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/proxy/pkg/user/backend"
+)
+
+func TestScopeAllowsRequest(t *testing.T) {
+	m := basicAuth{logger: log.NewLogger()}
+	readOnly := &cs3.User{Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+		backend.AppTokenScopeKey: {Decoder: "plain", Value: []byte(accounts.AppTokenScopeReadOnly)},
+	}}}
+	full := &cs3.User{Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+		backend.AppTokenScopeKey: {Decoder: "plain", Value: []byte("")},
+	}}}
+	password := &cs3.User{}
+
+	const file = "/remote.php/dav/files/einstein/file.txt"
+	tests := []struct {
+		user     *cs3.User
+		method   string
+		target   string
+		expected bool
+	}{
+		{readOnly, "GET", file, true},
+		{readOnly, "PROPFIND", file, true},
+		{readOnly, "PUT", file, false},
+		{readOnly, "DELETE", file, false},
+		{readOnly, "MKCOL", file, false},
+		{full, "PUT", file, true},
+		{password, "DELETE", file, true},
+
+		// the signing key allows to sign urls for any method
+		{readOnly, "GET", "/ocs/v1.php/cloud/user/signing-key", false},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signing-key?format=json", false},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user//signing-key/", false},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signing-keys", true},
+		{full, "GET", "/ocs/v2.php/cloud/user/signing-key", true},
+
+		// signed urls are limited to the methods of the scope
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signed-url?path=/remote.php/dav/files/einstein/file.txt", true},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signed-url?verb=get&path=/remote.php/dav/files/einstein/file.txt", true},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signed-url?verb=PROPFIND&path=/remote.php/dav/files/einstein", true},
+		{readOnly, "GET", "/ocs/v2.php/cloud/user/signed-url?verb=PUT&path=/remote.php/dav/files/einstein/file.txt", false},
+		{readOnly, "GET", "/ocs/v1.php/cloud/user/signed-url?verb=delete&path=/remote.php/dav/files/einstein/file.txt", false},
+		{full, "GET", "/ocs/v2.php/cloud/user/signed-url?verb=PUT&path=/remote.php/dav/files/einstein/file.txt", true},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if got := m.scopeAllowsRequest(tt.user, req); got != tt.expected {
+			t.Errorf("scopeAllowsRequest(%s %s) = %v, expected %v", tt.method, tt.target, got, tt.expected)
+		}
+	}
+}
@@ -24,14 +24,18 @@ type OIDCProvider interface {
 // OIDCAuth provides a middleware to check access secured by a static token.
 func OIDCAuth(optionSetters ...Option) func(next http.Handler) http.Handler {
 	options := newOptions(optionSetters...)
-	tokenCache := sync.NewCache(options.UserinfoCacheSize)
+	tokenCache := options.UserinfoCache
+	if tokenCache == nil {
+		c := sync.NewCache(options.UserinfoCacheSize)
+		tokenCache = &c
+	}
 
 	h := oidcAuth{
 		logger:        options.Logger,
 		providerFunc:  options.OIDCProviderFunc,
 		httpClient:    options.HTTPClient,
 		oidcIss:       options.OIDCIss,
-		tokenCache:    &tokenCache,
+		tokenCache:    tokenCache,
 		tokenCacheTTL: options.UserinfoCacheTTL,
 	}
 
@@ -68,6 +72,10 @@ func OIDCAuth(optionSetters ...Option) func(next http.Handler) http.Handler {
 	}
 }
 
+// revokedToken is cached instead of the userinfo of a token that was issued before the sign in sessions of the
+// account were revoked
+type revokedToken struct{}
+
 type oidcAuth struct {
 	logger        log.Logger
 	provider      OIDCProvider
@@ -105,13 +113,28 @@ func (m oidcAuth) getClaims(token string, req *http.Request) (claims oidc.Standa
 		//TODO: This should be read from the token instead of config
 		claims.Iss = m.oidcIss
 
-		expiration := m.extractExpiration(token)
+		// the account resolver compares the issue and authentication time with the time the sessions and refresh
+		// tokens of the account were revoked
+		if claims.Iat == 0 {
+			claims.Iat = extractIssuedAt(token)
+		}
+		if claims.AuthTime == 0 {
+			claims.AuthTime = extractAuthTime(token)
+		}
+
+		expiration := extractExpiration(token, m.tokenCacheTTL)
 		m.tokenCache.Store(token, claims, expiration)
 
 		m.logger.Debug().Interface("claims", claims).Interface("userInfo", userInfo).Time("expiration", expiration.UTC()).Msg("unmarshalled and cached userinfo")
 		return
 	}
 
+	if _, revoked := hit.V.(revokedToken); revoked {
+		m.logger.Debug().Msg("token has been revoked")
+		status = http.StatusUnauthorized
+		return
+	}
+
 	var ok = false
 	if claims, ok = hit.V.(oidc.StandardClaims); !ok {
 		status = http.StatusInternalServerError
@@ -124,26 +147,67 @@ func (m oidcAuth) getClaims(token string, req *http.Request) (claims oidc.Standa
 // extractExpiration tries to parse and extract the expiration from the provided token. It might not even be a jwt.
 // defaults to the configured fallback TTL.
 // TODO: use introspection endpoint if available in the oidc configuration. Still needs a fallback to configured TTL.
-func (m oidcAuth) extractExpiration(token string) time.Time {
-	defaultExpiration := time.Now().Add(m.tokenCacheTTL)
+func extractExpiration(token string, ttl time.Duration) time.Time {
+	at := parseAccessToken(token)
+	if at == nil || at.ExpiresAt == 0 {
+		return time.Now().Add(ttl)
+	}
+
+	return time.Unix(at.ExpiresAt, 0)
+}
 
+// extractIssuedAt tries to parse and extract the issue time from the provided token. It returns 0 if the token is no
+// jwt or has no iat claim, the issue time of such tokens is unknown.
+func extractIssuedAt(token string) int64 {
+	at := parseAccessToken(token)
+	if at == nil {
+		return 0
+	}
+
+	return at.IssuedAt
+}
+
+// extractAuthTime tries to parse and extract the time the user signed in from the provided token. It returns 0 if the
+// token is no jwt or has no auth_time claim.
+func extractAuthTime(token string) int64 {
+	at := parseAccessToken(token)
+	if at == nil {
+		return 0
+	}
+
+	return at.AuthTime
+}
+
+// accessTokenClaims are the claims of a jwt based access token the proxy looks at.
+type accessTokenClaims struct {
+	jwt.StandardClaims
+	AuthTime int64 `json:"auth_time,omitempty"`
+}
+
+// parseAccessToken returns the claims of a jwt based access token without verifying it. It returns nil if the token
+// is not a jwt.
+func parseAccessToken(token string) *accessTokenClaims {
 	s := strings.SplitN(token, ".", 4)
 	if len(s) != 3 {
-		return defaultExpiration
+		return nil
 	}
 
 	b, err := jwt.DecodeSegment(s[1])
 	if err != nil {
-		return defaultExpiration
+		return nil
 	}
 
-	at := &jwt.StandardClaims{}
-	err = json.Unmarshal(b, at)
-	if err != nil || at.ExpiresAt == 0 {
-		return defaultExpiration
+	at := &accessTokenClaims{}
+	if err = json.Unmarshal(b, at); err != nil {
+		return nil
 	}
 
-	return time.Unix(at.ExpiresAt, 0)
+	return at
+}
+
+// revokeUserinfo replaces the cached userinfo of a token, so it is rejected until it expires
+func revokeUserinfo(cache *sync.Cache, token string, ttl time.Duration) {
+	cache.Store(token, revokedToken{}, extractExpiration(token, ttl))
 }
 
 func (m oidcAuth) shouldServe(req *http.Request) bool {
@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-oidc"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
 	"golang.org/x/oauth2"
 )
 
@@ -33,6 +36,64 @@ func TestOIDCAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestOIDCAuthRejectsRevokedToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	cache := sync.NewCache(10)
+	cache.Store("sometoken", revokedToken{}, time.Now().Add(time.Minute))
+
+	m := OIDCAuth(
+		Logger(log.NewLogger()),
+		OIDCProviderFunc(func() (OIDCProvider, error) {
+			// the cached revocation must be honoured without asking the provider
+			return &mockOIDCProvider{}, nil
+		}),
+		OIDCIss("https://localhost:9200"),
+		UserinfoCache(&cache),
+	)(next)
+
+	r := httptest.NewRequest(http.MethodGet, "https://idp.example.com", nil)
+	r.Header.Set("Authorization", "Bearer sometoken")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized, got %d", w.Code)
+	}
+}
+
+func TestExtractIssuedAt(t *testing.T) {
+	at, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{IssuedAt: 1580646600}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iat := extractIssuedAt(at); iat != 1580646600 {
+		t.Errorf("expected the iat of the jwt, got %d", iat)
+	}
+
+	// the issue time of opaque tokens is unknown
+	if iat := extractIssuedAt("sometoken"); iat != 0 {
+		t.Errorf("expected no issue time, got %d", iat)
+	}
+}
+
+func TestExtractAuthTime(t *testing.T) {
+	at, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims{
+		StandardClaims: jwt.StandardClaims{IssuedAt: 1580646600},
+		AuthTime:       1580640000,
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authTime := extractAuthTime(at); authTime != 1580640000 {
+		t.Errorf("expected the auth_time of the jwt, got %d", authTime)
+	}
+
+	if authTime := extractAuthTime("sometoken"); authTime != 0 {
+		t.Errorf("expected no auth time, got %d", authTime)
+	}
+}
+
 type mockOIDCProvider struct {
 	UserInfoFunc func(ctx context.Context, ts oauth2.TokenSource) (*oidc.UserInfo, error)
 }
@@ -8,8 +8,11 @@ import (
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/micro/go-micro/v2/client"
 	acc "github.com/owncloud/ocis/accounts/pkg/proto/v0"
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
 )
@@ -49,8 +52,18 @@ type Options struct {
 	UserinfoCacheSize int
 	// UserinfoCacheTTL sets the max cache duration for the userinfo cache, intended for the oidc_auth middleware
 	UserinfoCacheTTL time.Duration
+	// UserinfoCache is shared by the oidc_auth and account_resolver middlewares, so the account resolver can
+	// invalidate the cached userinfo of revoked tokens
+	UserinfoCache *sync.Cache
+	// MaxTokenLifetime is the longest lifetime of an access token issued by the oidc provider. Tokens without a known
+	// issue time are rejected for this long after the sign in sessions of an account were revoked.
+	MaxTokenLifetime time.Duration
 	// CredentialsByUserAgent sets the auth challenges on a per user-agent basis
 	CredentialsByUserAgent map[string]string
+	// EventsClient to publish events with
+	EventsClient client.Client
+	// TrustedProxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honoured
+	TrustedProxies signedurl.TrustedProxies
 }
 
 // newOptions initializes the available default options.
@@ -169,9 +182,37 @@ func TokenCacheTTL(ttl time.Duration) Option {
 	}
 }
 
+// UserinfoCache provides a function to set the UserinfoCache
+func UserinfoCache(c *sync.Cache) Option {
+	return func(o *Options) {
+		o.UserinfoCache = c
+	}
+}
+
+// MaxTokenLifetime provides a function to set the MaxTokenLifetime
+func MaxTokenLifetime(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxTokenLifetime = d
+	}
+}
+
 // UserProvider sets the accounts user provider
 func UserProvider(up backend.UserBackend) Option {
 	return func(o *Options) {
 		o.UserProvider = up
 	}
 }
+
+// EventsClient sets the client used to publish events
+func EventsClient(c client.Client) Option {
+	return func(o *Options) {
+		o.EventsClient = c
+	}
+}
+
+// TrustedProxies sets the reverse proxies whose forwarded headers are honoured
+func TrustedProxies(val signedurl.TrustedProxies) Option {
+	return func(o *Options) {
+		o.TrustedProxies = val
+	}
+}
@@ -2,8 +2,6 @@ package middleware
 
 import (
 	"context"
-	"crypto/sha512"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	revauser "github.com/cs3org/reva/pkg/user"
@@ -14,9 +12,10 @@ import (
 	"time"
 
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 	store "github.com/owncloud/ocis/store/pkg/proto/v0"
-	"golang.org/x/crypto/pbkdf2"
+	"github.com/owncloud/ocis/store/pkg/signingkeys"
 )
 
 // SignedURLAuth provides a middleware to check access secured by a signed URL.
@@ -30,6 +29,7 @@ func SignedURLAuth(optionSetters ...Option) func(next http.Handler) http.Handler
 			preSignedURLConfig: options.PreSignedURLConfig,
 			store:              options.Store,
 			userProvider:       options.UserProvider,
+			trustedProxies:     options.TrustedProxies,
 		}
 	}
 }
@@ -40,6 +40,7 @@ type signedURLAuth struct {
 	preSignedURLConfig config.PreSignedURL
 	userProvider       backend.UserBackend
 	store              store.StoreService
+	trustedProxies     signedurl.TrustedProxies
 }
 
 func (m signedURLAuth) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -52,6 +53,7 @@ func (m signedURLAuth) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		m.logger.Error().Err(err).Msg("Could not get user by claim")
 		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
 	ctx := revauser.ContextSetUser(req.Context(), user)
@@ -80,6 +82,10 @@ func (m signedURLAuth) validate(req *http.Request) (err error) {
 		return err
 	}
 
+	if ok, err := m.algorithmIsAllowed(query); !ok {
+		return err
+	}
+
 	if ok, err := m.requestMethodMatches(req.Method, query); !ok {
 		return err
 	}
@@ -89,10 +95,21 @@ func (m signedURLAuth) validate(req *http.Request) (err error) {
 	}
 
 	if expired, err := m.urlIsExpired(query, time.Now); expired {
+		if err == nil {
+			err = errors.New("url is expired")
+		}
+		return err
+	}
+
+	key, ok, err := m.signatureIsValid(req)
+	if !ok {
+		if err == nil {
+			err = errors.New("invalid url signature")
+		}
 		return err
 	}
 
-	if ok, err := m.signatureIsValid(req); !ok {
+	if revoked, err := m.keyCreatedBeforeRevocation(req, key); revoked {
 		return err
 	}
 
@@ -105,7 +122,9 @@ func (m signedURLAuth) allRequiredParametersArePresent(query url.Values) (ok boo
 	// OC-Credential - defines the user scope (shall we use the owncloud user id here - this might leak internal data ....) REQUIRED
 	// OC-Date - defined the date the url was signed (ISO 8601 UTC) REQUIRED
 	// OC-Expires - defines the expiry interval in seconds (between 1 and 604800 = 7 days) REQUIRED
-	// TODO OC-Verb - defines for which http verb the request is valid - defaults to GET OPTIONAL
+	// OC-Verb - defines for which http verb the request is valid REQUIRED
+	// OC-Algo - defines the signature algorithm - defaults to PBKDF2/10000-SHA512 OPTIONAL
+	// OC-KeyID - defines which of the user's signing keys was used - defaults to the current key OPTIONAL
 	for _, p := range []string{
 		"OC-Signature",
 		"OC-Credential",
@@ -121,6 +140,26 @@ func (m signedURLAuth) allRequiredParametersArePresent(query url.Values) (ok boo
 	return true, nil
 }
 
+func (m signedURLAuth) algorithmIsAllowed(query url.Values) (ok bool, err error) {
+	// a missing OC-Algo means the oc10 compatible default
+	algo := query.Get(signedurl.ParamAlgorithm)
+	if algo == "" {
+		algo = signedurl.AlgorithmPBKDF2
+	}
+
+	allowed := m.preSignedURLConfig.AllowedAlgorithms
+	if len(allowed) == 0 {
+		allowed = signedurl.Algorithms
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(algo, a) {
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("signature algorithm %s is not allowed", algo)
+}
+
 func (m signedURLAuth) requestMethodMatches(meth string, query url.Values) (ok bool, err error) {
 	// check if given url query parameter OC-Verb matches given request method
 	if !strings.EqualFold(meth, query.Get("OC-Verb")) {
@@ -157,56 +196,70 @@ func (m signedURLAuth) urlIsExpired(query url.Values, now func() time.Time) (exp
 	if err != nil {
 		return true, err
 	}
+	if requestExpiry > signedurl.MaxExpires*time.Second {
+		return true, fmt.Errorf("OC-Expires must not exceed %d seconds", signedurl.MaxExpires)
+	}
 
 	validTo := validFrom.Add(requestExpiry)
 
 	return !(now().After(validFrom) && now().Before(validTo)), nil
 }
 
-func (m signedURLAuth) signatureIsValid(req *http.Request) (ok bool, err error) {
+func (m signedURLAuth) keyCreatedBeforeRevocation(req *http.Request, key signedurl.Key) (revoked bool, err error) {
+	// urls signed with a key that existed before the sign in sessions of the user were revoked are invalid. The
+	// signing date of the url is chosen by whoever signs it, the creation date of the key is not.
+	validFrom, ok := backend.SignInSessionsValidFrom(revauser.ContextMustGetUser(req.Context()))
+	if !ok {
+		return false, nil
+	}
+
+	if !key.CreatedAt.Truncate(time.Second).After(validFrom) {
+		return true, errors.New("url was signed with a key created before the sign in sessions were revoked")
+	}
+
+	return false, nil
+}
+
+// signatureIsValid returns the key the url was signed with if the signature is valid.
+func (m signedURLAuth) signatureIsValid(req *http.Request) (key signedurl.Key, ok bool, err error) {
 	u := revauser.ContextMustGetUser(req.Context())
-	signingKey, err := m.getSigningKey(req.Context(), u.Id.OpaqueId)
+	keyring, err := m.getSigningKeyring(req.Context(), u.Id.OpaqueId)
+	if err == signedurl.ErrNoKey {
+		m.logger.Debug().Str("userid", u.Id.OpaqueId).Msg("user has no signing keys")
+		return key, false, err
+	}
 	if err != nil {
 		m.logger.Error().Err(err).Msg("could not retrieve signing key")
-		return false, err
-	}
-	if len(signingKey) == 0 {
-		m.logger.Error().Err(err).Msg("signing key empty")
-		return false, err
+		return key, false, err
 	}
 	q := req.URL.Query()
-	signature := q.Get("OC-Signature")
-	q.Del("OC-Signature")
-	req.URL.RawQuery = q.Encode()
-	url := req.URL.String()
-	if !req.URL.IsAbs() {
-		url = "https://" + req.Host + url // TODO where do we get the scheme from
+	signingKeys, err := keyring.Lookup(q.Get(signedurl.ParamKeyID), time.Now())
+	if err != nil {
+		m.logger.Debug().Err(err).Str("key_id", q.Get(signedurl.ParamKeyID)).Msg("signing key not found or expired")
+		return key, false, err
 	}
+	signature := q.Get(signedurl.ParamSignature)
+	q.Del(signedurl.ParamSignature)
+	req.URL.RawQuery = q.Encode()
+	url := signedurl.RequestURL(req, m.trustedProxies).String()
 
-	return m.createSignature(url, signingKey) == signature, nil
-}
-
-func (m signedURLAuth) createSignature(url string, signingKey []byte) string {
-	// the oc10 signature check: $hash = \hash_pbkdf2("sha512", $url, $signingKey, 10000, 64, false);
-	// - sets the length of the output string to 64
-	// - sets raw output to false ->  if raw_output is FALSE length corresponds to twice the byte-length of the derived key (as every byte of the key is returned as two hexits).
-	// TODO change to length 128 in oc10?
-	// fo golangs pbkdf2.Key we need to use 32 because it will be encoded into 64 hexits later
-	hash := pbkdf2.Key([]byte(url), signingKey, 10000, 32, sha512.New)
-	return hex.EncodeToString(hash)
+	for _, k := range signingKeys {
+		ok, err := signedurl.VerifySignature(q.Get(signedurl.ParamAlgorithm), url, []byte(k.Secret), signature)
+		if err != nil {
+			return key, false, err
+		}
+		if ok {
+			return k, true, nil
+		}
+	}
+	return key, false, nil
 }
 
-func (m signedURLAuth) getSigningKey(ctx context.Context, ocisID string) ([]byte, error) {
-	res, err := m.store.Read(ctx, &store.ReadRequest{
-		Options: &store.ReadOptions{
-			Database: "proxy",
-			Table:    "signing-keys",
-		},
-		Key: ocisID,
-	})
-	if err != nil || len(res.Records) < 1 {
-		return []byte{}, err
+// getSigningKeyring reads the signing keys on every request, so revoked keys are rejected immediately
+func (m signedURLAuth) getSigningKeyring(ctx context.Context, ocisID string) (*signedurl.Keyring, error) {
+	keyring, _, err := signingkeys.New(m.store).Read(ctx, ocisID)
+	if err == nil && keyring == nil {
+		return nil, signedurl.ErrNoKey
 	}
-
-	return res.Records[0].Value, nil
+	return keyring, err
 }
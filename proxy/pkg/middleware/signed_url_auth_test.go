@@ -1,9 +1,24 @@
 package middleware
 
 import (
+	"context"
+	"crypto/tls"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	revauser "github.com/cs3org/reva/pkg/user"
+	"github.com/micro/go-micro/v2/client"
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/proxy/pkg/config"
+	"github.com/owncloud/ocis/proxy/pkg/user/backend"
+	store "github.com/owncloud/ocis/store/pkg/proto/v0"
 )
 
 func TestSignedURLAuth_shouldServe(t *testing.T) {
@@ -114,6 +129,7 @@ func TestSignedURLAuth_urlIsExpired(t *testing.T) {
 		{"http://example.com/example.jpg?OC-Date=2020-02-02T12:29:00.000Z&OC-Expires=59", true},
 		{"http://example.com/example.jpg?OC-Date=2020-02-03T12:29:00.000Z&OC-Expires=59", true},
 		{"http://example.com/example.jpg?OC-Date=2020-02-01T12:29:00.000Z&OC-Expires=59", true},
+		{"http://example.com/example.jpg?OC-Date=2020-02-01T12:29:00.000Z&OC-Expires=604801", true},
 	}
 
 	for _, tt := range tests {
@@ -125,12 +141,201 @@ func TestSignedURLAuth_urlIsExpired(t *testing.T) {
 	}
 }
 
-func TestSignedURLAuth_createSignature(t *testing.T) {
+func TestSignedURLAuth_algorithmIsAllowed(t *testing.T) {
 	pua := signedURLAuth{}
-	expected := "27d2ebea381384af3179235114801dcd00f91e46f99fca72575301cf3948101d"
-	s := pua.createSignature("something", []byte("somerandomkey"))
+	tests := []struct {
+		url      string
+		allowed  []string
+		expected bool
+	}{
+		{"https://example.com/example.jpg", []string{}, true},
+		{"https://example.com/example.jpg?OC-Algo=HMAC-SHA256", []string{}, true},
+		{"https://example.com/example.jpg?OC-Algo=MD5", []string{}, false},
+		{"https://example.com/example.jpg", []string{"HMAC-SHA256"}, false},
+		{"https://example.com/example.jpg?OC-Algo=HMAC-SHA256", []string{"HMAC-SHA256"}, true},
+	}
+
+	for _, tt := range tests {
+		pua.preSignedURLConfig.AllowedAlgorithms = tt.allowed
+		r := httptest.NewRequest("", tt.url, nil)
+		ok, _ := pua.algorithmIsAllowed(r.URL.Query())
+		if ok != tt.expected {
+			t.Errorf("with %s and allowed algorithms %s expected %t got %t", tt.url, tt.allowed, tt.expected, ok)
+		}
+	}
+}
+
+func TestSignedURLAuth_keyCreatedBeforeRevocation(t *testing.T) {
+	pua := signedURLAuth{}
+	validFrom, _ := time.Parse(time.RFC3339, "2020-02-02T12:30:00.000Z")
+	revoked := &userv1beta1.User{Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+		backend.SignInSessionsValidFromKey: {Decoder: "plain", Value: []byte(strconv.FormatInt(validFrom.Unix(), 10))},
+	}}}
+
+	tests := []struct {
+		name     string
+		key      signedurl.Key
+		user     *userv1beta1.User
+		expected bool
+	}{
+		{"created before", signedurl.Key{CreatedAt: validFrom.Add(-time.Minute)}, revoked, true},
+		{"created in the same second", signedurl.Key{CreatedAt: validFrom.Add(500 * time.Millisecond)}, revoked, true},
+		{"created after", signedurl.Key{CreatedAt: validFrom.Add(time.Minute)}, revoked, false},
+		{"legacy key", signedurl.Key{}, revoked, true},
+		{"never revoked", signedurl.Key{CreatedAt: validFrom.Add(-time.Minute)}, &userv1beta1.User{}, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://example.com/example.jpg", nil)
+		r = r.WithContext(revauser.ContextSetUser(r.Context(), tt.user))
+		revoked, _ := pua.keyCreatedBeforeRevocation(r, tt.key)
+		if revoked != tt.expected {
+			t.Errorf("%s: expected %t got %t", tt.name, tt.expected, revoked)
+		}
+	}
+}
+
+type mockStore struct {
+	store.StoreService
+	records map[string][]byte
+}
+
+func (m mockStore) Read(ctx context.Context, in *store.ReadRequest, opts ...client.CallOption) (*store.ReadResponse, error) {
+	v, ok := m.records[in.Key]
+	if !ok {
+		return nil, merrors.NotFound("", "not found")
+	}
+	return &store.ReadResponse{Records: []*store.Record{{Key: in.Key, Value: v}}}, nil
+}
+
+func TestSignedURLAuth_signatureIsValid(t *testing.T) {
+	now := time.Now()
+	keyring, _ := signedurl.NewKeyring(now.Add(-2 * time.Hour))
+	old, _ := keyring.Current()
+	current, _ := keyring.Rotate(now.Add(-time.Hour), 2*time.Hour)
+	krb, _ := keyring.Marshal()
+	legacyKeyring, _ := signedurl.ParseKeyring([]byte("legacykey"))
+	legacyCurrent, _ := legacyKeyring.Rotate(now.Add(-time.Hour), 2*time.Hour)
+	lkrb, _ := legacyKeyring.Marshal()
+	// httptest requests are sent from 192.0.2.1
+	trusted, _ := signedurl.ParseTrustedProxies([]string{"192.0.2.1"})
+
+	pua := signedURLAuth{
+		store: mockStore{records: map[string][]byte{
+			"einstein-id": krb,
+			"marie-id":    []byte("legacykey"),
+			"feynman-id":  lkrb,
+		}},
+		trustedProxies: trusted,
+	}
+
+	sign := func(userID, rawurl, algo string, key signedurl.Key) string {
+		u, _ := url.Parse(rawurl)
+		s, err := signedurl.Sign(u, signedurl.Params{Credential: userID, Verb: "GET", Date: now, Expires: 60, Algorithm: algo}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	tamper := func(s string) string {
+		return strings.Replace(s, "OC-Expires=60", "OC-Expires=600", 1)
+	}
 
-	if s != expected {
-		t.Fail()
+	tests := []struct {
+		name      string
+		userID    string
+		url       string
+		header    string
+		untrusted bool
+		expected  bool
+	}{
+		{"current key", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, current), "", false, true},
+		{"key in grace period", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, old), "", false, true},
+		{"pbkdf2", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmPBKDF2, current), "", false, true},
+		{"unknown key", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, signedurl.Key{ID: "unknown", Secret: "x"}), "", false, false},
+		{"tampered", "einstein-id", tamper(sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, current)), "", false, false},
+		{"legacy key", "marie-id", sign("marie-id", "https://example.com/example.jpg", signedurl.AlgorithmPBKDF2, signedurl.Key{Secret: "legacykey"}), "", false, true},
+		{"no key", "richard-id", sign("richard-id", "https://example.com/example.jpg", signedurl.AlgorithmPBKDF2, signedurl.Key{Secret: "legacykey"}), "", false, false},
+		{"legacy key after rotation", "feynman-id", sign("feynman-id", "https://example.com/example.jpg", signedurl.AlgorithmPBKDF2, signedurl.Key{Secret: "legacykey"}), "", false, true},
+		{"current key without id", "feynman-id", sign("feynman-id", "https://example.com/example.jpg", signedurl.AlgorithmPBKDF2, signedurl.Key{Secret: legacyCurrent.Secret}), "", false, true},
+		{"forwarded https", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, current), "https", false, true},
+		{"forwarded http", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, current), "http", false, false},
+		{"untrusted forwarded https", "einstein-id", sign("einstein-id", "https://example.com/example.jpg", signedurl.AlgorithmHMACSHA256, current), "https", true, false},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		// the proxy receives relative request uris
+		r := httptest.NewRequest("GET", u.RequestURI(), nil)
+		r.Host = u.Host
+		if tt.untrusted {
+			r.RemoteAddr = "198.51.100.1:1234"
+		}
+		if tt.header != "" {
+			r.Header.Set("X-Forwarded-Proto", tt.header)
+		} else {
+			r.TLS = &tls.ConnectionState{}
+		}
+		r = r.WithContext(revauser.ContextSetUser(r.Context(), &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: tt.userID}}))
+		_, ok, _ := pua.signatureIsValid(r)
+		if ok != tt.expected {
+			t.Errorf("%s: expected %t got %t", tt.name, tt.expected, ok)
+		}
+	}
+}
+
+func TestSignedURLAuth_validate(t *testing.T) {
+	now := time.Now()
+	keyring, _ := signedurl.NewKeyring(now.Add(-time.Hour))
+	key, _ := keyring.Current()
+	krb, _ := keyring.Marshal()
+	trusted, _ := signedurl.ParseTrustedProxies([]string{"192.0.2.1"})
+	pua := signedURLAuth{
+		preSignedURLConfig: config.PreSignedURL{AllowedHTTPMethods: []string{"GET"}},
+		store:              mockStore{records: map[string][]byte{"einstein-id": krb}},
+		trustedProxies:     trusted,
+	}
+
+	sign := func(date time.Time, key signedurl.Key) string {
+		u, _ := url.Parse("https://example.com/example.jpg")
+		s, err := signedurl.Sign(u, signedurl.Params{Credential: "einstein", Verb: "GET", Date: date, Expires: 60, Algorithm: signedurl.AlgorithmHMACSHA256}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	revokedAt := func(validFrom time.Time) *userv1beta1.User {
+		return &userv1beta1.User{
+			Id: &userv1beta1.UserId{OpaqueId: "einstein-id"},
+			Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+				backend.SignInSessionsValidFromKey: {Decoder: "plain", Value: []byte(strconv.FormatInt(validFrom.Unix(), 10))},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		user     *userv1beta1.User
+		expected bool
+	}{
+		{"valid", sign(now.Add(-time.Second), key), &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein-id"}}, true},
+		{"wrong signature", sign(now.Add(-time.Second), signedurl.Key{ID: key.ID, Secret: "wrong"}), &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein-id"}}, false},
+		{"expired", sign(now.Add(-time.Hour), key), &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "einstein-id"}}, false},
+		// the url claims to be signed after the revocation, but the key existed before
+		{"key created before revocation", sign(now.Add(-time.Second), key), revokedAt(now.Add(-time.Minute)), false},
+		{"key created after revocation", sign(now.Add(-time.Second), key), revokedAt(now.Add(-2 * time.Hour)), true},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		r := httptest.NewRequest("GET", u.RequestURI(), nil)
+		r.Host = u.Host
+		r.TLS = &tls.ConnectionState{}
+		r = r.WithContext(revauser.ContextSetUser(r.Context(), tt.user))
+		err := pua.validate(r)
+		if (err == nil) != tt.expected {
+			t.Errorf("%s: expected %t got %v", tt.name, tt.expected, err)
+		}
 	}
 }
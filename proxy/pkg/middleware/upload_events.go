@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	tokenPkg "github.com/cs3org/reva/pkg/token"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/owncloud/ocis/ocis-pkg/events"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/sync"
+)
+
+// tusUploadTTL is how long the proxy remembers the target of a tus upload which didn't finish yet.
+const tusUploadTTL = 24 * time.Hour
+
+// UploadEvents provides a middleware which publishes an UploadFinished event for every file uploaded through webdav
+func UploadEvents(optionSetters ...Option) func(next http.Handler) http.Handler {
+	options := newOptions(optionSetters...)
+	logger := options.Logger
+
+	return func(next http.Handler) http.Handler {
+		tokenManager, err := jwt.New(map[string]interface{}{
+			"secret": options.TokenManagerConfig.JWTSecret,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msgf("Could not initialize token-manager")
+		}
+
+		tusUploads := sync.NewCache(10000)
+		return &uploadEvents{
+			next:         next,
+			logger:       logger,
+			tokenManager: tokenManager,
+			secret:       options.TokenManagerConfig.JWTSecret,
+			client:       options.EventsClient,
+			tusUploads:   &tusUploads,
+		}
+	}
+}
+
+type uploadEvents struct {
+	next         http.Handler
+	logger       log.Logger
+	tokenManager tokenPkg.Manager
+	secret       string
+	client       client.Client
+	// tusUploads maps the url of unfinished tus uploads to the file they upload. The uploads are only published if
+	// they are finished through the proxy which created them.
+	tusUploads *sync.Cache
+}
+
+// tusUpload is an unfinished tus upload.
+type tusUpload struct {
+	owner  events.UserID
+	path   string
+	length string
+}
+
+func (m uploadEvents) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodPost && req.Header.Get("Tus-Resumable") != "":
+		m.createTusUpload(w, req)
+	case req.Method == http.MethodPatch && req.Header.Get("Tus-Resumable") != "":
+		m.patchTusUpload(w, req)
+	default:
+		m.put(w, req)
+	}
+}
+
+func (m uploadEvents) put(w http.ResponseWriter, req *http.Request) {
+	path, ok := uploadPath(req)
+	token := req.Header.Get(tokenPkg.TokenHeader)
+	if !ok || token == "" {
+		m.next.ServeHTTP(w, req)
+		return
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	m.next.ServeHTTP(rec, req)
+
+	// the etag is only known once the upload finished
+	etag := responseETag(w)
+	if (rec.status != http.StatusCreated && rec.status != http.StatusNoContent) || etag == "" {
+		return
+	}
+	if owner, ok := m.owner(req, token, path); ok {
+		m.publish(owner, path, etag)
+	}
+}
+
+// createTusUpload publishes tus uploads which are finished by the request creating them, the others are remembered
+// until they are finished by patchTusUpload.
+func (m uploadEvents) createTusUpload(w http.ResponseWriter, req *http.Request) {
+	dir, ok := homePath(req)
+	filename := tusMetadata(req.Header.Get("Upload-Metadata"))["filename"]
+	token := req.Header.Get(tokenPkg.TokenHeader)
+	if !ok || filename == "" || token == "" {
+		m.next.ServeHTTP(w, req)
+		return
+	}
+	p := path.Join(dir, filename)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	m.next.ServeHTTP(rec, req)
+	if rec.status != http.StatusCreated {
+		return
+	}
+	owner, ok := m.owner(req, token, p)
+	if !ok {
+		return
+	}
+
+	// the etag is only returned if the request contained the whole file
+	if etag := responseETag(w); etag != "" {
+		m.publish(owner, p, etag)
+		return
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || location.Path == "" {
+		m.logger.Error().Err(err).Str("path", p).Msg("could not get the location of the tus upload")
+		return
+	}
+	upload := tusUpload{owner: owner, path: p, length: req.Header.Get("Upload-Length")}
+	m.tusUploads.Store(location.Path, upload, time.Now().Add(tusUploadTTL))
+}
+
+// patchTusUpload publishes a tus upload when the offset reaches the length of the file.
+func (m uploadEvents) patchTusUpload(w http.ResponseWriter, req *http.Request) {
+	entry := m.tusUploads.Load(req.URL.Path)
+	if entry == nil {
+		m.next.ServeHTTP(w, req)
+		return
+	}
+	upload := entry.V.(tusUpload)
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	m.next.ServeHTTP(rec, req)
+	if rec.status != http.StatusNoContent || w.Header().Get("Upload-Offset") != upload.length {
+		return
+	}
+	// the data gateway doesn't return the etag, the subscribers look it up
+	if m.tusUploads.Delete(req.URL.Path) {
+		m.publish(upload.owner, upload.path, "")
+	}
+}
+
+// owner returns the id of the owner of the uploaded file. The token was minted by the account resolver.
+func (m uploadEvents) owner(req *http.Request, token, path string) (events.UserID, bool) {
+	u, err := m.tokenManager.DismantleToken(req.Context(), token)
+	if err != nil || u.Id == nil {
+		m.logger.Error().Err(err).Str("path", path).Msg("could not get the owner of the uploaded file")
+		return events.UserID{}, false
+	}
+	return events.UserID{Idp: u.Id.Idp, OpaqueID: u.Id.OpaqueId}, true
+}
+
+// publish publishes a signed UploadFinished event in the background.
+func (m uploadEvents) publish(owner events.UserID, path, etag string) {
+	ev := events.UploadFinished{
+		Owner: owner,
+		Path:  path,
+		ETag:  etag,
+	}
+	ev.Sign(m.secret)
+	go func() {
+		if err := events.Publish(context.Background(), m.client, events.UploadFinishedTopic, ev); err != nil {
+			m.logger.Error().Err(err).Str("path", path).Msg("could not publish the upload event")
+		}
+	}()
+}
+
+// responseETag returns the etag of the response without the weak prefix and the quotes.
+func responseETag(w http.ResponseWriter) string {
+	return strings.Trim(strings.TrimPrefix(w.Header().Get("ETag"), "W/"), `"`)
+}
+
+// uploadPath returns the path of the uploaded file relative to the home of the user. Chunked uploads are skipped
+// because the path of the request is the one of the chunk.
+func uploadPath(req *http.Request) (string, bool) {
+	if req.Method != http.MethodPut || req.Header.Get("OC-Chunked") != "" {
+		return "", false
+	}
+	p, ok := homePath(req)
+	if !ok || p == "/" {
+		return "", false
+	}
+	return p, true
+}
+
+// homePath returns the path of a webdav request relative to the home of the user.
+func homePath(req *http.Request) (string, bool) {
+	p := req.URL.Path
+	for _, prefix := range []string{"/remote.php/webdav", "/webdav"} {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return path.Join("/", strings.TrimPrefix(p, prefix)), true
+		}
+	}
+	if !strings.HasPrefix(p, "/remote.php/dav/files/") {
+		return "", false
+	}
+	// strip the username
+	parts := strings.SplitN(strings.TrimPrefix(p, "/remote.php/dav/files/"), "/", 2)
+	switch {
+	case parts[0] == "":
+		return "", false
+	case len(parts) == 1:
+		return "/", true
+	default:
+		return path.Join("/", parts[1]), true
+	}
+}
+
+// tusMetadata decodes the Upload-Metadata header of a tus upload, values which aren't base64 encoded are skipped.
+func tusMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, element := range strings.Split(header, ",") {
+		parts := strings.Fields(element)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		meta[parts[0]] = string(value)
+	}
+	return meta
+}
+
+// statusRecorder remembers the status code written to the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
+	"github.com/micro/go-micro/v2/broker"
+	"github.com/micro/go-micro/v2/broker/memory"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/micro/go-micro/v2/client/grpc"
+	"github.com/owncloud/ocis/ocis-pkg/events"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/proxy/pkg/config"
+)
+
+func TestUploadPath(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+		path   string
+		ok     bool
+	}{
+		{http.MethodPut, "https://example.com/remote.php/webdav/Photos/a.jpg", "/Photos/a.jpg", true},
+		{http.MethodPut, "https://example.com/webdav/a.jpg", "/a.jpg", true},
+		{http.MethodPut, "https://example.com/remote.php/dav/files/einstein/Photos/a%20b.jpg", "/Photos/a b.jpg", true},
+		{http.MethodPut, "https://example.com/remote.php/dav/files/einstein", "", false},
+		{http.MethodGet, "https://example.com/remote.php/webdav/a.jpg", "", false},
+		{http.MethodPut, "https://example.com/ocs/v1.php/cloud/user", "", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := uploadPath(httptest.NewRequest(tt.method, tt.url, nil))
+		if path != tt.path || ok != tt.ok {
+			t.Errorf("uploadPath(%s %s) = %q, %v, expected %q, %v", tt.method, tt.url, path, ok, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestHomePath(t *testing.T) {
+	tests := []struct {
+		url  string
+		path string
+		ok   bool
+	}{
+		{"https://example.com/remote.php/webdav", "/", true},
+		{"https://example.com/remote.php/webdav/Photos/", "/Photos", true},
+		{"https://example.com/webdav/Photos", "/Photos", true},
+		{"https://example.com/remote.php/dav/files/einstein", "/", true},
+		{"https://example.com/remote.php/dav/files/einstein/Photos", "/Photos", true},
+		{"https://example.com/remote.php/dav/files/", "", false},
+		{"https://example.com/remote.php/webdavx", "", false},
+		{"https://example.com/data/eyJhbGciOiJIUzI1NiJ9", "", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := homePath(httptest.NewRequest(http.MethodPost, tt.url, nil))
+		if path != tt.path || ok != tt.ok {
+			t.Errorf("homePath(%s) = %q, %v, expected %q, %v", tt.url, path, ok, tt.path, tt.ok)
+		}
+	}
+}
+
+// newUploadEventsTest returns an access token of einstein and a broker which passes the published events to the
+// channel.
+func newUploadEventsTest(t *testing.T) (string, broker.Broker, chan events.UploadFinished) {
+	tokenManager, err := jwt.New(map[string]interface{}{"secret": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokenManager.MintToken(context.Background(), &cs3.User{
+		Id:       &cs3.UserId{Idp: "https://localhost:9200", OpaqueId: "4c510ada-c86b-4815-8820-42cdf82c3d51"},
+		Username: "einstein",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := memory.NewBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan events.UploadFinished, 1)
+	_, err = b.Subscribe(events.UploadFinishedTopic, func(e broker.Event) error {
+		if strings.Contains(string(e.Message().Body), token) {
+			t.Error("the access token was published")
+		}
+		var ev events.UploadFinished
+		if err := json.Unmarshal(e.Message().Body, &ev); err != nil {
+			t.Error(err)
+		}
+		received <- ev
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, b, received
+}
+
+func TestUploadEventsPublishesFinishedUploads(t *testing.T) {
+	token, b, received := newUploadEventsTest(t)
+
+	m := UploadEvents(
+		Logger(log.NewLogger()),
+		TokenManagerConfig(config.TokenManager{JWTSecret: "secret"}),
+		EventsClient(grpc.NewClient(client.Broker(b))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"1872ade88f3013edeb33decd74a4f947"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "https://example.com/remote.php/webdav/a.jpg", nil)
+	req.Header.Set("x-access-token", token)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case ev := <-received:
+		expected := events.UploadFinished{
+			Owner: events.UserID{Idp: "https://localhost:9200", OpaqueID: "4c510ada-c86b-4815-8820-42cdf82c3d51"},
+			Path:  "/a.jpg",
+			ETag:  "1872ade88f3013edeb33decd74a4f947",
+		}
+		if err := ev.Verify("secret"); err != nil {
+			t.Error(err)
+		}
+		ev.Signature = ""
+		if ev != expected {
+			t.Errorf("received %+v, expected %+v", ev, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event was published")
+	}
+
+	// failed uploads are not published
+	m = UploadEvents(
+		Logger(log.NewLogger()),
+		TokenManagerConfig(config.TokenManager{JWTSecret: "secret"}),
+		EventsClient(grpc.NewClient(client.Broker(b))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInsufficientStorage)
+	}))
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case ev := <-received:
+		t.Errorf("unexpected event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUploadEventsPublishesFinishedTusUploads(t *testing.T) {
+	token, b, received := newUploadEventsTest(t)
+
+	// the file is uploaded in two chunks, the data gateway answers with the offset of the upload
+	var offset int
+	m := UploadEvents(
+		Logger(log.NewLogger()),
+		TokenManagerConfig(config.TokenManager{JWTSecret: "secret"}),
+		EventsClient(grpc.NewClient(client.Broker(b))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", "1.0.0")
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "https://example.com/data/eyJhbGciOiJIUzI1NiJ9")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPatch:
+			offset += 4
+			w.Header().Set("Upload-Offset", strconv.Itoa(offset))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/remote.php/dav/files/einstein/Photos", nil)
+	req.Header.Set("x-access-token", token)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Upload-Length", "8")
+	req.Header.Set("Upload-Metadata", "filename YS5qcGc=,mtime MTYxMTU4MjQwMA==")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	patch := func() {
+		// the data gateway authenticates the upload with the url
+		req := httptest.NewRequest(http.MethodPatch, "https://example.com/data/eyJhbGciOiJIUzI1NiJ9", strings.NewReader("abcd"))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+		m.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	patch()
+	select {
+	case ev := <-received:
+		t.Fatalf("the unfinished upload was published: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	patch()
+	select {
+	case ev := <-received:
+		if err := ev.Verify("secret"); err != nil {
+			t.Error(err)
+		}
+		expected := events.UploadFinished{
+			Owner:     events.UserID{Idp: "https://localhost:9200", OpaqueID: "4c510ada-c86b-4815-8820-42cdf82c3d51"},
+			Path:      "/Photos/a.jpg",
+			Signature: ev.Signature,
+		}
+		if ev != expected {
+			t.Errorf("received %+v, expected %+v", ev, expected)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event was published")
+	}
+
+	// the upload is only published once
+	patch()
+	select {
+	case ev := <-received:
+		t.Errorf("unexpected event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUploadEventsPublishesTusUploadsFinishedOnCreation(t *testing.T) {
+	token, b, received := newUploadEventsTest(t)
+
+	m := UploadEvents(
+		Logger(log.NewLogger()),
+		TokenManagerConfig(config.TokenManager{JWTSecret: "secret"}),
+		EventsClient(grpc.NewClient(client.Broker(b))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ocdav stats the file once the upload finished
+		w.Header().Set("Location", "https://example.com/data/eyJhbGciOiJIUzI1NiJ9")
+		w.Header().Set("ETag", `"1872ade88f3013edeb33decd74a4f947"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/remote.php/webdav", strings.NewReader("abcd"))
+	req.Header.Set("x-access-token", token)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Length", "4")
+	req.Header.Set("Upload-Metadata", "filename YS5qcGc=")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case ev := <-received:
+		if ev.Path != "/a.jpg" || ev.ETag != "1872ade88f3013edeb33decd74a4f947" {
+			t.Errorf("received %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event was published")
+	}
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	tokenPkg "github.com/cs3org/reva/pkg/token"
+	"github.com/owncloud/ocis/proxy/pkg/config"
+)
+
+// authorize evaluates the authorization rules of a route against the roles in the access token minted by the
+// account resolver. It returns 0 if the request may be proxied, otherwise the status to respond with.
+func (p *MultiHostReverseProxy) authorize(r *http.Request, a *config.RouteAuthorization) int {
+	if a == nil || (len(a.Roles) == 0 && len(a.Permissions) == 0) {
+		return 0
+	}
+
+	token := r.Header.Get(tokenPkg.TokenHeader)
+	if token == "" {
+		return http.StatusUnauthorized
+	}
+
+	u, err := p.tokenManager.DismantleToken(r.Context(), token)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("could not dismantle access token")
+		return http.StatusUnauthorized
+	}
+
+	roleIDs := make([]string, 0)
+	if u.Opaque != nil {
+		if roles, ok := u.Opaque.Map["roles"]; ok {
+			if err := json.Unmarshal(roles.Value, &roleIDs); err != nil {
+				p.logger.Error().Err(err).Str("userid", u.Id.OpaqueId).Msg("could not unmarshal role ids")
+				return http.StatusForbidden
+			}
+		}
+	}
+
+	if len(a.Roles) > 0 && !hasAnyRole(roleIDs, a.Roles) {
+		p.logger.Debug().Str("userid", u.Id.OpaqueId).Strs("roles", a.Roles).Str("path", r.URL.Path).Msg("route requires a role")
+		return http.StatusForbidden
+	}
+
+	for _, permissionID := range a.Permissions {
+		if p.roleManager == nil {
+			p.logger.Error().Str("path", r.URL.Path).Msg("route requires a permission but there is no role manager")
+			return http.StatusForbidden
+		}
+		if p.roleManager.FindPermissionByID(r.Context(), roleIDs, permissionID) == nil {
+			p.logger.Debug().Str("userid", u.Id.OpaqueId).Str("permission", permissionID).Str("path", r.URL.Path).Msg("route requires a permission")
+			return http.StatusForbidden
+		}
+	}
+
+	return 0
+}
+
+func hasAnyRole(roleIDs, required []string) bool {
+	for _, r := range required {
+		for _, id := range roleIDs {
+			if id == r {
+				return true
+			}
+		}
+	}
+	return false
+}
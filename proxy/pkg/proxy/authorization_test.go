@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	tokenPkg "github.com/cs3org/reva/pkg/token"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
+	"github.com/micro/go-micro/v2/client"
+	"github.com/owncloud/ocis/ocis-pkg/roles"
+	"github.com/owncloud/ocis/proxy/pkg/config"
+	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
+)
+
+func TestRouteAuthorization(t *testing.T) {
+	cfg := testConfig([]config.Policy{withPolicy("ocis", withRoutes{
+		{Endpoint: "/public", Backend: "http://backend"},
+		{Endpoint: "/admin", Backend: "http://backend", Authorization: &config.RouteAuthorization{Roles: []string{"admin"}}},
+		{Endpoint: "/debug", Backend: "http://backend", Authorization: &config.RouteAuthorization{Permissions: []string{"debug-permission"}}},
+	})})
+	cfg.TokenManager.JWTSecret = "secret"
+
+	roleService := settings.MockRoleService{
+		ListRolesFunc: func(ctx context.Context, req *settings.ListBundlesRequest, opts ...client.CallOption) (*settings.ListBundlesResponse, error) {
+			bundles := make([]*settings.Bundle, 0)
+			for _, id := range req.BundleIds {
+				if id == "admin" {
+					bundles = append(bundles, &settings.Bundle{Id: id, Settings: []*settings.Setting{{Id: "debug-permission"}}})
+				}
+			}
+			return &settings.ListBundlesResponse{Bundles: bundles}, nil
+		},
+	}
+	roleManager := roles.NewManager(roles.CacheSize(10), roles.CacheTTL(time.Minute), roles.RoleService(roleService))
+
+	rp := NewMultiHostReverseProxy(Config(cfg), RoleManager(&roleManager))
+	rp.Transport = RoundTripFunc(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`OK`)),
+			Header:     make(http.Header),
+		}
+	})
+
+	tests := []struct {
+		path     string
+		roles    []string
+		expected int
+	}{
+		{"/public", nil, http.StatusOK},
+		{"/admin", nil, http.StatusUnauthorized},
+		{"/admin", []string{"user"}, http.StatusForbidden},
+		{"/admin", []string{"user", "admin"}, http.StatusOK},
+		{"/debug", []string{"user"}, http.StatusForbidden},
+		{"/debug", []string{"admin"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "https://example.com"+tt.path, nil)
+		if tt.roles != nil {
+			r.Header.Set(tokenPkg.TokenHeader, mintToken(t, tt.roles))
+		}
+		w := httptest.NewRecorder()
+		rp.ServeHTTP(w, r)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s with roles %v: expected %d got %d", tt.path, tt.roles, tt.expected, w.Code)
+		}
+	}
+}
+
+func mintToken(t *testing.T, roleIDs []string) string {
+	tm, err := jwt.New(map[string]interface{}{"secret": "secret", "expires": int64(60)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(roleIDs)
+	token, err := tm.MintToken(context.Background(), &userv1beta1.User{
+		Id: &userv1beta1.UserId{OpaqueId: "einstein"},
+		Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+			"roles": {Decoder: "json", Value: b},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
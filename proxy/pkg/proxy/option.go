@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/roles"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 )
 
@@ -10,8 +11,9 @@ type Option func(o *Options)
 
 // Options defines the available options for this package.
 type Options struct {
-	Logger log.Logger
-	Config *config.Config
+	Logger      log.Logger
+	Config      *config.Config
+	RoleManager *roles.Manager
 }
 
 // newOptions initializes the available default options.
@@ -38,3 +40,10 @@ func Config(val *config.Config) Option {
 		o.Config = val
 	}
 }
+
+// RoleManager provides a function to set the role manager option, it is needed to check the permissions of routes.
+func RoleManager(val *roles.Manager) Option {
+	return func(o *Options) {
+		o.RoleManager = val
+	}
+}
@@ -15,7 +15,11 @@ import (
 	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
 	"go.opencensus.io/trace"
 
+	tokenPkg "github.com/cs3org/reva/pkg/token"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/roles"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
 	"github.com/owncloud/ocis/proxy/pkg/config"
 )
 
@@ -27,19 +31,41 @@ type MultiHostReverseProxy struct {
 	logger         log.Logger
 	propagator     tracecontext.HTTPFormat
 	config         *config.Config
+	routes         map[string]map[config.RouteType]map[string]config.Route
+	roleManager    *roles.Manager
+	tokenManager   tokenPkg.Manager
+	trustedProxies signedurl.TrustedProxies
 }
 
+// routeMatch identifies the director responsible for a request
+type routeMatch struct {
+	policy    string
+	routeType config.RouteType
+	endpoint  string
+}
+
+// routeMatchKey is the context key of the routeMatch, so a request is only matched once
+type routeMatchKey struct{}
+
 // NewMultiHostReverseProxy creates a new MultiHostReverseProxy
 func NewMultiHostReverseProxy(opts ...Option) *MultiHostReverseProxy {
 	options := newOptions(opts...)
 
 	rp := &MultiHostReverseProxy{
-		Directors: make(map[string]map[config.RouteType]map[string]func(req *http.Request)),
-		logger:    options.Logger,
-		config:    options.Config,
+		Directors:   make(map[string]map[config.RouteType]map[string]func(req *http.Request)),
+		logger:      options.Logger,
+		config:      options.Config,
+		routes:      make(map[string]map[config.RouteType]map[string]config.Route),
+		roleManager: options.RoleManager,
 	}
 	rp.Director = rp.directorSelectionDirector
 
+	trustedProxies, err := signedurl.ParseTrustedProxies(options.Config.TrustedProxies)
+	if err != nil {
+		options.Logger.Fatal().Err(err).Msg("invalid trusted proxies")
+	}
+	rp.trustedProxies = trustedProxies
+
 	// equals http.DefaultTransport except TLSClientConfig
 	rp.Transport = &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -103,6 +129,17 @@ func NewMultiHostReverseProxy(opts ...Option) *MultiHostReverseProxy {
 				Msg("adding route")
 
 			rp.AddHost(pol.Name, uri, route)
+
+			if route.Authorization != nil && rp.tokenManager == nil {
+				// the authorization rules are evaluated against the roles in the token minted by the account resolver
+				rp.tokenManager, err = jwt.New(map[string]interface{}{
+					"secret":  options.Config.TokenManager.JWTSecret,
+					"expires": int64(60),
+				})
+				if err != nil {
+					rp.logger.Fatal().Err(err).Msg("Could not initialize token-manager")
+				}
+			}
 		}
 	}
 
@@ -110,6 +147,18 @@ func NewMultiHostReverseProxy(opts ...Option) *MultiHostReverseProxy {
 }
 
 func (p *MultiHostReverseProxy) directorSelectionDirector(r *http.Request) {
+	match, ok := r.Context().Value(routeMatchKey{}).(routeMatch)
+	if !ok {
+		if match, ok = p.matchRoute(r); !ok {
+			return
+		}
+	}
+
+	p.Directors[match.policy][match.routeType][match.endpoint](r)
+}
+
+// matchRoute finds the director responsible for the request
+func (p *MultiHostReverseProxy) matchRoute(r *http.Request) (match routeMatch, ok bool) {
 	pol, err := p.PolicySelector(r.Context(), r)
 	if err != nil {
 		p.logger.Error().Msgf("Error while selecting pol %v", err)
@@ -120,7 +169,7 @@ func (p *MultiHostReverseProxy) directorSelectionDirector(r *http.Request) {
 		p.logger.
 			Error().
 			Msgf("policy %v is not configured", pol)
-		return
+		return match, false
 	}
 
 	// find matching director
@@ -152,16 +201,14 @@ func (p *MultiHostReverseProxy) directorSelectionDirector(r *http.Request) {
 					"path":   r.URL.Path,
 					"from":   r.RemoteAddr,
 				}).Msg("access-log")
-				p.Directors[pol][rt][endpoint](r)
-				return
+				return routeMatch{policy: pol, routeType: rt, endpoint: endpoint}, true
 			}
 		}
 	}
 
 	// override default director with root. If any
 	if p.Directors[pol][config.PrefixRoute]["/"] != nil {
-		p.Directors[pol][config.PrefixRoute]["/"](r)
-		return
+		return routeMatch{policy: pol, routeType: config.PrefixRoute, endpoint: "/"}, true
 	}
 
 	p.logger.
@@ -169,6 +216,7 @@ func (p *MultiHostReverseProxy) directorSelectionDirector(r *http.Request) {
 		Str("policy", pol).
 		Str("path", r.URL.Path).
 		Msg("no director found")
+	return match, false
 }
 
 func singleJoiningSlash(a, b string) string {
@@ -196,7 +244,19 @@ func (p *MultiHostReverseProxy) AddHost(policy string, target *url.URL, rt confi
 	if p.Directors[policy][routeType] == nil {
 		p.Directors[policy][routeType] = make(map[string]func(req *http.Request))
 	}
+	if p.routes[policy] == nil {
+		p.routes[policy] = make(map[config.RouteType]map[string]config.Route)
+	}
+	if p.routes[policy][routeType] == nil {
+		p.routes[policy][routeType] = make(map[string]config.Route)
+	}
+	p.routes[policy][routeType][rt.Endpoint] = rt
 	p.Directors[policy][routeType][rt.Endpoint] = func(req *http.Request) {
+		// let backends reconstruct the url the client used, e.g. when signing urls. The headers of clients which
+		// are not trusted are replaced.
+		u := signedurl.RequestURL(req, p.trustedProxies)
+		req.Header.Set("X-Forwarded-Proto", u.Scheme)
+		req.Header.Set("X-Forwarded-Host", u.Host)
 		req.URL.Scheme = target.Scheme
 		req.URL.Host = target.Host
 		// Apache deployments host addresses need to match on req.Host and req.URL.Host
@@ -219,12 +279,23 @@ func (p *MultiHostReverseProxy) AddHost(policy string, target *url.URL, rt confi
 }
 
 func (p *MultiHostReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	match, matched := p.matchRoute(r)
+	if matched {
+		if status := p.authorize(r, p.routes[match.policy][match.routeType][match.endpoint].Authorization); status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
 	ctx := context.Background()
+	if matched {
+		ctx = context.WithValue(ctx, routeMatchKey{}, match)
+	}
 	var span *trace.Span
 
 	// Start root span.
 	if p.config.Tracing.Enabled {
-		ctx, span = trace.StartSpan(context.Background(), r.URL.String())
+		ctx, span = trace.StartSpan(ctx, r.URL.String())
 		defer span.End()
 		p.propagator.SpanContextToRequest(span.SpanContext(), r)
 	}
@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -96,3 +98,33 @@ func TestSingleJoiningSlash(t *testing.T) {
 		}
 	}
 }
+
+func TestDirectorReplacesUntrustedForwardedHeaders(t *testing.T) {
+	cfg := config.New()
+	cfg.TrustedProxies = []string{"10.0.0.1"}
+	p := NewMultiHostReverseProxy(Config(cfg))
+	target, _ := url.Parse("http://localhost:9140")
+	p.AddHost("default", target, config.Route{Endpoint: "/"})
+	director := p.Directors["default"][config.DefaultRouteType]["/"]
+
+	tests := []struct {
+		remoteAddr   string
+		expectedHost string
+	}{
+		{"10.0.0.1:1234", "cloud.example.com"},
+		{"192.0.2.1:1234", "localhost:9200"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/ocs/v2.php/cloud/user/signed-url", nil)
+		req.Host = "localhost:9200"
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("X-Forwarded-Host", "cloud.example.com")
+		director(req)
+		if got := req.Header.Get("X-Forwarded-Host"); got != tt.expectedHost {
+			t.Errorf("X-Forwarded-Host from %s = %q, expected %q", tt.remoteAddr, got, tt.expectedHost)
+		}
+		if got := req.Header.Get("X-Forwarded-Proto"); got != "http" {
+			t.Errorf("X-Forwarded-Proto from %s = %q, expected http", tt.remoteAddr, got)
+		}
+	}
+}
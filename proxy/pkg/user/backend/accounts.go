@@ -89,6 +89,13 @@ func (a *accountsServiceBackend) Authenticate(ctx context.Context, username stri
 
 	user := a.accountToUser(account)
 
+	if t := account.AuthenticatedAppToken(); t != nil {
+		user.Opaque.Map[AppTokenScopeKey] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(t.Scope),
+		}
+	}
+
 	if err := injectRoles(ctx, user, a.settingsRoleService); err != nil {
 		a.logger.Warn().Err(err).Msgf("Could not load roles... continuing without")
 	}
@@ -152,6 +159,18 @@ func (a *accountsServiceBackend) accountToUser(account *accounts.Account) *cs3.U
 			},
 		},
 	}
+	if account.SignInSessionsValidFromDateTime != nil {
+		user.Opaque.Map[SignInSessionsValidFromKey] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatInt(account.SignInSessionsValidFromDateTime.Seconds, 10)),
+		}
+	}
+	if account.RefreshTokensValidFromDateTime != nil {
+		user.Opaque.Map[RefreshTokensValidFromKey] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatInt(account.RefreshTokensValidFromDateTime.Seconds, 10)),
+		}
+	}
 	return user
 }
 
@@ -10,7 +10,9 @@ import (
 	settings "github.com/owncloud/ocis/settings/pkg/proto/v0"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"testing"
+	"time"
 )
 
 var mockAccResp = []*accounts.Account{
@@ -96,6 +98,54 @@ func TestAuthenticate(t *testing.T) {
 	assertUserMatchesAccount(t, mockAccResp[0], u)
 }
 
+func TestAuthenticateWithAppToken(t *testing.T) {
+	acc := &accounts.Account{
+		Id:        "1234",
+		AppTokens: []*accounts.AppToken{{Id: "abc", Scope: accounts.AppTokenScopeReadOnly}},
+	}
+	accBackend := newAccountsBackend([]*accounts.Account{acc}, expectedRoles)
+	u, err := accBackend.Authenticate(context.Background(), "foo", "abc.secret")
+
+	assert.NoError(t, err)
+	scope, ok := AppTokenScope(u)
+	assert.True(t, ok)
+	assert.Equal(t, accounts.AppTokenScopeReadOnly, scope)
+
+	accBackend = newAccountsBackend(mockAccResp, expectedRoles)
+	u, err = accBackend.Authenticate(context.Background(), "foo", "secret")
+
+	assert.NoError(t, err)
+	_, ok = AppTokenScope(u)
+	assert.False(t, ok)
+}
+
+func TestGetUserByClaimsWithRevokedSessions(t *testing.T) {
+	validFrom := time.Date(2020, 2, 2, 12, 30, 0, 0, time.UTC)
+	acc := &accounts.Account{
+		Id:                              "1234",
+		AccountEnabled:                  true,
+		SignInSessionsValidFromDateTime: timestamppb.New(validFrom),
+		RefreshTokensValidFromDateTime:  timestamppb.New(validFrom),
+	}
+	accBackend := newAccountsBackend([]*accounts.Account{acc}, expectedRoles)
+	u, err := accBackend.GetUserByClaims(context.Background(), "mail", "foo@example.com", false)
+
+	assert.NoError(t, err)
+	v, ok := SignInSessionsValidFrom(u)
+	assert.True(t, ok)
+	assert.True(t, validFrom.Equal(v))
+	v, ok = RefreshTokensValidFrom(u)
+	assert.True(t, ok)
+	assert.True(t, validFrom.Equal(v))
+
+	accBackend = newAccountsBackend(mockAccResp, expectedRoles)
+	u, _ = accBackend.GetUserByClaims(context.Background(), "mail", "foo@example.com", false)
+	_, ok = SignInSessionsValidFrom(u)
+	assert.False(t, ok)
+	_, ok = RefreshTokensValidFrom(u)
+	assert.False(t, ok)
+}
+
 func TestAuthenticateFailed(t *testing.T) {
 	accBackend := newAccountsBackend([]*accounts.Account{}, expectedRoles)
 	u, err := accBackend.Authenticate(context.Background(), "foo", "secret")
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"time"
+
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
@@ -21,6 +24,17 @@ var (
 	ErrNotSupported = errors.New("operation not supported")
 )
 
+const (
+	// AppTokenScopeKey is the opaque key holding the scope of the app token a user authenticated with
+	AppTokenScopeKey = "app-token-scope"
+	// SignInSessionsValidFromKey is the opaque key holding the unix time before which all sessions of a user are
+	// invalid
+	SignInSessionsValidFromKey = "sign-in-sessions-valid-from"
+	// RefreshTokensValidFromKey is the opaque key holding the unix time before which all refresh tokens of a user are
+	// invalid
+	RefreshTokensValidFromKey = "refresh-tokens-valid-from"
+)
+
 // UserBackend allows the proxy to retrieve users from different user-backends (accounts-service, CS3)
 type UserBackend interface {
 	GetUserByClaims(ctx context.Context, claim, value string, withRoles bool) (*cs3.User, error)
@@ -34,6 +48,39 @@ type RevaAuthenticator interface {
 	Authenticate(ctx context.Context, in *gateway.AuthenticateRequest, opts ...grpc.CallOption) (*gateway.AuthenticateResponse, error)
 }
 
+// AppTokenScope returns the scope of the app token the user authenticated with. ok is false if the user did not use
+// an app token.
+func AppTokenScope(u *cs3.User) (scope string, ok bool) {
+	if u.Opaque == nil || u.Opaque.Map[AppTokenScopeKey] == nil {
+		return "", false
+	}
+	return string(u.Opaque.Map[AppTokenScopeKey].Value), true
+}
+
+// SignInSessionsValidFrom returns the time before which tokens and signed urls of the user must be rejected. ok is
+// false if the sessions of the user were never revoked.
+func SignInSessionsValidFrom(u *cs3.User) (validFrom time.Time, ok bool) {
+	return opaqueTime(u, SignInSessionsValidFromKey)
+}
+
+// RefreshTokensValidFrom returns the time before which the user must have signed in for a token to be accepted. ok
+// is false if the refresh tokens of the user were never revoked.
+func RefreshTokensValidFrom(u *cs3.User) (validFrom time.Time, ok bool) {
+	return opaqueTime(u, RefreshTokensValidFromKey)
+}
+
+// opaqueTime parses the unix time stored under the given opaque key of the user.
+func opaqueTime(u *cs3.User, key string) (time.Time, bool) {
+	if u.Opaque == nil || u.Opaque.Map[key] == nil {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(string(u.Opaque.Map[key].Value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
 // loadRolesIDs returns the role-ids assigned to an user
 func loadRolesIDs(ctx context.Context, opaqueUserID string, rs settings.RoleService) ([]string, error) {
 	req := &settings.ListRoleAssignmentsRequest{AccountUuid: opaqueUserID}
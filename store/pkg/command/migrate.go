@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/micro/cli/v2"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/flagset"
+	svc "github.com/owncloud/ocis/store/pkg/service/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+)
+
+// Migrate is the entrypoint for the migrate command.
+func Migrate(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Copy the records from one backend to another, the store must not be running",
+		Flags: flagset.MigrateWithConfig(cfg),
+		Before: func(c *cli.Context) error {
+			return ParseConfig(c, cfg)
+		},
+		Action: func(c *cli.Context) error {
+			logger := NewLogger(cfg)
+
+			from, to := c.String("from"), c.String("to")
+			if from == to {
+				err := fmt.Errorf("can't migrate from %s to itself", from)
+				fmt.Println(err)
+				return err
+			}
+
+			fromCfg, toCfg := *cfg, *cfg
+			fromCfg.Backend.Type = from
+			toCfg.Backend.Type = to
+
+			src, err := storage.New(&fromCfg, logger)
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not open the %s backend %w", from, err))
+				return err
+			}
+			defer src.Close()
+			dst, err := storage.New(&toCfg, logger)
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not open the %s backend %w", to, err))
+				return err
+			}
+			defer dst.Close()
+
+			n, err := storage.Copy(src, dst)
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not copy the records %w", err))
+				return err
+			}
+
+			if err := svc.CheckIndexOnNextStart(cfg); err != nil {
+				fmt.Println(fmt.Errorf("could not invalidate the index %w", err))
+				return err
+			}
+
+			fmt.Printf("copied %d records from %s to %s\n", n, from, to)
+			return nil
+		},
+	}
+}
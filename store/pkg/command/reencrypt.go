@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/micro/cli/v2"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/flagset"
+	svc "github.com/owncloud/ocis/store/pkg/service/v0"
+)
+
+// Reencrypt is the entrypoint for the reencrypt command.
+func Reencrypt(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "reencrypt",
+		Usage: "Encrypt all records with the current master key, the store must not be running",
+		Flags: flagset.ReencryptWithConfig(cfg),
+		Before: func(c *cli.Context) error {
+			return ParseConfig(c, cfg)
+		},
+		Action: func(c *cli.Context) error {
+			logger := NewLogger(cfg)
+
+			n, err := svc.Reencrypt(
+				svc.Logger(logger),
+				svc.Config(cfg),
+			)
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not re-encrypt the records %w", err))
+				return err
+			}
+
+			fmt.Printf("re-encrypted %d records\n", n)
+			return nil
+		},
+	}
+}
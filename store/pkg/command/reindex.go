@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/micro/cli/v2"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/flagset"
+	svc "github.com/owncloud/ocis/store/pkg/service/v0"
+)
+
+// Reindex is the entrypoint for the reindex command.
+func Reindex(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "reindex",
+		Usage: "Rebuild the metadata index from the stored records, the store must not be running",
+		Flags: flagset.ReindexWithConfig(cfg),
+		Before: func(c *cli.Context) error {
+			return ParseConfig(c, cfg)
+		},
+		Action: func(c *cli.Context) error {
+			logger := NewLogger(cfg)
+
+			n, err := svc.Reindex(
+				svc.Logger(logger),
+				svc.Config(cfg),
+			)
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not rebuild the index %w", err))
+				return err
+			}
+
+			fmt.Printf("indexed %d records\n", n)
+			return nil
+		},
+	}
+}
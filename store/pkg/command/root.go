@@ -39,6 +39,9 @@ func Execute() error {
 		Commands: []*cli.Command{
 			Server(cfg),
 			Health(cfg),
+			Reindex(cfg),
+			Migrate(cfg),
+			Reencrypt(cfg),
 			PrintVersion(cfg),
 		},
 	}
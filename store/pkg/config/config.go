@@ -37,15 +37,47 @@ type Tracing struct {
 	Service   string
 }
 
+// Backend defines which storage implementation is used for the records.
+type Backend struct {
+	Type  string
+	Bolt  Bolt
+	Redis Redis
+}
+
+// Bolt is the embedded bbolt implementation of the storage.
+type Bolt struct {
+	Path string
+}
+
+// Redis is the redis implementation of the storage.
+type Redis struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// Encryption configures the master keys used to encrypt the record values. Values are not encrypted if no key is
+// configured.
+type Encryption struct {
+	// Keys are comma separated id:key pairs with base64 encoded 32 byte keys, the first one encrypts new records.
+	Keys string
+	// KeyFile is read if Keys is empty, it contains one id:key pair per line.
+	KeyFile string
+}
+
 // Config combines all available configuration parts.
 type Config struct {
-	File     string
-	Log      Log
-	Debug    Debug
-	GRPC     GRPC
-	Tracing  Tracing
-	Datapath string
-	Service  Service
+	File       string
+	Log        Log
+	Debug      Debug
+	GRPC       GRPC
+	Tracing    Tracing
+	Datapath   string
+	Backend    Backend
+	Encryption Encryption
+	Service    Service
+	// SweepInterval in seconds defines how often expired records are deleted. 0 disables the sweeper.
+	SweepInterval int
 }
 
 // New initializes a new configuration with or without defaults.
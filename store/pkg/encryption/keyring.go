@@ -0,0 +1,142 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/owncloud/ocis/store/pkg/config"
+)
+
+// keySize is the size of the master and data keys, they are AES-256 keys.
+const keySize = 32
+
+// Keyring holds the master keys of the store. Values are encrypted with a random data key, which is encrypted with
+// the current master key. The other master keys are only used to decrypt the data keys of older records.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// Load returns the keyring of the configured master keys, or nil if encryption is not configured.
+func Load(cfg config.Encryption) (*Keyring, error) {
+	keys := cfg.Keys
+	if keys == "" && cfg.KeyFile != "" {
+		data, err := ioutil.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the key file: %w", err)
+		}
+		keys = string(data)
+	}
+	if strings.TrimSpace(keys) == "" {
+		return nil, nil
+	}
+	return Parse(keys)
+}
+
+// Parse creates a keyring from master keys separated by commas or newlines. Every key is given as id:key, where key is
+// the base64 encoded 32 byte key. The first key is the current one.
+func Parse(keys string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	for _, entry := range strings.FieldsFunc(keys, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.New("master keys must be given as id:key")
+		}
+		id := parts[0]
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("master key %s is given twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("master key %s must be %d base64 encoded bytes", id, keySize)
+		}
+		if k.keys[id], err = newAEAD(key); err != nil {
+			return nil, err
+		}
+		if k.current == "" {
+			k.current = id
+		}
+	}
+	if k.current == "" {
+		return nil, errors.New("no master key given")
+	}
+	return k, nil
+}
+
+// Current returns the id of the master key used to encrypt new data keys.
+func (k *Keyring) Current() string {
+	return k.current
+}
+
+// Seal encrypts a value with a new data key. The additional data is authenticated but not encrypted, it must be
+// passed to Open again, which binds the ciphertext to e.g. the id of its record. It returns the id of the master key
+// and the encrypted data key, which are needed to decrypt the value.
+func (k *Keyring) Seal(value, additionalData []byte) (keyID string, dataKey []byte, ciphertext []byte, err error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", nil, nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if ciphertext, err = seal(aead, value, additionalData); err != nil {
+		return "", nil, nil, err
+	}
+	if dataKey, err = seal(k.keys[k.current], key, []byte(k.current)); err != nil {
+		return "", nil, nil, err
+	}
+	return k.current, dataKey, ciphertext, nil
+}
+
+// Open decrypts a value sealed with the given master key and data key.
+func (k *Keyring) Open(keyID string, dataKey, ciphertext, additionalData []byte) ([]byte, error) {
+	master, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %s", keyID)
+	}
+	key, err := open(master, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	key1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	key2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		current string
+		err     bool
+	}{
+		{"single key", "k1:" + key1, "k1", false},
+		{"comma separated", "k2:" + key2 + ",k1:" + key1, "k2", false},
+		{"newline separated", "k1:" + key1 + "\n\nk2:" + key2 + "\n", "k1", false},
+		{"missing id", ":" + key1, "", true},
+		{"missing separator", key1, "", true},
+		{"invalid base64", "k1:not base64", "", true},
+		{"short key", "k1:MDEyMzQ1Njc4OWFiY2RlZg==", "", true},
+		{"duplicate id", "k1:" + key1 + ",k1:" + key2, "", true},
+		{"no keys", " , ", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := Parse(tt.keys)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.current, k.Current())
+		})
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	k, err := Parse("k1:" + key1 + ",k2:" + key2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyID, dataKey, ciphertext, err := k.Seal([]byte("secret"), []byte("record"))
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	value, err := k.Open(keyID, dataKey, ciphertext, []byte("record"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(value))
+
+	_, err = k.Open(keyID, dataKey, ciphertext, []byte("other record"))
+	assert.Error(t, err)
+	_, err = k.Open("k2", dataKey, ciphertext, []byte("record"))
+	assert.Error(t, err)
+	_, err = k.Open("k3", dataKey, ciphertext, []byte("record"))
+	assert.Error(t, err)
+	_, err = k.Open(keyID, dataKey, ciphertext[:4], []byte("record"))
+	assert.Error(t, err)
+
+	// older keys still open their values after a rotation
+	rotated, err := Parse("k2:" + key2 + ",k1:" + key1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err = rotated.Open(keyID, dataKey, ciphertext, []byte("record"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(value))
+}
+
+func TestLoad(t *testing.T) {
+	k, err := Load(config.Encryption{})
+	assert.NoError(t, err)
+	assert.Nil(t, k)
+
+	dir, err := ioutil.TempDir("", "store-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "keys")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("k2:"+key2+"\nk1:"+key1+"\n"), 0600))
+
+	k, err = Load(config.Encryption{KeyFile: file})
+	assert.NoError(t, err)
+	assert.Equal(t, "k2", k.Current())
+
+	// the keys take precedence over the key file
+	k, err = Load(config.Encryption{Keys: "k1:" + key1, KeyFile: file})
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", k.Current())
+
+	_, err = Load(config.Encryption{KeyFile: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
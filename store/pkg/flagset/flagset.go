@@ -54,7 +54,7 @@ func HealthWithConfig(cfg *config.Config) []cli.Flag {
 
 // ServerWithConfig applies cfg to the root flagset
 func ServerWithConfig(cfg *config.Config) []cli.Flag {
-	return []cli.Flag{
+	flags := []cli.Flag{
 		&cli.BoolFlag{
 			Name:        "tracing-enabled",
 			Usage:       "Enable sending traces",
@@ -136,7 +136,16 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			EnvVars:     []string{"STORE_DATA_PATH"},
 			Destination: &cfg.Datapath,
 		},
+		&cli.IntFlag{
+			Name:        "sweep-interval",
+			Value:       60,
+			Usage:       "Interval in seconds in which expired records are deleted, 0 disables the sweeper",
+			EnvVars:     []string{"STORE_SWEEP_INTERVAL"},
+			Destination: &cfg.SweepInterval,
+		},
 	}
+	flags = append(flags, BackendWithConfig(cfg)...)
+	return append(flags, EncryptionWithConfig(cfg)...)
 }
 
 // ListStoreWithConfig applies the config to the list commands flags.
@@ -157,3 +166,138 @@ func ListStoreWithConfig(cfg *config.Config) []cli.Flag {
 		},
 	}
 }
+
+// ReindexWithConfig applies cfg to the reindex flagset
+func ReindexWithConfig(cfg *config.Config) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config-file",
+			Value:       "",
+			Usage:       "Path to config file",
+			EnvVars:     []string{"STORE_CONFIG_FILE"},
+			Destination: &cfg.File,
+		},
+		&cli.StringFlag{
+			Name:        "data-path",
+			Value:       "/var/tmp/ocis/store",
+			Usage:       "location of the store data path",
+			EnvVars:     []string{"STORE_DATA_PATH"},
+			Destination: &cfg.Datapath,
+		},
+	}
+	return append(flags, BackendWithConfig(cfg)...)
+}
+
+// MigrateWithConfig applies cfg to the migrate flagset
+func MigrateWithConfig(cfg *config.Config) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config-file",
+			Value:       "",
+			Usage:       "Path to config file",
+			EnvVars:     []string{"STORE_CONFIG_FILE"},
+			Destination: &cfg.File,
+		},
+		&cli.StringFlag{
+			Name:        "data-path",
+			Value:       "/var/tmp/ocis/store",
+			Usage:       "location of the store data path",
+			EnvVars:     []string{"STORE_DATA_PATH"},
+			Destination: &cfg.Datapath,
+		},
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "Backend to copy the records from: disk, bolt or redis",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "Backend to copy the records to: disk, bolt or redis",
+			Required: true,
+		},
+	}
+	return append(flags, BackendWithConfig(cfg)...)
+}
+
+// ReencryptWithConfig applies cfg to the reencrypt flagset
+func ReencryptWithConfig(cfg *config.Config) []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config-file",
+			Value:       "",
+			Usage:       "Path to config file",
+			EnvVars:     []string{"STORE_CONFIG_FILE"},
+			Destination: &cfg.File,
+		},
+		&cli.StringFlag{
+			Name:        "data-path",
+			Value:       "/var/tmp/ocis/store",
+			Usage:       "location of the store data path",
+			EnvVars:     []string{"STORE_DATA_PATH"},
+			Destination: &cfg.Datapath,
+		},
+	}
+	flags = append(flags, BackendWithConfig(cfg)...)
+	return append(flags, EncryptionWithConfig(cfg)...)
+}
+
+// BackendWithConfig applies cfg to the backend flagset
+func BackendWithConfig(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "backend",
+			Value:       "disk",
+			Usage:       "Backend which stores the records: disk, bolt or redis. Every backend serves a single store instance",
+			EnvVars:     []string{"STORE_BACKEND"},
+			Destination: &cfg.Backend.Type,
+		},
+		&cli.StringFlag{
+			Name:        "bolt-path",
+			Value:       "",
+			Usage:       "Path to the bolt file, defaults to store.db in the data path",
+			EnvVars:     []string{"STORE_BOLT_PATH"},
+			Destination: &cfg.Backend.Bolt.Path,
+		},
+		&cli.StringFlag{
+			Name:        "redis-addr",
+			Value:       "127.0.0.1:6379",
+			Usage:       "Address of the redis server, its database can only be used by one store instance",
+			EnvVars:     []string{"STORE_REDIS_ADDR"},
+			Destination: &cfg.Backend.Redis.Addr,
+		},
+		&cli.StringFlag{
+			Name:        "redis-password",
+			Value:       "",
+			Usage:       "Password of the redis server",
+			EnvVars:     []string{"STORE_REDIS_PASSWORD"},
+			Destination: &cfg.Backend.Redis.Password,
+		},
+		&cli.IntFlag{
+			Name:        "redis-db",
+			Value:       0,
+			Usage:       "Redis database which stores the records, it can only be used by one store instance",
+			EnvVars:     []string{"STORE_REDIS_DB"},
+			Destination: &cfg.Backend.Redis.DB,
+		},
+	}
+}
+
+// EncryptionWithConfig applies cfg to the encryption flagset
+func EncryptionWithConfig(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "encryption-keys",
+			Value:       "",
+			Usage:       "Comma separated id:key master keys with base64 encoded 32 byte keys, the first one encrypts new records",
+			EnvVars:     []string{"STORE_ENCRYPTION_KEYS"},
+			Destination: &cfg.Encryption.Keys,
+		},
+		&cli.StringFlag{
+			Name:        "encryption-key-file",
+			Value:       "",
+			Usage:       "Path to a file with one id:key master key per line, used if no keys are given",
+			EnvVars:     []string{"STORE_ENCRYPTION_KEY_FILE"},
+			Destination: &cfg.Encryption.KeyFile,
+		},
+	}
+}
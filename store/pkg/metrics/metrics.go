@@ -14,6 +14,10 @@ var (
 type Metrics struct {
 	// Counter  *prometheus.CounterVec
 	BuildInfo *prometheus.GaugeVec
+	// Expired counts the expired records which were hidden from reads and lists.
+	Expired *prometheus.CounterVec
+	// Swept counts the expired records which were deleted by the sweeper.
+	Swept *prometheus.CounterVec
 }
 
 // New initializes the available metrics.
@@ -31,6 +35,18 @@ func New() *Metrics {
 			Name:      "build_info",
 			Help:      "Build Information",
 		}, []string{"version"}),
+		Expired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "expired_records_total",
+			Help:      "How many expired records were hidden from reads",
+		}, []string{}),
+		Swept: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "swept_records_total",
+			Help:      "How many expired records were deleted",
+		}, []string{}),
 	}
 
 	// prometheus.Register(
@@ -41,5 +57,13 @@ func New() *Metrics {
 		m.BuildInfo,
 	)
 
+	_ = prometheus.Register(
+		m.Expired,
+	)
+
+	_ = prometheus.Register(
+		m.Swept,
+	)
+
 	return m
 }
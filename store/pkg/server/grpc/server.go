@@ -1,6 +1,9 @@
 package grpc
 
 import (
+	"time"
+
+	"github.com/micro/go-micro/v2"
 	"github.com/owncloud/ocis/ocis-pkg/service/grpc"
 	"github.com/owncloud/ocis/store/pkg/proto/v0"
 	svc "github.com/owncloud/ocis/store/pkg/service/v0"
@@ -23,14 +26,17 @@ func Server(opts ...Option) grpc.Service {
 	hdlr, err := svc.New(
 		svc.Logger(options.Logger),
 		svc.Config(options.Config),
+		svc.Metrics(options.Metrics),
 	)
 	if err != nil {
 		options.Logger.Fatal().Err(err).Msg("could not initialize service handler")
 	}
+	go hdlr.RunSweeper(options.Context, time.Duration(options.Config.SweepInterval)*time.Second)
 	if err = proto.RegisterStoreHandler(service.Server(), hdlr); err != nil {
 		options.Logger.Fatal().Err(err).Msg("could not register service handler")
 	}
 
-	service.Init()
+	// closing the service records the state of the index and releases the backend
+	service.Init(micro.AfterStop(hdlr.Close))
 	return service
 }
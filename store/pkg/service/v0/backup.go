@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/owncloud/ocis/ocis-pkg/backup"
+	"github.com/owncloud/ocis/store/pkg/storage"
+)
+
+// BackupSource backs up the records of the configured backend in the disk layout. The index isn't backed up, it is
+// rebuilt when the records are restored. The store must not be running.
+type BackupSource struct {
+	options Options
+}
+
+// NewBackupSource returns the backup source of the store.
+func NewBackupSource(opts ...Option) backup.Source {
+	return BackupSource{options: newOptions(opts...)}
+}
+
+// Name implements the backup.Source interface.
+func (b BackupSource) Name() string {
+	return "store"
+}
+
+// Export implements the backup.Source interface. The records are copied from the backend opened read-only, the store
+// isn't opened, so the index and the journal are left as they are.
+func (b BackupSource) Export(dir string) error {
+	if err := b.checkExportable(); err != nil {
+		return err
+	}
+	backend := b.options.Backend
+	if backend == nil {
+		var err error
+		if backend, err = storage.NewReadOnly(b.options.Config, b.options.Logger); err != nil {
+			return err
+		}
+	}
+	defer backend.Close()
+
+	disk, err := storage.NewDisk(dir, b.options.Logger)
+	if err != nil {
+		return err
+	}
+	_, err = storage.Copy(backend, disk)
+	return err
+}
+
+// checkExportable returns an error if the records can only be exported after the store was started once: the records
+// of a disk backend still have an older layout or a batch interrupted by a crash wasn't rolled back yet.
+func (b BackupSource) checkExportable() error {
+	datapath := b.options.Config.Datapath
+	if b.options.Backend == nil && (b.options.Config.Backend.Type == "" || b.options.Config.Backend.Type == "disk") && !layoutIsCurrent(datapath) {
+		for _, dir := range []string{"databases", "databases.legacy"} {
+			if _, err := os.Stat(filepath.Join(datapath, dir)); err == nil {
+				return errors.New("the records have an older layout, start and stop the store once to migrate them")
+			}
+		}
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Join(datapath, "journal"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), batchPrefix) {
+			return errors.New("a batch was interrupted, start and stop the store once to roll it back")
+		}
+	}
+	return nil
+}
+
+// CheckEmpty implements the backup.Source interface.
+func (b BackupSource) CheckEmpty() error {
+	s, err := b.openBackend()
+	if err != nil {
+		return err
+	}
+	defer s.backend.Close()
+	return checkEmpty(s.backend)
+}
+
+// Import implements the backup.Source interface.
+func (b BackupSource) Import(dir string) error {
+	s, err := b.openBackend()
+	if err != nil {
+		return err
+	}
+	if err := checkEmpty(s.backend); err != nil {
+		s.backend.Close()
+		return err
+	}
+
+	disk, err := storage.NewDisk(dir, b.options.Logger)
+	if err != nil {
+		s.backend.Close()
+		return err
+	}
+	if _, err := storage.Copy(disk, s.backend); err != nil {
+		s.backend.Close()
+		return err
+	}
+
+	if err := s.rebuildIndex(); err != nil {
+		s.backend.Close()
+		return err
+	}
+	return s.Close()
+}
+
+// openBackend opens the backend without the index, which is rebuilt when the records are restored.
+func (b BackupSource) openBackend() (*Service, error) {
+	s := &Service{
+		log:    b.options.Logger,
+		Config: b.options.Config,
+	}
+	if err := s.openBackend(b.options.Backend); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func checkEmpty(backend storage.Backend) error {
+	databases, err := backend.Databases()
+	if err != nil {
+		return err
+	}
+	if len(databases) > 0 {
+		return errors.New("the store already has records")
+	}
+	return nil
+}
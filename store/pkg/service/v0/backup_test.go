@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owncloud/ocis/ocis-pkg/backup"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{"sessions": {"s1"}})
+	defer cleanup()
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	writeAccount(t, s, "marie", "marie@example.org")
+	assert.NoError(t, s.Close())
+
+	archive := &bytes.Buffer{}
+	_, err := backup.Create(archive, "test", NewBackupSource(Logger(log.NewLogger()), Config(s.Config)))
+	assert.NoError(t, err)
+
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	cfg := config.New()
+	cfg.Datapath = root
+	cfg.Backend.Type = "bolt"
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), NewBackupSource(Logger(log.NewLogger()), Config(cfg)))
+	assert.NoError(t, err)
+
+	restored, err := New(Logger(log.NewLogger()), Config(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"einstein"}, findAccounts(t, restored, "einstein@example.org"))
+	for table, keys := range map[string][]string{"accounts": {"einstein", "marie"}, "sessions": {"s1"}} {
+		stream := &listStream{}
+		err := restored.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: table}}, stream)
+		assert.NoError(t, err)
+		assert.Equal(t, keys, stream.keys())
+	}
+	assert.NoError(t, restored.Close())
+
+	// the store already has records
+	_, err = backup.Restore(bytes.NewReader(archive.Bytes()), NewBackupSource(Logger(log.NewLogger()), Config(cfg)))
+	assert.Error(t, err)
+}
+
+// files returns the files of a directory and their sizes.
+func files(t *testing.T, root string) map[string]int64 {
+	sizes := map[string]int64{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		sizes[p] = info.Size()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sizes
+}
+
+func TestExportDoesNotOpenTheStore(t *testing.T) {
+	for _, backend := range []string{"disk", "bolt"} {
+		s, cleanup := newTestService(t, nil)
+		defer cleanup()
+		assert.NoError(t, s.Close())
+		s.Config.Backend.Type = backend
+		s, err := New(Logger(log.NewLogger()), Config(s.Config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeAccount(t, s, "einstein", "einstein@example.org")
+		assert.NoError(t, s.Close())
+		// a record which was added without the store would be indexed on the next start
+		changeAccount(t, s, "marie", "marie@example.org")
+		before := files(t, s.Config.Datapath)
+
+		dir, err := ioutil.TempDir("", "store-export")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		assert.NoError(t, NewBackupSource(Logger(log.NewLogger()), Config(s.Config)).Export(dir))
+		assert.Equal(t, before, files(t, s.Config.Datapath), backend)
+
+		exported, err := storage.NewDisk(dir, log.NewLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := exported.Keys("proxy", "accounts")
+		assert.NoError(t, err)
+		if backend == "disk" {
+			assert.Equal(t, []string{"einstein", "marie"}, keys)
+		} else {
+			assert.Equal(t, []string{"einstein"}, keys)
+		}
+	}
+}
+
+func TestExportRefusesInterruptedBatches(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	assert.NoError(t, s.Close())
+	// the batch is rolled back by the next start
+	if _, err := s.journalBatch(&batchEntry{Database: "proxy", Table: "accounts"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "store-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.Error(t, NewBackupSource(Logger(log.NewLogger()), Config(s.Config)).Export(dir))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxBatchSize is the maximum number of operations of a batch.
+const maxBatchSize = 1000
+
+// BatchWrite implements the StoreHandler interface. The conditions of all operations are checked before any of them
+// is applied, operations on the same key see the changes of the earlier ones. The batch is journaled with the previous
+// and the written values, so a batch which fails or is interrupted by a crash is rolled back.
+func (s *Service) BatchWrite(c context.Context, breq *proto.BatchWriteRequest, bres *proto.BatchWriteResponse) error {
+	if err := s.validateTable(breq.Database, breq.Table); err != nil {
+		return err
+	}
+	if len(breq.Operations) > maxBatchSize {
+		return merrors.BadRequest(s.id, "a batch can't have more than %d operations", maxBatchSize)
+	}
+
+	now := time.Now()
+	values := make([]map[string]interface{}, len(breq.Operations))
+	for i, op := range breq.Operations {
+		key := op.GetRecord().GetKey()
+		if op.Record == nil {
+			key = op.Delete
+		}
+		if err := validateName("key", key); err != nil {
+			return merrors.BadRequest(s.id, "operation %d: %v", i, err)
+		}
+		if op.Record == nil {
+			continue
+		}
+		var err error
+		if values[i], err = typedValues(op.Record.Metadata); err != nil {
+			return merrors.BadRequest(s.id, "operation %d: %v", i, err)
+		}
+		op.Record.ExpiresAt = expiresAt(op.Record, op.Options, now)
+		op.Record.Expiry = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the previous value of every key and its version after the operations checked so far
+	type state struct {
+		previous []byte
+		version  uint64
+		exists   bool
+	}
+	states := map[string]*state{}
+	keys := make([]string, 0, len(breq.Operations))
+	final := map[string]*proto.Record{}
+	for i, op := range breq.Operations {
+		key := op.Delete
+		if op.Record != nil {
+			key = op.Record.Key
+		}
+		st, ok := states[key]
+		if !ok {
+			previous, err := s.backend.Get(breq.Database, breq.Table, key)
+			if err != nil && !storage.IsNotFoundErr(err) {
+				s.log.Error().Err(err).Str("key", key).Msg("could not read record")
+				return merrors.InternalServerError(s.id, "could not write records")
+			}
+			st = &state{previous: previous}
+			if previous != nil {
+				if st.version, st.exists, err = recordVersion(previous, now); err != nil {
+					s.log.Error().Err(err).Str("key", key).Msg("could not read record version")
+					return merrors.InternalServerError(s.id, "could not write records")
+				}
+			}
+			states[key] = st
+			keys = append(keys, key)
+		}
+		if err := s.checkConditions(op.Options, st.version, st.exists); err != nil {
+			return merrors.Conflict(s.id, "operation %d: %s", i, merrors.Parse(err.Error()).Detail)
+		}
+		final[key] = op.Record
+		if op.Record == nil {
+			if !st.exists {
+				return merrors.NotFound(s.id, "operation %d: could not find record", i)
+			}
+			st.version, st.exists = 0, false
+			continue
+		}
+		op.Record.Version = st.version + 1
+		st.version, st.exists = op.Record.Version, true
+	}
+
+	// the journal and the backend get the encrypted records, the events the plain ones
+	e := &batchEntry{
+		Database: breq.Database,
+		Table:    breq.Table,
+		Changes:  make([]batchChange, 0, len(keys)),
+	}
+	for _, key := range keys {
+		c := batchChange{Change: storage.Change{Key: key}, Previous: states[key].previous}
+		if rec := final[key]; rec != nil {
+			stored, err := s.sealRecord(storage.ID(breq.Database, breq.Table, key), rec)
+			if err != nil {
+				s.log.Error().Err(err).Str("key", key).Msg("could not encrypt record")
+				return merrors.InternalServerError(s.id, "could not write records")
+			}
+			if c.Value, err = protojson.Marshal(stored); err != nil {
+				return merrors.InternalServerError(s.id, "could not marshal record")
+			}
+		}
+		e.Changes = append(e.Changes, c)
+	}
+
+	entry, err := s.journalBatch(e)
+	if err != nil {
+		s.log.Error().Err(err).Msg("could not journal batch")
+		return merrors.InternalServerError(s.id, "could not write records")
+	}
+	if err := s.applyBatch(e); err != nil {
+		s.log.Error().Err(err).Msg("could not apply batch")
+		s.abortBatch(entry, e)
+		return merrors.InternalServerError(s.id, "could not write records")
+	}
+
+	batch := s.index.NewBatch()
+	for i, op := range breq.Operations {
+		if op.Record == nil {
+			batch.Delete(storage.ID(breq.Database, breq.Table, op.Delete))
+			continue
+		}
+		doc := BleveDocument{
+			Metadata:  op.Record.Metadata,
+			Values:    values[i],
+			Database:  breq.Database,
+			Table:     breq.Table,
+			ExpiresAt: op.Record.ExpiresAt,
+		}
+		if err := batch.Index(storage.ID(breq.Database, breq.Table, op.Record.Key), doc); err != nil {
+			s.log.Error().Err(err).Interface("document", doc).Msg("could not index record metadata")
+			s.abortBatch(entry, e)
+			return merrors.InternalServerError(s.id, "could not index records")
+		}
+	}
+	if err := s.index.Batch(batch); err != nil {
+		s.log.Error().Err(err).Msg("could not index batch")
+		s.abortBatch(entry, e)
+		return merrors.InternalServerError(s.id, "could not index records")
+	}
+
+	s.commitBatch(entry)
+	bres.Versions = make([]uint64, len(breq.Operations))
+	for i, op := range breq.Operations {
+		ev := &proto.WatchResponse{
+			Type:     proto.WatchResponse_TYPE_DELETE,
+			Database: breq.Database,
+			Table:    breq.Table,
+			Key:      op.Delete,
+		}
+		if op.Record != nil {
+			ev.Type = proto.WatchResponse_TYPE_WRITE
+			ev.Key = op.Record.Key
+			ev.Record = op.Record
+			bres.Versions[i] = op.Record.Version
+		}
+		s.publish(ev)
+	}
+	return nil
+}
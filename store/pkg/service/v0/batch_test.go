@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func writeKey(s *Service, key, value string, opts *proto.WriteOptions) (uint64, error) {
+	if opts == nil {
+		opts = &proto.WriteOptions{}
+	}
+	opts.Database = "proxy"
+	opts.Table = "signing-keys"
+	rsp := &proto.WriteResponse{}
+	err := s.Write(context.Background(), &proto.WriteRequest{Record: &proto.Record{Key: key, Value: []byte(value)}, Options: opts}, rsp)
+	return rsp.Version, err
+}
+
+func readKey(t *testing.T, s *Service, key string) *proto.Record {
+	rsp := &proto.ReadResponse{}
+	err := s.Read(context.Background(), &proto.ReadRequest{Key: key, Options: &proto.ReadOptions{Database: "proxy", Table: "signing-keys"}}, rsp)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		t.Fatal(err)
+	}
+	return rsp.Records[0]
+}
+
+func assertCode(t *testing.T, code int, err error) {
+	if assert.Error(t, err) {
+		assert.EqualValues(t, code, merrors.Parse(err.Error()).Code, err.Error())
+	}
+}
+
+func TestConditionalWrite(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	v, err := writeKey(s, "einstein", "first", &proto.WriteOptions{IfAbsent: true})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, v)
+
+	// a concurrent writer lost the race
+	_, err = writeKey(s, "einstein", "second", &proto.WriteOptions{IfAbsent: true})
+	assertCode(t, http.StatusConflict, err)
+
+	v, err = writeKey(s, "einstein", "updated", &proto.WriteOptions{IfVersion: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, v)
+
+	_, err = writeKey(s, "einstein", "stale", &proto.WriteOptions{IfVersion: 1})
+	assertCode(t, http.StatusConflict, err)
+	_, err = writeKey(s, "marie", "missing", &proto.WriteOptions{IfVersion: 1})
+	assertCode(t, http.StatusConflict, err)
+
+	rec := readKey(t, s, "einstein")
+	assert.Equal(t, "updated", string(rec.Value))
+	assert.EqualValues(t, 2, rec.Version)
+
+	// unconditional writes still increase the version
+	v, err = writeKey(s, "einstein", "overwritten", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, v)
+
+	// expired records are absent
+	_, err = writeKey(s, "feynman", "expired", &proto.WriteOptions{Expiry: time.Now().Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+	v, err = writeKey(s, "feynman", "recreated", &proto.WriteOptions{IfAbsent: true})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, v)
+}
+
+func batchWrite(s *Service, ops ...*proto.BatchWriteOperation) ([]uint64, error) {
+	rsp := &proto.BatchWriteResponse{}
+	err := s.BatchWrite(context.Background(), &proto.BatchWriteRequest{Database: "proxy", Table: "signing-keys", Operations: ops}, rsp)
+	return rsp.Versions, err
+}
+
+func put(key, value string, opts *proto.WriteOptions) *proto.BatchWriteOperation {
+	return &proto.BatchWriteOperation{Record: &proto.Record{Key: key, Value: []byte(value)}, Options: opts}
+}
+
+func del(key string, opts *proto.WriteOptions) *proto.BatchWriteOperation {
+	return &proto.BatchWriteOperation{Delete: key, Options: opts}
+}
+
+func TestBatchWrite(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	_, err := writeKey(s, "marie", "old", nil)
+	assert.NoError(t, err)
+
+	versions, err := batchWrite(s,
+		put("einstein", "e1", &proto.WriteOptions{IfAbsent: true}),
+		put("einstein", "e2", &proto.WriteOptions{IfVersion: 1}),
+		put("feynman", "f1", nil),
+		del("marie", &proto.WriteOptions{IfVersion: 1}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 1, 0}, versions)
+
+	assert.Equal(t, "e2", string(readKey(t, s, "einstein").Value))
+	assert.Equal(t, "f1", string(readKey(t, s, "feynman").Value))
+	assert.Nil(t, readKey(t, s, "marie"))
+	count, err := s.index.DocCount()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	entries, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "journal"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestBatchWriteIsAtomic(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	_, err := writeKey(s, "einstein", "e1", nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		code int
+		ops  []*proto.BatchWriteOperation
+	}{
+		{"stale version", http.StatusConflict, []*proto.BatchWriteOperation{
+			put("feynman", "f1", nil),
+			put("einstein", "e2", &proto.WriteOptions{IfVersion: 2}),
+		}},
+		{"not absent", http.StatusConflict, []*proto.BatchWriteOperation{
+			put("feynman", "f1", nil),
+			put("feynman", "f2", &proto.WriteOptions{IfAbsent: true}),
+		}},
+		{"deleted earlier in the batch", http.StatusConflict, []*proto.BatchWriteOperation{
+			del("einstein", nil),
+			put("einstein", "e2", &proto.WriteOptions{IfVersion: 1}),
+		}},
+		{"delete missing record", http.StatusNotFound, []*proto.BatchWriteOperation{
+			put("feynman", "f1", nil),
+			del("marie", nil),
+		}},
+		{"invalid key", http.StatusBadRequest, []*proto.BatchWriteOperation{
+			put("feynman", "f1", nil),
+			del("", nil),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := batchWrite(s, tt.ops...)
+			assertCode(t, tt.code, err)
+
+			assert.Nil(t, readKey(t, s, "feynman"))
+			rec := readKey(t, s, "einstein")
+			assert.Equal(t, "e1", string(rec.Value))
+			assert.EqualValues(t, 1, rec.Version)
+		})
+	}
+}
+
+// failingBackend fails to write a key, to interrupt batches. It hides the transactions of the wrapped backend.
+type failingBackend struct {
+	storage.Backend
+	key string
+}
+
+func (b failingBackend) Put(database, table, key string, value []byte) error {
+	if key == b.key {
+		return errors.New("disk full")
+	}
+	return b.Backend.Put(database, table, key, value)
+}
+
+func TestFailedBatchIsRolledBack(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	_, err := writeKey(s, "marie", "old", nil)
+	assert.NoError(t, err)
+	s.backend = failingBackend{Backend: s.backend, key: "marie"}
+
+	_, err = batchWrite(s,
+		put("einstein", "e1", nil),
+		put("marie", "new", &proto.WriteOptions{IfVersion: 1}),
+	)
+	assertCode(t, http.StatusInternalServerError, err)
+
+	assert.Nil(t, readKey(t, s, "einstein"))
+	assert.Equal(t, "old", string(readKey(t, s, "marie").Value))
+	count, err := s.index.DocCount()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	entries, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "journal"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestBatchIsRolledBackAfterCrash(t *testing.T) {
+	for name, lostIndex := range map[string]bool{"journal is replayed": false, "index is rebuilt": true} {
+		lostIndex := lostIndex
+		t.Run(name, func(t *testing.T) {
+			s, cleanup := newTestService(t, nil)
+			defer cleanup()
+
+			_, err := writeKey(s, "marie", "old", nil)
+			assert.NoError(t, err)
+			marie := storedRecord(t, s, "marie")
+			previous, err := protojson.Marshal(marie)
+			assert.NoError(t, err)
+
+			// the store crashed while applying a batch, feynman was written again after the batch
+			einstein, err := protojson.Marshal(&proto.Record{Key: "einstein", Value: []byte("e1"), Version: 1, Metadata: map[string]*proto.Field{"kind": {Value: "key"}}})
+			assert.NoError(t, err)
+			feynman, err := protojson.Marshal(&proto.Record{Key: "feynman", Value: []byte("f1"), Version: 1})
+			assert.NoError(t, err)
+			_, err = s.journalBatch(&batchEntry{Database: "proxy", Table: "signing-keys", Changes: []batchChange{
+				{Change: storage.Change{Key: "einstein", Value: einstein}},
+				{Change: storage.Change{Key: "feynman", Value: feynman}},
+				{Change: storage.Change{Key: "marie"}, Previous: previous},
+			}})
+			assert.NoError(t, err)
+			assert.NoError(t, s.backend.Put("proxy", "signing-keys", "einstein", einstein))
+			assert.NoError(t, s.backend.Put("proxy", "signing-keys", "feynman", feynman))
+			_, err = writeKey(s, "feynman", "newer", nil)
+			assert.NoError(t, err)
+			if lostIndex {
+				assert.NoError(t, os.Remove(filepath.Join(s.Config.Datapath, "index-version")))
+			}
+
+			s = restart(t, s)
+			assert.Nil(t, readKey(t, s, "einstein"))
+			assert.Equal(t, "newer", string(readKey(t, s, "feynman").Value))
+			assert.Equal(t, "old", string(readKey(t, s, "marie").Value))
+
+			where := map[string]*proto.Field{"kind": {Value: "key"}}
+			rsp := &proto.ReadResponse{}
+			err = s.Read(context.Background(), &proto.ReadRequest{Options: &proto.ReadOptions{Database: "proxy", Table: "signing-keys", Where: where}}, rsp)
+			assert.NoError(t, err)
+			assert.Empty(t, rsp.Records)
+			count, err := s.index.DocCount()
+			assert.NoError(t, err)
+			assert.EqualValues(t, 2, count)
+			// the journal is removed when the index is rebuilt
+			entries, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "journal"))
+			if !os.IsNotExist(err) {
+				assert.NoError(t, err)
+			}
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestLegacyRecordsAreVersioned(t *testing.T) {
+	// records written before records were versioned
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": {"einstein"}})
+	defer cleanup()
+
+	rec := readKey(t, s, "einstein")
+	assert.EqualValues(t, legacyVersion, rec.Version)
+
+	_, err := writeKey(s, "einstein", "absent", &proto.WriteOptions{IfAbsent: true})
+	assertCode(t, http.StatusConflict, err)
+	v, err := writeKey(s, "einstein", "updated", &proto.WriteOptions{IfVersion: rec.Version})
+	assert.NoError(t, err)
+	assert.EqualValues(t, legacyVersion+1, v)
+	_, err = writeKey(s, "einstein", "stale", &proto.WriteOptions{IfVersion: rec.Version})
+	assertCode(t, http.StatusConflict, err)
+}
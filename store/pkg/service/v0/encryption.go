@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// sealRecord returns a copy of the record with an encrypted value, which is persisted instead of the record. The
+// ciphertext is bound to the id of the record, so it can't be moved to another record. Without a keyring the record
+// is returned as it is.
+func (s *Service) sealRecord(id string, rec *proto.Record) (*proto.Record, error) {
+	if s.keyring == nil {
+		return rec, nil
+	}
+	keyID, dataKey, ciphertext, err := s.keyring.Seal(rec.Value, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	sealed := protobuf.Clone(rec).(*proto.Record)
+	sealed.Value = ciphertext
+	sealed.Encryption = &proto.Encryption{
+		KeyId:   keyID,
+		DataKey: dataKey,
+	}
+	return sealed, nil
+}
+
+// openRecord decrypts the value of a persisted record in place. Records which are not encrypted are left as they are.
+func (s *Service) openRecord(id string, rec *proto.Record) error {
+	if rec.Encryption == nil {
+		return nil
+	}
+	if s.keyring == nil {
+		return errors.New("the record is encrypted but no encryption keys are configured")
+	}
+	value, err := s.keyring.Open(rec.Encryption.KeyId, rec.Encryption.DataKey, rec.Value, []byte(id))
+	if err != nil {
+		return err
+	}
+	rec.Value = value
+	rec.Encryption = nil
+	return nil
+}
+
+// Reencrypt encrypts the values of all records, which are not encrypted with the current master key yet, with the
+// current master key and returns the number of changed records. Older master keys can be removed afterwards. The
+// store must not be running.
+func Reencrypt(opts ...Option) (int, error) {
+	s, err := New(opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	if s.keyring == nil {
+		return 0, errors.New("no encryption keys are configured")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := 0
+	err = storage.Walk(s.backend, func(database, table, key string) error {
+		id := storage.ID(database, table, key)
+		data, err := s.backend.Get(database, table, key)
+		if err != nil {
+			if storage.IsNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		rec := &proto.Record{}
+		if err := protojson.Unmarshal(data, rec); err != nil {
+			return fmt.Errorf("could not unmarshal %s: %w", id, err)
+		}
+		if rec.Encryption != nil && rec.Encryption.KeyId == s.keyring.Current() {
+			return nil
+		}
+
+		if err := s.openRecord(id, rec); err != nil {
+			return fmt.Errorf("could not decrypt %s: %w", id, err)
+		}
+		sealed, err := s.sealRecord(id, rec)
+		if err != nil {
+			return err
+		}
+		if data, err = protojson.Marshal(sealed); err != nil {
+			return err
+		}
+		if err := s.backend.Put(database, table, key, data); err != nil {
+			return err
+		}
+		changed++
+		return nil
+	})
+	return changed, err
+}
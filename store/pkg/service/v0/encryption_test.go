@@ -0,0 +1,154 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	oldKey = "old:MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	newKey = "new:ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+// withKeys restarts the service with the given master keys.
+func withKeys(t *testing.T, s *Service, keys ...string) *Service {
+	s.Config.Encryption.Keys = strings.Join(keys, ",")
+	return restart(t, s)
+}
+
+// storedRecord returns the record as it is kept by the backend.
+func storedRecord(t *testing.T, s *Service, key string) *proto.Record {
+	data, err := s.backend.Get("proxy", "signing-keys", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &proto.Record{}
+	if err := protojson.Unmarshal(data, rec); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestEncryptedWrite(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+	s = withKeys(t, s, oldKey)
+
+	_, err := writeKey(s, "einstein", "secret", nil)
+	assert.NoError(t, err)
+	_, err = batchWrite(s, put("marie", "also secret", nil))
+	assert.NoError(t, err)
+
+	for key, value := range map[string]string{"einstein": "secret", "marie": "also secret"} {
+		stored := storedRecord(t, s, key)
+		assert.Equal(t, "old", stored.Encryption.GetKeyId())
+		assert.NotContains(t, string(stored.Value), value)
+		assert.Equal(t, value, string(readKey(t, s, key).Value))
+	}
+
+	// the ciphertext is bound to its record
+	einstein := storedRecord(t, s, "einstein")
+	data, err := protojson.Marshal(&proto.Record{Key: "marie", Value: einstein.Value, Encryption: einstein.Encryption})
+	assert.NoError(t, err)
+	assert.NoError(t, s.backend.Put("proxy", "signing-keys", "marie", data))
+	_, err = s.readRecord(storage.ID("proxy", "signing-keys", "marie"))
+	assertCode(t, http.StatusInternalServerError, err)
+
+	// the records can't be read without the key
+	s = withKeys(t, s)
+	_, err = s.readRecord(storage.ID("proxy", "signing-keys", "einstein"))
+	assertCode(t, http.StatusInternalServerError, err)
+}
+
+// journalSnoop keeps the journal entries which exist while values are written.
+type journalSnoop struct {
+	storage.Backend
+	dir     string
+	entries []string
+}
+
+func (b *journalSnoop) Put(database, table, key string, value []byte) error {
+	files, _ := ioutil.ReadDir(b.dir)
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(b.dir, f.Name()))
+		if err != nil {
+			return err
+		}
+		b.entries = append(b.entries, string(data))
+	}
+	return b.Backend.Put(database, table, key, value)
+}
+
+func TestEncryptedBatchJournal(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+	s = withKeys(t, s, oldKey)
+	_, err := writeKey(s, "einstein", "old secret", nil)
+	assert.NoError(t, err)
+
+	snoop := &journalSnoop{Backend: s.backend, dir: filepath.Join(s.Config.Datapath, "journal")}
+	s.backend = snoop
+	_, err = batchWrite(s, put("einstein", "secret", nil))
+	assert.NoError(t, err)
+
+	if assert.Len(t, snoop.entries, 1) {
+		// base64 of the values
+		assert.NotContains(t, snoop.entries[0], "c2VjcmV0")
+		assert.NotContains(t, snoop.entries[0], "b2xkIHNlY3JldA")
+	}
+	assert.Equal(t, "secret", string(readKey(t, s, "einstein").Value))
+}
+
+func TestPlaintextRecordsAreReadable(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": {"einstein"}})
+	defer cleanup()
+	s = withKeys(t, s, oldKey)
+
+	assert.Equal(t, "signing-keys:einstein", string(readKey(t, s, "einstein").Value))
+	assert.Nil(t, storedRecord(t, s, "einstein").Encryption)
+}
+
+func TestReencrypt(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": {"feynman"}})
+	defer cleanup()
+	s = withKeys(t, s, oldKey)
+	_, err := writeKey(s, "einstein", "secret", nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Close())
+	cfg := s.Config
+	cfg.Encryption.Keys = strings.Join([]string{newKey, oldKey}, ",")
+	n, err := Reencrypt(Logger(log.NewLogger()), Config(cfg))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = Reencrypt(Logger(log.NewLogger()), Config(cfg))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// the old key is no longer needed
+	cfg.Encryption.Keys = newKey
+	s, err = New(Logger(log.NewLogger()), Config(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "new", storedRecord(t, s, "einstein").Encryption.GetKeyId())
+	assert.Equal(t, "new", storedRecord(t, s, "feynman").Encryption.GetKeyId())
+	assert.Equal(t, "secret", string(readKey(t, s, "einstein").Value))
+	assert.Equal(t, "signing-keys:feynman", string(readKey(t, s, "feynman").Value))
+	assert.EqualValues(t, 1, readKey(t, s, "einstein").Version)
+
+	// without keys there is nothing to encrypt with
+	assert.NoError(t, s.Close())
+	cfg.Encryption.Keys = ""
+	_, err = Reencrypt(Logger(log.NewLogger()), Config(cfg))
+	assert.Error(t, err)
+}
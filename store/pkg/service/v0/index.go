@@ -0,0 +1,311 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/registry"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// indexBatchSize is the number of documents indexed at once when the index is rebuilt.
+	indexBatchSize = 1000
+
+	// indexVersion is increased whenever the mapping or the documents of the index change, indexes of older versions
+	// are rebuilt.
+	indexVersion = 3
+
+	// noDateTimeParser is the default date time parser of the index.
+	noDateTimeParser = "store-no-datetime"
+
+	// indexRecordsFile holds the number of records in the index when the store was shut down. It is removed when the
+	// index is opened, so it only exists if the index wasn't changed since it was closed.
+	indexRecordsFile = "index-records"
+)
+
+func init() {
+	registry.RegisterDateTimeParser(noDateTimeParser, func(map[string]interface{}, *registry.Cache) (analysis.DateTimeParser, error) {
+		return textOnly{}, nil
+	})
+}
+
+// textOnly is a date time parser which never succeeds, so strings which look like dates are still indexed as text.
+// Dates are indexed from metadata of the type time.
+type textOnly struct{}
+
+func (textOnly) ParseDateTime(string) (time.Time, error) {
+	return time.Time{}, analysis.ErrInvalidDateTime
+}
+
+// Reindex rebuilds the index of the store from the records of the backend and returns the number of indexed records.
+// Records of an older disk layout are migrated first. The store must not be running.
+func Reindex(opts ...Option) (uint64, error) {
+	options := newOptions(opts...)
+	s := &Service{
+		id:     options.Config.Service.Namespace + "." + options.Config.Service.Name,
+		log:    options.Logger,
+		Config: options.Config,
+	}
+	if err := s.openBackend(options.Backend); err != nil {
+		return 0, err
+	}
+	if err := s.rebuildIndex(); err != nil {
+		s.backend.Close()
+		return 0, err
+	}
+	defer s.Close()
+
+	return s.index.DocCount()
+}
+
+func newIndexMapping() mapping.IndexMapping {
+	indexMapping := bleve.NewIndexMapping()
+	// keep all symbols in terms to allow exact matching, eg. emails
+	indexMapping.DefaultAnalyzer = keyword.Name
+	indexMapping.DefaultDateTimeParser = noDateTimeParser
+	return indexMapping
+}
+
+// openIndex opens the index kept since the last start and replays the changes which were interrupted by a crash. The
+// index is rebuilt if it doesn't exist, has an older version, can't be opened or doesn't contain a document for every
+// record. The records are only counted if the store wasn't shut down cleanly or the index changed since.
+func (s *Service) openIndex() error {
+	if v, err := ioutil.ReadFile(filepath.Join(s.Config.Datapath, "index-version")); err != nil || strings.TrimSpace(string(v)) != strconv.Itoa(indexVersion) {
+		s.log.Info().Int("version", indexVersion).Msg("creating index")
+		return s.rebuildIndex()
+	}
+
+	index, err := bleve.Open(filepath.Join(s.Config.Datapath, "index.bleve"))
+	switch {
+	case err == bleve.ErrorIndexPathDoesNotExist:
+		s.log.Info().Msg("creating index")
+		return s.rebuildIndex()
+	case err != nil:
+		s.log.Error().Err(err).Msg("could not open index, rebuilding it")
+		return s.rebuildIndex()
+	}
+	s.index = index
+
+	if err := s.replayJournal(); err != nil {
+		s.log.Error().Err(err).Msg("could not replay journal, rebuilding the index")
+		index.Close()
+		return s.rebuildIndex()
+	}
+
+	docs, err := index.DocCount()
+	if err != nil {
+		return err
+	}
+	if records, ok := s.takeIndexRecords(); ok && records == docs {
+		return nil
+	}
+	records, err := s.countRecords()
+	if err != nil {
+		return err
+	}
+	if records != docs {
+		s.log.Warn().Uint64("records", records).Uint64("documents", docs).Msg("index is out of sync, rebuilding it")
+		index.Close()
+		return s.rebuildIndex()
+	}
+	return nil
+}
+
+// rebuildIndex recreates the index from the records of the backend.
+func (s *Service) rebuildIndex() (err error) {
+	indexDir := filepath.Join(s.Config.Datapath, "index.bleve")
+	if err = os.RemoveAll(indexDir); err != nil {
+		return err
+	}
+	if s.index, err = bleve.New(indexDir, newIndexMapping()); err != nil {
+		return err
+	}
+	if err = s.rollbackBatches(); err != nil {
+		return err
+	}
+	if err = s.indexRecords(); err != nil {
+		return err
+	}
+	// the index contains all changes of the journal
+	if err = os.RemoveAll(filepath.Join(s.Config.Datapath, "journal")); err != nil {
+		return err
+	}
+	return s.writeFile(filepath.Join(s.Config.Datapath, "index-version"), []byte(strconv.Itoa(indexVersion)))
+}
+
+// indexRecords adds the documents of all records to the index.
+func (s *Service) indexRecords() error {
+	batch := s.index.NewBatch()
+	err := storage.Walk(s.backend, func(database, table, key string) error {
+		id := storage.ID(database, table, key)
+		doc, err := s.document(id)
+		if err != nil {
+			s.log.Error().Err(err).Str("id", id).Msg("could not read record")
+			return nil
+		}
+		if err := batch.Index(id, doc); err != nil {
+			s.log.Error().Err(err).Interface("document", doc).Str("id", id).Msg("could not index record metadata")
+			return nil
+		}
+		if batch.Size() >= indexBatchSize {
+			if err := s.index.Batch(batch); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		s.log.Debug().Str("id", id).Msg("indexed record")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.index.Batch(batch)
+}
+
+// takeIndexRecords returns the number of records in the index when the store was shut down and removes it, so it
+// isn't trusted after a crash. ok is false if the store wasn't shut down cleanly.
+func (s *Service) takeIndexRecords() (records uint64, ok bool) {
+	file := filepath.Join(s.Config.Datapath, indexRecordsFile)
+	v, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, false
+	}
+	if err := os.Remove(file); err != nil {
+		s.log.Error().Err(err).Msg("could not remove the number of indexed records")
+		return 0, false
+	}
+	records, err = strconv.ParseUint(strings.TrimSpace(string(v)), 10, 64)
+	return records, err == nil
+}
+
+// CheckIndexOnNextStart makes the next start of the store count the records of the backend and rebuild the index if
+// it doesn't match. It has to be called when the records of the backend are changed without the store.
+func CheckIndexOnNextStart(cfg *config.Config) error {
+	err := os.Remove(filepath.Join(cfg.Datapath, indexRecordsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// closeIndex closes the index and records how many records it contains, so the next start doesn't need to count the
+// records of the backend.
+func (s *Service) closeIndex() error {
+	docs, derr := s.index.DocCount()
+	if err := s.index.Close(); err != nil {
+		return err
+	}
+	if derr != nil {
+		return nil
+	}
+	return s.writeFile(filepath.Join(s.Config.Datapath, indexRecordsFile), []byte(strconv.FormatUint(docs, 10)))
+}
+
+// countRecords returns the number of records without reading them.
+func (s *Service) countRecords() (uint64, error) {
+	var count uint64
+	err := storage.Walk(s.backend, func(database, table, key string) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// document reads a record and returns its index document. Records which can't be unmarshalled are indexed without
+// metadata, so the index still has a document for every record.
+func (s *Service) document(id string) (BleveDocument, error) {
+	database, table, _, err := storage.ParseID(id)
+	if err != nil {
+		return BleveDocument{}, err
+	}
+	doc := BleveDocument{
+		Database: database,
+		Table:    table,
+	}
+
+	data, err := s.get(id)
+	if err != nil {
+		return doc, err
+	}
+	rec := &proto.Record{}
+	if err := protojson.Unmarshal(data, rec); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not unmarshal record")
+		return doc, nil
+	}
+	doc.Metadata = rec.Metadata
+	doc.ExpiresAt = rec.ExpiresAt
+	if doc.Values, err = typedValues(rec.Metadata); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not parse metadata")
+	}
+	return doc, nil
+}
+
+// expiredIDs searches the index for the ids of the records which expired before now and match all filters.
+func (s *Service) expiredIDs(now time.Time, filters ...query.Query) (map[string]struct{}, error) {
+	// records which don't expire have no expiry in the index
+	min, max := float64(1), float64(now.Unix())
+	inclusive := true
+	eq := bleve.NewNumericRangeInclusiveQuery(&min, &max, &inclusive, &inclusive)
+	eq.SetField("expires_at")
+	q := bleve.NewConjunctionQuery(append(filters, eq)...)
+
+	ids := map[string]struct{}{}
+	for {
+		req := bleve.NewSearchRequestOptions(q, indexBatchSize, len(ids), false)
+		// the order doesn't matter, but it must be stable between the pages
+		req.SortBy([]string{"_id"})
+		res, err := s.index.Search(req)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range res.Hits {
+			ids[hit.ID] = struct{}{}
+		}
+		if len(res.Hits) < indexBatchSize {
+			return ids, nil
+		}
+	}
+}
+
+// tableQuery matches the documents of a table.
+func tableQuery(database, table string) query.Query {
+	dtq := bleve.NewTermQuery(database)
+	dtq.SetField("database")
+	ttq := bleve.NewTermQuery(table)
+	ttq.SetField("table")
+	return bleve.NewConjunctionQuery(dtq, ttq)
+}
+
+// reindex updates the document of a record to its state in the backend.
+func (s *Service) reindex(id string) error {
+	if !isRecord(id) {
+		return s.index.Delete(id)
+	}
+	doc, err := s.document(id)
+	switch {
+	case storage.IsNotFoundErr(err):
+		return s.index.Delete(id)
+	case err != nil:
+		return err
+	}
+	return s.index.Index(id, doc)
+}
+
+// isRecord checks if an id was returned by storage.ID.
+func isRecord(id string) bool {
+	_, _, _, err := storage.ParseID(id)
+	return err == nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func writeAccount(t *testing.T, s *Service, key, mail string) {
+	rec := &proto.Record{Key: key, Metadata: map[string]*proto.Field{"mail": {Type: "string", Value: mail}}}
+	opts := &proto.WriteOptions{Database: "proxy", Table: "accounts"}
+	if err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: opts}, &proto.WriteResponse{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// changeAccount changes a record file without updating the index.
+func changeAccount(t *testing.T, s *Service, key, mail string) {
+	rec := &proto.Record{Key: key, Metadata: map[string]*proto.Field{"mail": {Type: "string", Value: mail}}}
+	data, err := protojson.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(s.Config.Datapath, "databases", "proxy", "accounts", key)
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func findAccounts(t *testing.T, s *Service, mail string) []string {
+	where := map[string]*proto.Field{"mail": {Type: "string", Value: mail}}
+	rsp := &proto.ReadResponse{}
+	err := s.Read(context.Background(), &proto.ReadRequest{Options: &proto.ReadOptions{Database: "proxy", Table: "accounts", Where: where}}, rsp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(rsp.Records))
+	for _, rec := range rsp.Records {
+		keys = append(keys, rec.Key)
+	}
+	return keys
+}
+
+// restart closes a service and starts a new service on the same data path.
+func restart(t *testing.T, s *Service) *Service {
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	restarted, err := New(Logger(log.NewLogger()), Config(s.Config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	return restarted
+}
+
+// crash stops a service without closing it and starts a new service on the same data path.
+func crash(t *testing.T, s *Service) *Service {
+	s.index.Close()
+	s.backend.Close()
+	restarted, err := New(Logger(log.NewLogger()), Config(s.Config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	return restarted
+}
+
+// listingBackend counts how often the keys of a table are listed.
+type listingBackend struct {
+	storage.Backend
+	lists *int
+}
+
+func (b listingBackend) Keys(database, table string) ([]string, error) {
+	*b.lists++
+	return b.Backend.Keys(database, table)
+}
+
+func TestIndexIsKeptAcrossRestarts(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	// not visible to the index, the number of records doesn't change
+	changeAccount(t, s, "einstein", "albert@example.org")
+
+	s = restart(t, s)
+	assert.Equal(t, []string{"einstein"}, findAccounts(t, s, "einstein@example.org"))
+	assert.Empty(t, findAccounts(t, s, "albert@example.org"))
+
+	assert.NoError(t, s.Close())
+	n, err := Reindex(Logger(log.NewLogger()), Config(s.Config))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	s = restart(t, s)
+	assert.Empty(t, findAccounts(t, s, "einstein@example.org"))
+	assert.Equal(t, []string{"einstein"}, findAccounts(t, s, "albert@example.org"))
+}
+
+func TestJournalIsReplayed(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	writeAccount(t, s, "marie", "marie@example.org")
+
+	// crash after changing the files but before updating the index
+	assert.NoError(t, s.journal(storage.ID("proxy", "accounts", "einstein")))
+	changeAccount(t, s, "einstein", "albert@example.org")
+	assert.NoError(t, s.journal(storage.ID("proxy", "accounts", "marie")))
+	assert.NoError(t, os.Remove(filepath.Join(s.Config.Datapath, "databases", "proxy", "accounts", "marie")))
+
+	s = restart(t, s)
+	assert.Equal(t, []string{"einstein"}, findAccounts(t, s, "albert@example.org"))
+	assert.Empty(t, findAccounts(t, s, "marie@example.org"))
+
+	entries, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "journal"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRecordsAreOnlyCountedAfterACrash(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	assert.NoError(t, s.Close())
+
+	open := func() *Service {
+		backend, err := storage.NewDisk(s.Config.Datapath, log.NewLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		lists := 0
+		restarted, err := New(Logger(log.NewLogger()), Config(s.Config), Backend(listingBackend{Backend: backend, lists: &lists}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { restarted.Close() })
+		assert.Equal(t, 0, lists, "the records were counted after a clean shutdown")
+		return restarted
+	}
+	s = open()
+	assert.NoError(t, s.Close())
+	s = open()
+
+	// the count is only trusted once
+	_, err := ioutil.ReadFile(filepath.Join(s.Config.Datapath, indexRecordsFile))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIndexIsRebuiltOnDrift(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	// a record which was added without the store, eg. from a backup
+	changeAccount(t, s, "marie", "marie@example.org")
+
+	// the records are counted after a crash
+	s = crash(t, s)
+	assert.Equal(t, []string{"marie"}, findAccounts(t, s, "marie@example.org"))
+	assert.Equal(t, []string{"einstein"}, findAccounts(t, s, "einstein@example.org"))
+
+	// or if the backend was changed while the store was stopped
+	changeAccount(t, s, "feynman", "feynman@example.org")
+	assert.NoError(t, s.Close())
+	assert.NoError(t, CheckIndexOnNextStart(s.Config))
+	s = restart(t, s)
+	assert.Equal(t, []string{"feynman"}, findAccounts(t, s, "feynman@example.org"))
+}
+
+func TestWriteCommitsJournal(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	writeAccount(t, s, "einstein", "einstein@example.org")
+
+	entries, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "journal"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	entries, err = ioutil.ReadDir(filepath.Join(s.Config.Datapath, "tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/owncloud/ocis/store/pkg/storage"
+)
+
+// batchPrefix is the prefix of the journal entries of batches, the entries of single changes are named after the
+// hash of the record id.
+const batchPrefix = "batch-"
+
+// journal records that a record is about to change. Entries of changes which were interrupted before their index
+// document was updated are replayed on the next start, so a crash can't leave the index out of sync.
+func (s *Service) journal(id string) error {
+	return s.writeFile(s.journalEntry(id), []byte(id))
+}
+
+// commit removes the journal entry of a completed change.
+func (s *Service) commit(id string) {
+	if err := os.Remove(s.journalEntry(id)); err != nil && !os.IsNotExist(err) {
+		s.log.Error().Err(err).Str("id", id).Msg("could not remove journal entry")
+	}
+}
+
+func (s *Service) journalEntry(id string) string {
+	return filepath.Join(s.Config.Datapath, "journal", fmt.Sprintf("%x", sha256.Sum256([]byte(id))))
+}
+
+// batchEntry is the journal entry of a batch. It keeps the values of the changed keys before and after the batch, so
+// a batch which was interrupted or failed can be rolled back.
+type batchEntry struct {
+	Database string
+	Table    string
+	Changes  []batchChange
+}
+
+// batchChange is the final change of a key by a batch. Previous is the value before the batch, nil if the key didn't
+// exist.
+type batchChange struct {
+	storage.Change
+	Previous []byte
+}
+
+// journalBatch records a batch with the previous and the final values of its keys, before any of them is written. It
+// returns the journal entry.
+func (s *Service) journalBatch(e *batchEntry) (string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	entry := filepath.Join(s.Config.Datapath, "journal", fmt.Sprintf("%s%x", batchPrefix, sha256.Sum256(data)))
+	return entry, s.writeFile(entry, data)
+}
+
+// commitBatch removes the journal entry of a completed batch.
+func (s *Service) commitBatch(entry string) {
+	if err := os.Remove(entry); err != nil && !os.IsNotExist(err) {
+		s.log.Error().Err(err).Str("entry", entry).Msg("could not remove journal entry")
+	}
+}
+
+// applyBatch writes the changes of a batch to the backend, in one transaction if the backend supports transactions.
+func (s *Service) applyBatch(e *batchEntry) error {
+	changes := make([]storage.Change, len(e.Changes))
+	for i := range e.Changes {
+		changes[i] = e.Changes[i].Change
+	}
+	if t, ok := s.backend.(storage.Transactional); ok {
+		return t.Apply(e.Database, e.Table, changes)
+	}
+
+	for _, c := range changes {
+		if c.Value == nil {
+			if err := s.backend.Delete(e.Database, e.Table, c.Key); err != nil && !storage.IsNotFoundErr(err) {
+				return err
+			}
+			continue
+		}
+		if err := s.backend.Put(e.Database, e.Table, c.Key, c.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackBatch restores the previous values of the keys changed by a batch. Only keys which still have the value
+// written by the batch are restored, so a rollback never overwrites later changes.
+func (s *Service) rollbackBatch(e *batchEntry) error {
+	for _, c := range e.Changes {
+		current, err := s.backend.Get(e.Database, e.Table, c.Key)
+		if err != nil && !storage.IsNotFoundErr(err) {
+			return err
+		}
+		if !bytes.Equal(current, c.Value) {
+			continue
+		}
+		if c.Previous == nil {
+			err = s.backend.Delete(e.Database, e.Table, c.Key)
+		} else {
+			err = s.backend.Put(e.Database, e.Table, c.Key, c.Previous)
+		}
+		if err != nil && !storage.IsNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// abortBatch rolls back a batch which could not be completed and updates the index documents of its keys. The journal
+// entry is kept if that fails, so the batch is rolled back on the next start.
+func (s *Service) abortBatch(entry string, e *batchEntry) {
+	if err := s.rollbackBatch(e); err != nil {
+		s.log.Error().Err(err).Str("entry", entry).Msg("could not roll back batch")
+		return
+	}
+	for _, c := range e.Changes {
+		if err := s.reindex(storage.ID(e.Database, e.Table, c.Key)); err != nil {
+			s.log.Error().Err(err).Str("entry", entry).Msg("could not index rolled back batch")
+			return
+		}
+	}
+	s.commitBatch(entry)
+}
+
+// replayBatch rolls back a journaled batch and updates the index documents of its keys.
+func (s *Service) replayBatch(data []byte) error {
+	e := &batchEntry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return err
+	}
+	if err := s.rollbackBatch(e); err != nil {
+		return err
+	}
+	for _, c := range e.Changes {
+		if err := s.reindex(storage.ID(e.Database, e.Table, c.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackBatches rolls back the journaled batches without updating the index, before it is rebuilt.
+func (s *Service) rollbackBatches() error {
+	dir := filepath.Join(s.Config.Datapath, "journal")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), batchPrefix) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		e := &batchEntry{}
+		if err := json.Unmarshal(data, e); err != nil {
+			return err
+		}
+		if err := s.rollbackBatch(e); err != nil {
+			return err
+		}
+		s.log.Info().Str("entry", entry.Name()).Msg("rolled back journaled batch")
+	}
+	return nil
+}
+
+// replayJournal updates the index documents of all records with a pending journal entry. Pending batches are rolled
+// back, their clients never got a response.
+func (s *Service) replayJournal() error {
+	dir := filepath.Join(s.Config.Datapath, "journal")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, e := range entries {
+		entry := filepath.Join(dir, e.Name())
+		data, err := ioutil.ReadFile(entry)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(e.Name(), batchPrefix) {
+			err = s.replayBatch(data)
+		} else {
+			err = s.reindex(string(data))
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(entry); err != nil {
+			return err
+		}
+		s.log.Info().Str("entry", e.Name()).Msg("replayed journal entry")
+	}
+	return nil
+}
+
+// writeFile atomically replaces a file of the data path.
+func (s *Service) writeFile(file string, data []byte) error {
+	return storage.WriteFile(filepath.Join(s.Config.Datapath, "tmp"), file, data)
+}
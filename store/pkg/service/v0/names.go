@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/owncloud/ocis/store/pkg/storage"
+)
+
+const (
+	// maxNameLength is the maximum length of an encoded database, table or key name, most filesystems don't support
+	// longer file names.
+	maxNameLength = 255
+
+	// layoutVersion is the version of the file layout of the disk backend. Version 1 used the names as they are,
+	// version 2 encodes them with storage.EncodeName.
+	layoutVersion = 2
+)
+
+// validateName checks if a database, table or key name can be stored.
+func validateName(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s is required", kind)
+	}
+	if len(storage.EncodeName(name)) > maxNameLength {
+		return fmt.Errorf("%s is too long", kind)
+	}
+	return nil
+}
+
+// migrateLayout moves the records of a data path from an older layout to the current one. The records are copied to
+// a new databases directory, which replaces the old one once all records were copied. The index and journal are
+// removed, because the ids of the records changed.
+func (s *Service) migrateLayout() error {
+	versionFile := filepath.Join(s.Config.Datapath, "layout-version")
+	recordsDir := filepath.Join(s.Config.Datapath, "databases")
+	legacyDir := filepath.Join(s.Config.Datapath, "databases.legacy")
+	migratedDir := filepath.Join(s.Config.Datapath, "databases.migrated")
+
+	if layoutIsCurrent(s.Config.Datapath) {
+		return nil
+	}
+
+	// an earlier migration was interrupted after moving the old records
+	if _, err := os.Stat(legacyDir); err == nil {
+		if _, err := os.Stat(recordsDir); err == nil {
+			if err := os.RemoveAll(recordsDir); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(legacyDir, recordsDir); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(recordsDir); err == nil {
+		s.log.Info().Int("version", layoutVersion).Msg("migrating the records to a new layout")
+		if err := os.RemoveAll(migratedDir); err != nil {
+			return err
+		}
+		migrated, err := s.copyLegacyRecords(recordsDir, migratedDir)
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(recordsDir, legacyDir); err != nil {
+			return err
+		}
+		if err := os.Rename(migratedDir, recordsDir); err != nil {
+			return err
+		}
+		for _, dir := range []string{"index.bleve", "journal"} {
+			if err := os.RemoveAll(filepath.Join(s.Config.Datapath, dir)); err != nil {
+				return err
+			}
+		}
+		s.log.Info().Int("records", migrated).Msg("migrated the records to a new layout")
+	}
+
+	if err := s.writeFile(versionFile, []byte(strconv.Itoa(layoutVersion))); err != nil {
+		return err
+	}
+	return os.RemoveAll(legacyDir)
+}
+
+// layoutIsCurrent returns whether the records of a data path were migrated to the current layout.
+func layoutIsCurrent(datapath string) bool {
+	data, err := ioutil.ReadFile(filepath.Join(datapath, "layout-version"))
+	if err != nil {
+		return false
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return err == nil && v >= layoutVersion
+}
+
+// copyLegacyRecords copies the records stored as {database}/{table}/{key}, where the key may contain slashes, to
+// their encoded paths in dst.
+func (s *Service) copyLegacyRecords(src, dst string) (int, error) {
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return 0, err
+	}
+	copied := 0
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 3)
+		if len(parts) != 3 {
+			s.log.Warn().Str("path", p).Msg("skipping file which is not a record")
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dst, storage.ID(parts[0], parts[1], parts[2]))
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
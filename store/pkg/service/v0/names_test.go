@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestInvalidNamesAreRejected(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	tests := []struct {
+		name            string
+		database, table string
+		key             string
+	}{
+		{"empty database", "", "accounts", "einstein"},
+		{"empty table", "proxy", "", "einstein"},
+		{"empty key", "proxy", "accounts", ""},
+		{"too long key", "proxy", "accounts", strings.Repeat("/", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &proto.Record{Key: tt.key}
+			err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: tt.database, Table: tt.table}}, &proto.WriteResponse{})
+			assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+			err = s.Delete(context.Background(), &proto.DeleteRequest{Key: tt.key, Options: &proto.DeleteOptions{Database: tt.database, Table: tt.table}}, &proto.DeleteResponse{})
+			assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+		})
+	}
+}
+
+func TestKeysCantEscapeTheTable(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	keys := []string{"../../../escaped", "nested/key", ".."}
+	for _, key := range keys {
+		rec := &proto.Record{Key: key, Value: []byte(key)}
+		err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "proxy", Table: "accounts"}}, &proto.WriteResponse{})
+		assert.NoError(t, err)
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(s.Config.Datapath, "databases", "proxy", "accounts"))
+	assert.NoError(t, err)
+	assert.Len(t, files, len(keys))
+	for _, f := range files {
+		assert.False(t, f.IsDir())
+	}
+	_, err = os.Stat(filepath.Join(s.Config.Datapath, "escaped"))
+	assert.True(t, os.IsNotExist(err))
+
+	stream := &listStream{}
+	err = s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "accounts"}}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"..", "../../../escaped", "nested/key"}, stream.keys())
+
+	rsp := &proto.ReadResponse{}
+	err = s.Read(context.Background(), &proto.ReadRequest{Key: "nested/key", Options: &proto.ReadOptions{Database: "proxy", Table: "accounts"}}, rsp)
+	assert.NoError(t, err)
+	assert.Equal(t, "nested/key", string(rsp.Records[0].Value))
+}
+
+func TestMigrateLayout(t *testing.T) {
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// records of the first layout, where keys with slashes were nested directories
+	for _, key := range []string{"einstein@example.org", "nested/key"} {
+		rec := &proto.Record{Key: key, Metadata: map[string]*proto.Field{"kind": {Type: "string", Value: "account"}}}
+		data, err := protojson.Marshal(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(root, "databases", "proxy", "accounts", filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.New()
+	cfg.Datapath = root
+	s, err := New(Logger(log.NewLogger()), Config(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	stream := &listStream{}
+	err = s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "accounts"}}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"einstein@example.org", "nested/key"}, stream.keys())
+
+	where := map[string]*proto.Field{"kind": {Type: "string", Value: "account"}}
+	rsp := &proto.ReadResponse{}
+	err = s.Read(context.Background(), &proto.ReadRequest{Options: &proto.ReadOptions{Database: "proxy", Table: "accounts", Where: where}}, rsp)
+	assert.NoError(t, err)
+	assert.Len(t, rsp.Records, 2)
+
+	_, err = os.Stat(filepath.Join(root, "databases", "proxy", "accounts", "nested%2Fkey"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(root, "databases.legacy"))
+	assert.True(t, os.IsNotExist(err))
+	version, err := ioutil.ReadFile(filepath.Join(root, "layout-version"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(version))
+}
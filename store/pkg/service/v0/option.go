@@ -3,6 +3,8 @@ package service
 import (
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/metrics"
+	"github.com/owncloud/ocis/store/pkg/storage"
 )
 
 // Option defines a single option function.
@@ -10,8 +12,10 @@ type Option func(o *Options)
 
 // Options defines the available options for this package.
 type Options struct {
-	Logger log.Logger
-	Config *config.Config
+	Logger  log.Logger
+	Config  *config.Config
+	Metrics *metrics.Metrics
+	Backend storage.Backend
 
 	Database, Table string
 	Nodes           []string
@@ -61,3 +65,17 @@ func Config(val *config.Config) Option {
 		o.Config = val
 	}
 }
+
+// Metrics configures the Metrics option.
+func Metrics(val *metrics.Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = val
+	}
+}
+
+// Backend configures the Backend option, the backend of the config is used if it isn't set.
+func Backend(val storage.Backend) Option {
+	return func(o *Options) {
+		o.Backend = val
+	}
+}
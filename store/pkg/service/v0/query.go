@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+)
+
+// typedValue parses the value of a field according to its type. Numbers are indexed as float64, unknown types as
+// string.
+func typedValue(f *proto.Field) (interface{}, error) {
+	switch f.GetType() {
+	case "int", "int64", "float64":
+		v, err := strconv.ParseFloat(f.GetValue(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid %s", f.GetValue(), f.GetType())
+		}
+		return v, nil
+	case "bool":
+		v, err := strconv.ParseBool(f.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid bool", f.GetValue())
+		}
+		return v, nil
+	case "time":
+		v, err := time.Parse(time.RFC3339Nano, f.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid RFC 3339 time", f.GetValue())
+		}
+		return v, nil
+	default:
+		return f.GetValue(), nil
+	}
+}
+
+// typedValues returns the typed values of the metadata of a record. Fields with invalid values are left out, the
+// error of the first one is returned.
+func typedValues(metadata map[string]*proto.Field) (map[string]interface{}, error) {
+	var firstErr error
+	values := make(map[string]interface{}, len(metadata))
+	for k, f := range metadata {
+		v, err := typedValue(f)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("metadata %s: %v", k, err)
+			}
+			continue
+		}
+		values[k] = v
+	}
+	return values, firstErr
+}
+
+// buildQuery converts a metadata query to a bleve query.
+func buildQuery(q *proto.Query) (query.Query, error) {
+	queries := make([]query.Query, 0)
+
+	for _, sub := range q.GetAnd() {
+		bq, err := buildQuery(sub)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, bq)
+	}
+
+	if len(q.GetOr()) > 0 {
+		or := bleve.NewDisjunctionQuery()
+		for _, sub := range q.GetOr() {
+			bq, err := buildQuery(sub)
+			if err != nil {
+				return nil, err
+			}
+			or.AddQuery(bq)
+		}
+		queries = append(queries, or)
+	}
+
+	if q.GetNot() != nil {
+		bq, err := buildQuery(q.GetNot())
+		if err != nil {
+			return nil, err
+		}
+		not := bleve.NewBooleanQuery()
+		not.AddMustNot(bq)
+		queries = append(queries, not)
+	}
+
+	if q.GetEquals() != nil || q.GetPrefix() != "" || q.GetRange() != nil {
+		if q.GetField() == "" {
+			return nil, fmt.Errorf("field is required")
+		}
+		fq, err := buildFieldQuery(q)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, fq...)
+	}
+
+	if len(queries) == 0 {
+		return bleve.NewMatchAllQuery(), nil
+	}
+	return bleve.NewConjunctionQuery(queries...), nil
+}
+
+// buildFieldQuery converts the conditions of a query on its field.
+func buildFieldQuery(q *proto.Query) ([]query.Query, error) {
+	field := "values." + q.GetField()
+	queries := make([]query.Query, 0, 3)
+
+	if q.GetEquals() != nil {
+		v, err := typedValue(q.GetEquals())
+		if err != nil {
+			return nil, err
+		}
+		inclusive := true
+		var fq query.FieldableQuery
+		switch v := v.(type) {
+		case float64:
+			fq = bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+		case bool:
+			fq = bleve.NewBoolFieldQuery(v)
+		case time.Time:
+			fq = bleve.NewDateRangeInclusiveQuery(v, v, &inclusive, &inclusive)
+		case string:
+			fq = bleve.NewTermQuery(v)
+		}
+		fq.SetField(field)
+		queries = append(queries, fq)
+	}
+
+	if q.GetPrefix() != "" {
+		pq := bleve.NewPrefixQuery(q.GetPrefix())
+		pq.SetField(field)
+		queries = append(queries, pq)
+	}
+
+	if q.GetRange() != nil {
+		rq, err := buildRangeQuery(q.GetRange())
+		if err != nil {
+			return nil, err
+		}
+		rq.SetField(field)
+		queries = append(queries, rq)
+	}
+	return queries, nil
+}
+
+// buildRangeQuery converts a range to a numeric, date or term range query, depending on the type of its bounds.
+func buildRangeQuery(r *proto.Range) (query.FieldableQuery, error) {
+	var min, max interface{}
+	var err error
+	if r.GetMin() != nil {
+		if min, err = typedValue(r.GetMin()); err != nil {
+			return nil, err
+		}
+	}
+	if r.GetMax() != nil {
+		if max, err = typedValue(r.GetMax()); err != nil {
+			return nil, err
+		}
+	}
+	if min == nil && max == nil {
+		return nil, fmt.Errorf("range requires min or max")
+	}
+	if min != nil && max != nil && fmt.Sprintf("%T", min) != fmt.Sprintf("%T", max) {
+		return nil, fmt.Errorf("min and max of a range must have the same type")
+	}
+	minInclusive, maxInclusive := !r.GetExcludeMin(), !r.GetExcludeMax()
+
+	bound := min
+	if bound == nil {
+		bound = max
+	}
+	switch bound.(type) {
+	case float64:
+		var minF, maxF *float64
+		if min != nil {
+			v := min.(float64)
+			minF = &v
+		}
+		if max != nil {
+			v := max.(float64)
+			maxF = &v
+		}
+		return bleve.NewNumericRangeInclusiveQuery(minF, maxF, &minInclusive, &maxInclusive), nil
+	case time.Time:
+		var start, end time.Time
+		if min != nil {
+			start = min.(time.Time)
+		}
+		if max != nil {
+			end = max.(time.Time)
+		}
+		return bleve.NewDateRangeInclusiveQuery(start, end, &minInclusive, &maxInclusive), nil
+	case string:
+		var minS, maxS string
+		if min != nil {
+			minS = min.(string)
+		}
+		if max != nil {
+			maxS = max.(string)
+		}
+		return bleve.NewTermRangeInclusiveQuery(minS, maxS, &minInclusive, &maxInclusive), nil
+	default:
+		return nil, fmt.Errorf("range of %T is not supported", bound)
+	}
+}
+
+// sortOrder converts the sort options to the sort order of a bleve search request. Records are sorted by relevance
+// and their id if no order is given, so pages of results are stable.
+func sortOrder(sorts []*proto.Sort) []string {
+	order := make([]string, 0, len(sorts)+1)
+	if len(sorts) == 0 {
+		order = append(order, "-_score")
+	}
+	for _, s := range sorts {
+		field := "values." + s.GetField()
+		if s.GetDescending() {
+			field = "-" + field
+		}
+		order = append(order, field)
+	}
+	return append(order, "_id")
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSessions(t *testing.T) (*Service, func()) {
+	s, cleanup := newTestService(t, nil)
+
+	sessions := []struct {
+		key, user, created, logins, admin, day string
+	}{
+		{"s1", "einstein", "2021-01-01T10:00:00Z", "3", "false", "2021-01-01"},
+		{"s2", "marie", "2021-01-02T10:00:00Z", "10", "true", "2021-01-02"},
+		{"s3", "moss", "2021-01-03T10:00:00Z", "1", "true", "2021-01-03"},
+		{"s4", "richard", "2021-01-04T10:00:00Z", "7", "false", "2021-01-04"},
+	}
+	for _, session := range sessions {
+		rec := &proto.Record{Key: session.key, Metadata: map[string]*proto.Field{
+			"user":    {Type: "string", Value: session.user},
+			"created": {Type: "time", Value: session.created},
+			"logins":  {Type: "int", Value: session.logins},
+			"admin":   {Type: "bool", Value: session.admin},
+			"day":     {Type: "string", Value: session.day},
+		}}
+		opts := &proto.WriteOptions{Database: "proxy", Table: "sessions"}
+		if err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: opts}, &proto.WriteResponse{}); err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+	}
+	return s, cleanup
+}
+
+func querySessions(s *Service, opts *proto.ReadOptions) ([]string, error) {
+	opts.Database = "proxy"
+	opts.Table = "sessions"
+	rsp := &proto.ReadResponse{}
+	if err := s.Read(context.Background(), &proto.ReadRequest{Options: opts}, rsp); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(rsp.Records))
+	for _, rec := range rsp.Records {
+		keys = append(keys, rec.Key)
+	}
+	return keys, nil
+}
+
+func TestQuery(t *testing.T) {
+	s, cleanup := newTestSessions(t)
+	defer cleanup()
+
+	byKey := []*proto.Sort{{Field: "user"}}
+	tests := []struct {
+		name  string
+		query *proto.Query
+		keys  []string
+	}{
+		{"all", &proto.Query{}, []string{"s1", "s2", "s3", "s4"}},
+		{"equal string", &proto.Query{Field: "user", Equals: &proto.Field{Value: "marie"}}, []string{"s2"}},
+		{"equal string looking like a date", &proto.Query{Field: "day", Equals: &proto.Field{Value: "2021-01-03"}}, []string{"s3"}},
+		{"equal int", &proto.Query{Field: "logins", Equals: &proto.Field{Type: "int", Value: "7"}}, []string{"s4"}},
+		{"equal bool", &proto.Query{Field: "admin", Equals: &proto.Field{Type: "bool", Value: "true"}}, []string{"s2", "s3"}},
+		{"equal time", &proto.Query{Field: "created", Equals: &proto.Field{Type: "time", Value: "2021-01-01T11:00:00+01:00"}}, []string{"s1"}},
+		{"prefix", &proto.Query{Field: "user", Prefix: "m"}, []string{"s2", "s3"}},
+		{"numeric range", &proto.Query{Field: "logins", Range: &proto.Range{
+			Min: &proto.Field{Type: "int", Value: "3"},
+			Max: &proto.Field{Type: "int", Value: "10"},
+		}}, []string{"s1", "s2", "s4"}},
+		{"exclusive numeric range", &proto.Query{Field: "logins", Range: &proto.Range{
+			Min:        &proto.Field{Type: "int", Value: "3"},
+			Max:        &proto.Field{Type: "int", Value: "10"},
+			ExcludeMin: true,
+			ExcludeMax: true,
+		}}, []string{"s4"}},
+		{"open numeric range", &proto.Query{Field: "logins", Range: &proto.Range{
+			Min: &proto.Field{Type: "float64", Value: "5"},
+		}}, []string{"s2", "s4"}},
+		{"time range", &proto.Query{Field: "created", Range: &proto.Range{
+			Min:        &proto.Field{Type: "time", Value: "2021-01-02T10:00:00Z"},
+			ExcludeMin: true,
+		}}, []string{"s3", "s4"}},
+		{"string range", &proto.Query{Field: "user", Range: &proto.Range{
+			Min: &proto.Field{Value: "f"},
+			Max: &proto.Field{Value: "n"},
+		}}, []string{"s2", "s3"}},
+		{"or", &proto.Query{Or: []*proto.Query{
+			{Field: "user", Equals: &proto.Field{Value: "einstein"}},
+			{Field: "logins", Equals: &proto.Field{Type: "int", Value: "1"}},
+		}}, []string{"s1", "s3"}},
+		{"not", &proto.Query{Not: &proto.Query{Field: "admin", Equals: &proto.Field{Type: "bool", Value: "true"}}}, []string{"s1", "s4"}},
+		{"and", &proto.Query{And: []*proto.Query{
+			{Field: "admin", Equals: &proto.Field{Type: "bool", Value: "true"}},
+			{Field: "logins", Range: &proto.Range{Min: &proto.Field{Type: "int", Value: "5"}}},
+		}}, []string{"s2"}},
+		{"parts are combined", &proto.Query{
+			Field:  "user",
+			Prefix: "m",
+			Not:    &proto.Query{Field: "logins", Equals: &proto.Field{Type: "int", Value: "1"}},
+		}, []string{"s2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := querySessions(s, &proto.ReadOptions{Query: tt.query, Sort: byKey})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
+
+func TestQueryWithWhere(t *testing.T) {
+	s, cleanup := newTestSessions(t)
+	defer cleanup()
+
+	keys, err := querySessions(s, &proto.ReadOptions{
+		Where: map[string]*proto.Field{"admin": {Value: "true"}},
+		Query: &proto.Query{Field: "user", Prefix: "mo"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"s3"}, keys)
+}
+
+func TestQuerySort(t *testing.T) {
+	s, cleanup := newTestSessions(t)
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		opts *proto.ReadOptions
+		keys []string
+	}{
+		{"numeric", &proto.ReadOptions{Sort: []*proto.Sort{{Field: "logins"}}}, []string{"s3", "s1", "s4", "s2"}},
+		{"descending", &proto.ReadOptions{Sort: []*proto.Sort{{Field: "logins", Descending: true}}}, []string{"s2", "s4", "s1", "s3"}},
+		{"time", &proto.ReadOptions{Sort: []*proto.Sort{{Field: "created", Descending: true}}}, []string{"s4", "s3", "s2", "s1"}},
+		{"multiple fields", &proto.ReadOptions{Sort: []*proto.Sort{{Field: "admin"}, {Field: "user", Descending: true}}}, []string{"s4", "s1", "s3", "s2"}},
+		{"limit and offset", &proto.ReadOptions{Sort: []*proto.Sort{{Field: "logins"}}, Limit: 2, Offset: 1}, []string{"s1", "s4"}},
+		{"no order", &proto.ReadOptions{Query: &proto.Query{}}, []string{"s1", "s2", "s3", "s4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := querySessions(s, tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
+
+func TestInvalidQueries(t *testing.T) {
+	s, cleanup := newTestSessions(t)
+	defer cleanup()
+
+	queries := map[string]*proto.Query{
+		"missing field":  {Prefix: "m"},
+		"invalid int":    {Field: "logins", Equals: &proto.Field{Type: "int", Value: "many"}},
+		"empty range":    {Field: "logins", Range: &proto.Range{}},
+		"mixed range":    {Field: "logins", Range: &proto.Range{Min: &proto.Field{Type: "int", Value: "1"}, Max: &proto.Field{Value: "9"}}},
+		"invalid nested": {Or: []*proto.Query{{Field: "created", Equals: &proto.Field{Type: "time", Value: "yesterday"}}}},
+	}
+	for name, q := range queries {
+		t.Run(name, func(t *testing.T) {
+			_, err := querySessions(s, &proto.ReadOptions{Query: q})
+			assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+		})
+	}
+
+	rec := &proto.Record{Key: "s5", Metadata: map[string]*proto.Field{"logins": {Type: "int", Value: "many"}}}
+	err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "proxy", Table: "sessions"}}, &proto.WriteResponse{})
+	assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+}
@@ -2,25 +2,34 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve"
-	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
 	merrors "github.com/micro/go-micro/v2/errors"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/encryption"
+	"github.com/owncloud/ocis/store/pkg/metrics"
 	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // BleveDocument wraps the generated Record.Metadata and adds a property that is used to distinguish documents in the index.
 type BleveDocument struct {
 	Metadata map[string]*proto.Field `json:"metadata"`
-	Database string                  `json:"database"`
-	Table    string                  `json:"table"`
+	// Values are the metadata values parsed according to their type, so they can be compared as numbers, dates or
+	// booleans.
+	Values   map[string]interface{} `json:"values"`
+	Database string                 `json:"database"`
+	Table    string                 `json:"table"`
+	// ExpiresAt is the unix time the record expires at, it is omitted for records which don't expire. Expired records
+	// are found with it without reading them.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // New returns a new instance of Service
@@ -29,68 +38,85 @@ func New(opts ...Option) (s *Service, err error) {
 	logger := options.Logger
 	cfg := options.Config
 
-	recordsDir := filepath.Join(cfg.Datapath, "databases")
-	{
-		var fi os.FileInfo
-		if fi, err = os.Stat(recordsDir); err != nil {
-			if os.IsNotExist(err) {
-				// create store directory
-				if err = os.MkdirAll(recordsDir, 0700); err != nil {
-					return nil, err
-				}
-			}
-		} else if !fi.IsDir() {
-			return nil, fmt.Errorf("%s is not a directory", recordsDir)
-		}
-	}
-
-	indexMapping := bleve.NewIndexMapping()
-	// keep all symbols in terms to allow exact matching, eg. emails
-	indexMapping.DefaultAnalyzer = keyword.Name
-
 	s = &Service{
-		id:     cfg.Service.Namespace + "." + cfg.Service.Name,
-		log:    logger,
-		Config: cfg,
+		id:      cfg.Service.Namespace + "." + cfg.Service.Name,
+		log:     logger,
+		Config:  cfg,
+		metrics: options.Metrics,
 	}
 
-	indexDir := filepath.Join(cfg.Datapath, "index.bleve")
-	// for now recreate index on every start
-	if err = os.RemoveAll(indexDir); err != nil {
+	if s.keyring, err = encryption.Load(cfg.Encryption); err != nil {
 		return nil, err
 	}
-	if s.index, err = bleve.New(indexDir, indexMapping); err != nil {
-		return
+	if err = s.openBackend(options.Backend); err != nil {
+		return nil, err
 	}
-	if err = s.indexRecords(recordsDir); err != nil {
+	if err = s.openIndex(); err != nil {
+		s.backend.Close()
 		return nil, err
 	}
 	return
 }
 
+// openBackend uses the given backend or opens the one of the config. Records of an older disk layout are migrated
+// first.
+func (s *Service) openBackend(backend storage.Backend) (err error) {
+	if backend != nil {
+		s.backend = backend
+		return nil
+	}
+	if s.Config.Backend.Type == "" || s.Config.Backend.Type == "disk" {
+		if err = s.migrateLayout(); err != nil {
+			return err
+		}
+	}
+	s.backend, err = storage.New(s.Config, s.log)
+	return err
+}
+
+// Close closes the index and the backend. No requests must be served afterwards.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.closeIndex()
+	if berr := s.backend.Close(); err == nil {
+		err = berr
+	}
+	return err
+}
+
 // Service implements the AccountsServiceHandler interface
 type Service struct {
-	id     string
-	log    log.Logger
-	Config *config.Config
-	index  bleve.Index
+	id      string
+	log     log.Logger
+	Config  *config.Config
+	index   bleve.Index
+	backend storage.Backend
+	metrics *metrics.Metrics
+	// keyring encrypts the record values, it is nil if encryption is not configured
+	keyring *encryption.Keyring
+	// mu serializes the changes of records, so the sweeper doesn't delete a record which was just rewritten. Readers
+	// hold it shared, so they don't see a partly applied batch.
+	mu sync.RWMutex
+
+	watchersMu sync.Mutex
+	watchers   map[*watcher]struct{}
 }
 
-// Read implements the StoreHandler interface.
+// Read implements the StoreHandler interface. Without an exact key the records of the table are read in the order of
+// their keys, the key is used as prefix or suffix if the options say so.
 func (s *Service) Read(c context.Context, rreq *proto.ReadRequest, rres *proto.ReadResponse) error {
-	if len(rreq.Key) != 0 {
-		id := getID(rreq.Options.Database, rreq.Options.Table, rreq.Key)
-		file := filepath.Join(s.Config.Datapath, "databases", id)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-		var data []byte
-		rec := &proto.Record{}
-		data, err := ioutil.ReadFile(file)
-		if err != nil {
-			return merrors.NotFound(s.id, "could not read record")
+	opts := rreq.GetOptions()
+	if len(rreq.Key) != 0 && !opts.GetPrefix() && !opts.GetSuffix() {
+		if err := s.validateKey(opts.GetDatabase(), opts.GetTable(), rreq.Key); err != nil {
+			return err
 		}
-
-		if err = protojson.Unmarshal(data, rec); err != nil {
-			return merrors.InternalServerError(s.id, "could not unmarshal record")
+		rec, err := s.readRecord(storage.ID(opts.GetDatabase(), opts.GetTable(), rreq.Key))
+		if err != nil {
+			return err
 		}
 
 		rres.Records = append(rres.Records, rec)
@@ -98,23 +124,37 @@ func (s *Service) Read(c context.Context, rreq *proto.ReadRequest, rres *proto.R
 	}
 
 	s.log.Info().Interface("request", rreq).Msg("read request")
-	if rreq.Options.Where != nil {
+	if err := s.validateTable(opts.GetDatabase(), opts.GetTable()); err != nil {
+		return err
+	}
+	if opts.GetWhere() != nil || opts.GetQuery() != nil || len(opts.GetSort()) > 0 {
 		// build bleve query
 		// execute search
 		// fetch the actual record if there's a hit
-		dtq := bleve.NewTermQuery(rreq.Options.Database)
-		ttq := bleve.NewTermQuery(rreq.Options.Table)
-		dtq.SetField("database")
-		ttq.SetField("table")
-
-		query := bleve.NewConjunctionQuery(dtq, ttq)
-		for k, v := range rreq.Options.Where {
+		query := bleve.NewConjunctionQuery(tableQuery(opts.Database, opts.Table))
+		for k, v := range opts.Where {
 			ntq := bleve.NewTermQuery(v.Value)
 			ntq.SetField("metadata." + k + ".value")
 			query.AddQuery(ntq)
 		}
+		if opts.GetQuery() != nil {
+			mq, err := buildQuery(opts.GetQuery())
+			if err != nil {
+				return merrors.BadRequest(s.id, "invalid query: %v", err)
+			}
+			query.AddQuery(mq)
+		}
 
-		searchRequest := bleve.NewSearchRequest(query)
+		size := int(opts.GetLimit())
+		if size == 0 {
+			count, err := s.index.DocCount()
+			if err != nil {
+				return merrors.InternalServerError(s.id, "could not count documents")
+			}
+			size = int(count)
+		}
+		searchRequest := bleve.NewSearchRequestOptions(query, size, int(opts.GetOffset()), false)
+		searchRequest.SortBy(sortOrder(opts.GetSort()))
 		var searchResult *bleve.SearchResult
 		searchResult, err := s.index.Search(searchRequest)
 		if err != nil {
@@ -123,20 +163,14 @@ func (s *Service) Read(c context.Context, rreq *proto.ReadRequest, rres *proto.R
 		}
 
 		for _, hit := range searchResult.Hits {
-			rec := &proto.Record{}
-
-			dest := filepath.Join(s.Config.Datapath, "databases", hit.ID)
-
-			var data []byte
-			data, err := ioutil.ReadFile(dest)
-			s.log.Info().Str("path", dest).Interface("hit", hit).Msgf("hit info")
+			s.log.Info().Str("id", hit.ID).Interface("hit", hit).Msgf("hit info")
+			rec, err := s.readRecord(hit.ID)
 			if err != nil {
-				s.log.Info().Str("path", dest).Interface("hit", hit).Msgf("file not found")
-				return merrors.NotFound(s.id, "could not read record")
-			}
-
-			if err = protojson.Unmarshal(data, rec); err != nil {
-				return merrors.InternalServerError(s.id, "could not unmarshal record")
+				if isNotFound(err) {
+					// deleted or expired since it was indexed
+					continue
+				}
+				return err
 			}
 
 			rres.Records = append(rres.Records, rec)
@@ -144,51 +178,126 @@ func (s *Service) Read(c context.Context, rreq *proto.ReadRequest, rres *proto.R
 		return nil
 	}
 
-	return merrors.InternalServerError(s.id, "neither id nor metadata present")
+	var prefix, suffix string
+	if opts.GetPrefix() {
+		prefix = rreq.Key
+	}
+	if opts.GetSuffix() {
+		suffix = rreq.Key
+	}
+	keys, err := s.listKeys(opts.GetDatabase(), opts.GetTable(), prefix, suffix, opts.GetLimit(), opts.GetOffset())
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		rec, err := s.readRecord(storage.ID(opts.Database, opts.Table, key))
+		if err != nil {
+			if isNotFound(err) {
+				// deleted or expired since it was listed
+				continue
+			}
+			return err
+		}
+
+		rres.Records = append(rres.Records, rec)
+	}
+	return nil
 }
 
-// Write implements the StoreHandler interface.
+// Write implements the StoreHandler interface. Every write increases the version of the record, a write whose
+// conditions don't hold fails with a conflict.
 func (s *Service) Write(c context.Context, wreq *proto.WriteRequest, wres *proto.WriteResponse) error {
-	id := getID(wreq.Options.Database, wreq.Options.Table, wreq.Record.Key)
-	file := filepath.Join(s.Config.Datapath, "databases", id)
+	if err := s.validateKey(wreq.GetOptions().GetDatabase(), wreq.GetOptions().GetTable(), wreq.GetRecord().GetKey()); err != nil {
+		return err
+	}
+	id := storage.ID(wreq.Options.Database, wreq.Options.Table, wreq.Record.Key)
 
-	var bytes []byte
-	bytes, err := protojson.Marshal(wreq.Record)
+	wreq.Record.ExpiresAt = expiresAt(wreq.Record, wreq.Options, time.Now())
+	wreq.Record.Expiry = 0
+
+	values, err := typedValues(wreq.Record.Metadata)
 	if err != nil {
-		return merrors.InternalServerError(s.id, "could not marshal record")
+		return merrors.BadRequest(s.id, "%v", err)
 	}
 
-	err = os.MkdirAll(filepath.Dir(file), 0700)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, exists, err := s.currentVersion(id)
 	if err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not read record version")
+		return merrors.InternalServerError(s.id, "could not write record")
+	}
+	if err := s.checkConditions(wreq.Options, version, exists); err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(file, bytes, 0600)
+	wreq.Record.Version = version + 1
+
+	stored, err := s.sealRecord(id, wreq.Record)
+	if err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not encrypt record")
+		return merrors.InternalServerError(s.id, "could not write record")
+	}
+	var bytes []byte
+	bytes, err = protojson.Marshal(stored)
 	if err != nil {
+		return merrors.InternalServerError(s.id, "could not marshal record")
+	}
+
+	if err := s.journal(id); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not journal write")
+		return merrors.InternalServerError(s.id, "could not write record")
+	}
+	if err := s.backend.Put(wreq.Options.Database, wreq.Options.Table, wreq.Record.Key, bytes); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not write record")
 		return merrors.InternalServerError(s.id, "could not write record")
 	}
 
 	doc := BleveDocument{
-		Metadata: wreq.Record.Metadata,
-		Database: wreq.Options.Database,
-		Table:    wreq.Options.Table,
+		Metadata:  wreq.Record.Metadata,
+		Values:    values,
+		Database:  wreq.Options.Database,
+		Table:     wreq.Options.Table,
+		ExpiresAt: wreq.Record.ExpiresAt,
 	}
 	if err := s.index.Index(id, doc); err != nil {
 		s.log.Error().Err(err).Interface("document", doc).Msg("could not index record metadata")
 		return err
 	}
 
+	s.commit(id)
+	s.publish(&proto.WatchResponse{
+		Type:     proto.WatchResponse_TYPE_WRITE,
+		Database: wreq.Options.Database,
+		Table:    wreq.Options.Table,
+		Key:      wreq.Record.Key,
+		Record:   wreq.Record,
+	})
+	wres.Version = wreq.Record.Version
 	return nil
 }
 
 // Delete implements the StoreHandler interface.
 func (s *Service) Delete(c context.Context, dreq *proto.DeleteRequest, dres *proto.DeleteResponse) error {
-	id := getID(dreq.Options.Database, dreq.Options.Table, dreq.Key)
-	file := filepath.Join(s.Config.Datapath, "databases", id)
-	if err := os.Remove(file); err != nil {
-		if os.IsNotExist(err) {
+	if err := s.validateKey(dreq.GetOptions().GetDatabase(), dreq.GetOptions().GetTable(), dreq.Key); err != nil {
+		return err
+	}
+	id := storage.ID(dreq.Options.Database, dreq.Options.Table, dreq.Key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.journal(id); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not journal delete")
+		return merrors.InternalServerError(s.id, "could not delete record")
+	}
+	if err := s.backend.Delete(dreq.Options.Database, dreq.Options.Table, dreq.Key); err != nil {
+		if storage.IsNotFoundErr(err) {
+			s.commit(id)
 			return merrors.NotFound(s.id, "could not find record")
 		}
 
+		s.log.Error().Err(err).Str("id", id).Msg("could not delete record")
 		return merrors.InternalServerError(s.id, "could not delete record")
 	}
 
@@ -197,136 +306,239 @@ func (s *Service) Delete(c context.Context, dreq *proto.DeleteRequest, dres *pro
 		return merrors.InternalServerError(s.id, "could not remove record from index")
 	}
 
+	s.commit(id)
+	s.publish(&proto.WatchResponse{
+		Type:     proto.WatchResponse_TYPE_DELETE,
+		Database: dreq.Options.Database,
+		Table:    dreq.Options.Table,
+		Key:      dreq.Key,
+	})
 	return nil
 }
 
-// List implements the StoreHandler interface.
-func (s *Service) List(context.Context, *proto.ListRequest, proto.Store_ListStream) error {
+// List implements the StoreHandler interface. The keys are sent in lexical order, in pages of at most listPageSize
+// keys.
+func (s *Service) List(c context.Context, lreq *proto.ListRequest, stream proto.Store_ListStream) error {
+	opts := lreq.GetOptions()
+	s.mu.RLock()
+	keys, err := s.listKeys(opts.GetDatabase(), opts.GetTable(), opts.GetPrefix(), opts.GetSuffix(), opts.GetLimit(), opts.GetOffset())
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for len(keys) > 0 {
+		n := listPageSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+		if err := stream.Send(&proto.ListResponse{Keys: keys[:n]}); err != nil {
+			s.log.Error().Err(err).Msg("could not send keys")
+			return merrors.InternalServerError(s.id, "could not send keys")
+		}
+		keys = keys[n:]
+	}
 	return nil
 }
 
 // Databases implements the StoreHandler interface.
 func (s *Service) Databases(c context.Context, dbreq *proto.DatabasesRequest, dbres *proto.DatabasesResponse) error {
-	file := filepath.Join(s.Config.Datapath, "databases")
-	f, err := os.Open(file)
+	databases, err := s.backend.Databases()
 	if err != nil {
-		return merrors.InternalServerError(s.id, "could not open database directory")
+		s.log.Error().Err(err).Msg("could not list databases")
+		return merrors.InternalServerError(s.id, "could not list databases")
 	}
-	defer f.Close()
 
-	dnames, err := f.Readdirnames(0)
-	if err != nil {
-		return merrors.InternalServerError(s.id, "could not read database directory")
-	}
-
-	dbres.Databases = dnames
+	dbres.Databases = databases
 	return nil
 }
 
 // Tables implements the StoreHandler interface.
 func (s *Service) Tables(ctx context.Context, in *proto.TablesRequest, out *proto.TablesResponse) error {
-	file := filepath.Join(s.Config.Datapath, "databases", in.Database)
-	f, err := os.Open(file)
-	if err != nil {
-		return merrors.InternalServerError(s.id, "could not open tables directory")
+	if err := validateName("database", in.Database); err != nil {
+		return merrors.BadRequest(s.id, "%v", err)
 	}
-	defer f.Close()
-
-	tnames, err := f.Readdirnames(0)
+	tables, err := s.backend.Tables(in.Database)
 	if err != nil {
-		return merrors.InternalServerError(s.id, "could not read tables directory")
+		s.log.Error().Err(err).Str("database", in.Database).Msg("could not list tables")
+		return merrors.InternalServerError(s.id, "could not list tables")
 	}
 
-	out.Tables = tnames
+	out.Tables = tables
 	return nil
 }
 
-// TODO sanitize key. As it may contain invalid characters, such as slashes.
-// file: /var/tmp/ocis/store/databases/{database}/{table}/{record.key}.
-func getID(database string, table string, key string) string {
-	// TODO sanitize input.
-	return filepath.Join(database, table, key)
+// validateTable returns a BadRequest error if the name of the database or table can't be stored.
+func (s *Service) validateTable(database, table string) error {
+	if err := validateName("database", database); err != nil {
+		return merrors.BadRequest(s.id, "%v", err)
+	}
+	if err := validateName("table", table); err != nil {
+		return merrors.BadRequest(s.id, "%v", err)
+	}
+	return nil
+}
+
+// validateKey returns a BadRequest error if the name of the database, table or key can't be stored.
+func (s *Service) validateKey(database, table, key string) error {
+	if err := s.validateTable(database, table); err != nil {
+		return err
+	}
+	if err := validateName("key", key); err != nil {
+		return merrors.BadRequest(s.id, "%v", err)
+	}
+	return nil
 }
 
-func (s Service) indexRecords(recordsDir string) (err error) {
+// listPageSize is the maximum number of keys sent with one ListResponse.
+const listPageSize = 100
 
-	// TODO use filepath.Walk to clean up code
-	rh, err := os.Open(recordsDir)
+// listKeys returns the sorted keys of a table which match the prefix and suffix and are not expired. The first offset
+// keys are skipped, a limit of 0 returns all remaining keys.
+func (s *Service) listKeys(database, table, prefix, suffix string, limit, offset uint64) ([]string, error) {
+	if err := s.validateTable(database, table); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.backend.Keys(database, table)
 	if err != nil {
-		return merrors.InternalServerError(s.id, "could not open database directory")
+		s.log.Error().Err(err).Str("database", database).Str("table", table).Msg("could not list keys")
+		return nil, merrors.InternalServerError(s.id, "could not list keys")
 	}
-	defer rh.Close()
 
-	dbs, err := rh.Readdirnames(0)
+	expired, err := s.expiredIDs(time.Now(), tableQuery(database, table))
 	if err != nil {
-		return merrors.InternalServerError(s.id, "could not read databases directory")
+		s.log.Error().Err(err).Str("database", database).Str("table", table).Msg("could not search expired records")
+		return nil, merrors.InternalServerError(s.id, "could not list keys")
 	}
 
-	for i := range dbs {
-		tp := filepath.Join(s.Config.Datapath, "databases", dbs[i])
-		th, err := os.Open(tp)
-		if err != nil {
-			s.log.Error().Err(err).Str("database", dbs[i]).Msg("could not open database directory")
+	page := make([]string, 0)
+	for _, key := range keys {
+		if limit > 0 && uint64(len(page)) == limit {
+			break
+		}
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, suffix) {
 			continue
 		}
-		defer th.Close()
-
-		tables, err := th.Readdirnames(0)
-		if err != nil {
-			s.log.Error().Err(err).Str("database", dbs[i]).Msg("could not read database directory")
+		if _, ok := expired[storage.ID(database, table, key)]; ok {
+			continue
+		}
+		if offset > 0 {
+			offset--
 			continue
 		}
+		page = append(page, key)
+	}
+	return page, nil
+}
 
-		for j := range tables {
+// readRecord reads the record with the given id from the backend. Records which expired but were not swept yet are not
+// found, the expiry of other records is set to the remaining seconds until they expire.
+func (s *Service) readRecord(id string) (*proto.Record, error) {
+	data, err := s.get(id)
+	if err != nil {
+		s.log.Debug().Err(err).Str("id", id).Msg("could not read record")
+		if storage.IsNotFoundErr(err) {
+			return nil, merrors.NotFound(s.id, "could not read record")
+		}
+		return nil, merrors.InternalServerError(s.id, "could not read record")
+	}
 
-			tp := filepath.Join(s.Config.Datapath, "databases", dbs[i], tables[j])
-			kh, err := os.Open(tp)
-			if err != nil {
-				s.log.Error().Err(err).Str("database", dbs[i]).Str("table", tables[i]).Msg("could not open table directory")
-				continue
-			}
-			defer kh.Close()
+	rec := &proto.Record{}
+	if err = protojson.Unmarshal(data, rec); err != nil {
+		return nil, merrors.InternalServerError(s.id, "could not unmarshal record")
+	}
+	if err = s.openRecord(id, rec); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not decrypt record")
+		return nil, merrors.InternalServerError(s.id, "could not decrypt record")
+	}
 
-			keys, err := kh.Readdirnames(0)
-			if err != nil {
-				s.log.Error().Err(err).Str("database", dbs[i]).Str("table", tables[i]).Msg("could not read table directory")
-				continue
-			}
+	if rec.Version == 0 {
+		rec.Version = legacyVersion
+	}
 
-			for k := range keys {
+	if rec.ExpiresAt > 0 {
+		remaining := time.Until(time.Unix(rec.ExpiresAt, 0))
+		if remaining <= 0 {
+			if s.metrics != nil {
+				s.metrics.Expired.WithLabelValues().Inc()
+			}
+			return nil, merrors.NotFound(s.id, "record expired")
+		}
+		rec.Expiry = int64(math.Ceil(remaining.Seconds()))
+	}
+	return rec, nil
+}
 
-				id := getID(dbs[i], tables[j], keys[k])
-				kp := filepath.Join(s.Config.Datapath, "databases", id)
+// get reads the value of the record with the given id from the backend.
+func (s *Service) get(id string) ([]byte, error) {
+	database, table, key, err := storage.ParseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.Get(database, table, key)
+}
 
-				// read record
-				var data []byte
-				rec := &proto.Record{}
-				data, err = ioutil.ReadFile(kp)
-				if err != nil {
-					s.log.Error().Err(err).Str("id", id).Msg("could not read record")
-					continue
-				}
+// legacyVersion is the version of records written before records were versioned. They are distinct from absent
+// records, so they can be written conditionally like any other record.
+const legacyVersion = 1
 
-				if err = protojson.Unmarshal(data, rec); err != nil {
-					s.log.Error().Err(err).Str("id", id).Msg("could not unmarshal record")
-					continue
-				}
+// currentVersion returns the version of a record and if it exists.
+func (s *Service) currentVersion(id string) (uint64, bool, error) {
+	data, err := s.get(id)
+	if err != nil {
+		if storage.IsNotFoundErr(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return recordVersion(data, time.Now())
+}
 
-				// index record
-				doc := BleveDocument{
-					Metadata: rec.Metadata,
-					Database: dbs[i],
-					Table:    tables[j],
-				}
-				if err := s.index.Index(id, doc); err != nil {
-					s.log.Error().Err(err).Interface("document", doc).Str("id", id).Msg("could not index record metadata")
-					continue
-				}
+// recordVersion returns the version of a persisted record and if it exists. Expired records don't exist anymore, even
+// if they were not swept yet.
+func recordVersion(data []byte, now time.Time) (uint64, bool, error) {
+	rec := &proto.Record{}
+	if err := protojson.Unmarshal(data, rec); err != nil {
+		return 0, false, err
+	}
+	if rec.ExpiresAt > 0 && !now.Before(time.Unix(rec.ExpiresAt, 0)) {
+		return 0, false, nil
+	}
+	if rec.Version == 0 {
+		return legacyVersion, true, nil
+	}
+	return rec.Version, true, nil
+}
 
-				s.log.Debug().Str("id", id).Msg("indexed record")
-			}
-		}
+// checkConditions returns a Conflict error if the record doesn't have the version required by the options, or exists
+// although it must be absent.
+func (s *Service) checkConditions(opts *proto.WriteOptions, version uint64, exists bool) error {
+	if opts.GetIfAbsent() && exists {
+		return merrors.Conflict(s.id, "record exists")
+	}
+	if opts.GetIfVersion() > 0 && (!exists || version != opts.GetIfVersion()) {
+		return merrors.Conflict(s.id, "record has version %d, not %d", version, opts.GetIfVersion())
 	}
+	return nil
+}
 
-	return
+// isNotFound checks if readRecord failed because the record doesn't exist or expired.
+func isNotFound(err error) bool {
+	return err != nil && merrors.Parse(err.Error()).Code == http.StatusNotFound
+}
+
+// expiresAt returns the unix time at which a record written now expires, 0 if it doesn't expire. The expiry time of
+// the options takes precedence over their ttl and the expiry of the record.
+func expiresAt(rec *proto.Record, opts *proto.WriteOptions, now time.Time) int64 {
+	switch {
+	case opts.GetExpiry() > 0:
+		return opts.GetExpiry()
+	case opts.GetTtl() > 0:
+		return now.Add(time.Duration(opts.GetTtl()) * time.Second).Unix()
+	case rec.GetExpiry() > 0:
+		return now.Add(time.Duration(rec.GetExpiry()) * time.Second).Unix()
+	default:
+		return 0
+	}
 }
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// listStream collects the keys sent by List.
+type listStream struct {
+	responses []*proto.ListResponse
+}
+
+func (s *listStream) Context() context.Context  { return context.Background() }
+func (s *listStream) SendMsg(interface{}) error { return nil }
+func (s *listStream) RecvMsg(interface{}) error { return nil }
+func (s *listStream) Close() error              { return nil }
+func (s *listStream) Send(r *proto.ListResponse) error {
+	s.responses = append(s.responses, r)
+	return nil
+}
+
+func (s *listStream) keys() []string {
+	keys := make([]string, 0)
+	for _, r := range s.responses {
+		keys = append(keys, r.Keys...)
+	}
+	return keys
+}
+
+// newTestService creates a service on top of records stored in the file layout databases/{db}/{table}/{key}.
+func newTestService(t *testing.T, records map[string][]string) (*Service, func()) {
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for table, keys := range records {
+		for _, key := range keys {
+			file := filepath.Join(root, "databases", "proxy", table, key)
+			data, err := protojson.Marshal(&proto.Record{Key: key, Value: []byte(table + ":" + key)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(file, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	cfg := config.New()
+	cfg.Datapath = root
+	s, err := New(Logger(log.NewLogger()), Config(cfg))
+	if err != nil {
+		os.RemoveAll(root)
+		t.Fatal(err)
+	}
+	return s, func() {
+		s.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func TestList(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{
+		"signing-keys": {"marie", "einstein", "feynman", "moss", "richard"},
+		"other":        {"einstein-other"},
+	})
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		opts *proto.ListOptions
+		keys []string
+	}{
+		{"all", &proto.ListOptions{}, []string{"einstein", "feynman", "marie", "moss", "richard"}},
+		{"prefix", &proto.ListOptions{Prefix: "m"}, []string{"marie", "moss"}},
+		{"suffix", &proto.ListOptions{Suffix: "n"}, []string{"einstein", "feynman"}},
+		{"prefix and suffix", &proto.ListOptions{Prefix: "m", Suffix: "e"}, []string{"marie"}},
+		{"limit", &proto.ListOptions{Limit: 2}, []string{"einstein", "feynman"}},
+		{"offset", &proto.ListOptions{Offset: 3}, []string{"moss", "richard"}},
+		{"limit and offset", &proto.ListOptions{Limit: 2, Offset: 1}, []string{"feynman", "marie"}},
+		{"offset beyond the end", &proto.ListOptions{Offset: 10}, []string{}},
+		{"no match", &proto.ListOptions{Prefix: "x"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Database = "proxy"
+			tt.opts.Table = "signing-keys"
+			stream := &listStream{}
+			err := s.List(context.Background(), &proto.ListRequest{Options: tt.opts}, stream)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.keys, stream.keys())
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	keys := make([]string, 0, listPageSize+1)
+	for i := 0; i <= listPageSize; i++ {
+		keys = append(keys, string(rune('a'+i/26))+string(rune('a'+i%26)))
+	}
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": keys})
+	defer cleanup()
+
+	stream := &listStream{}
+	err := s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "signing-keys"}}, stream)
+	assert.NoError(t, err)
+	assert.Len(t, stream.responses, 2)
+	assert.Equal(t, keys, stream.keys())
+}
+
+func TestListMissingTable(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	stream := &listStream{}
+	err := s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "signing-keys"}}, stream)
+	assert.NoError(t, err)
+	assert.Empty(t, stream.responses)
+
+	err = s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy"}}, stream)
+	assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+}
+
+func TestReadWithoutExactKey(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{
+		"signing-keys": {"marie", "einstein", "feynman", "moss"},
+	})
+	defer cleanup()
+
+	tests := []struct {
+		name string
+		key  string
+		opts *proto.ReadOptions
+		keys []string
+	}{
+		{"exact key", "moss", &proto.ReadOptions{}, []string{"moss"}},
+		{"all", "", &proto.ReadOptions{}, []string{"einstein", "feynman", "marie", "moss"}},
+		{"prefix", "m", &proto.ReadOptions{Prefix: true}, []string{"marie", "moss"}},
+		{"suffix", "n", &proto.ReadOptions{Suffix: true}, []string{"einstein", "feynman"}},
+		{"limit and offset", "", &proto.ReadOptions{Limit: 1, Offset: 2}, []string{"marie"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Database = "proxy"
+			tt.opts.Table = "signing-keys"
+			rsp := &proto.ReadResponse{}
+			err := s.Read(context.Background(), &proto.ReadRequest{Key: tt.key, Options: tt.opts}, rsp)
+			assert.NoError(t, err)
+			keys := make([]string, 0, len(rsp.Records))
+			for _, rec := range rsp.Records {
+				keys = append(keys, rec.Key)
+				assert.Equal(t, "signing-keys:"+rec.Key, string(rec.Value))
+			}
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tests := []struct {
+		name      string
+		rec       *proto.Record
+		opts      *proto.WriteOptions
+		expiresAt int64
+	}{
+		{"no expiry", &proto.Record{}, &proto.WriteOptions{}, 0},
+		{"record expiry", &proto.Record{Expiry: 60}, &proto.WriteOptions{}, 1600000060},
+		{"ttl", &proto.Record{Expiry: 60}, &proto.WriteOptions{Ttl: 30}, 1600000030},
+		{"expiry time", &proto.Record{Expiry: 60}, &proto.WriteOptions{Ttl: 30, Expiry: 1600000010}, 1600000010},
+		{"no options", &proto.Record{Expiry: 60}, nil, 1600000060},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expiresAt, expiresAt(tt.rec, tt.opts, now))
+		})
+	}
+}
+
+func TestExpiredRecords(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": {"einstein"}})
+	defer cleanup()
+
+	write := func(key string, opts *proto.WriteOptions) {
+		opts.Database = "proxy"
+		opts.Table = "signing-keys"
+		rec := &proto.Record{Key: key, Metadata: map[string]*proto.Field{"kind": {Type: "string", Value: "key"}}}
+		err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: opts}, &proto.WriteResponse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("marie", &proto.WriteOptions{Ttl: 3600})
+	write("feynman", &proto.WriteOptions{Expiry: time.Now().Add(-time.Minute).Unix()})
+
+	rsp := &proto.ReadResponse{}
+	err := s.Read(context.Background(), &proto.ReadRequest{Key: "marie", Options: &proto.ReadOptions{Database: "proxy", Table: "signing-keys"}}, rsp)
+	assert.NoError(t, err)
+	assert.InDelta(t, 3600, rsp.Records[0].Expiry, 1)
+
+	err = s.Read(context.Background(), &proto.ReadRequest{Key: "feynman", Options: &proto.ReadOptions{Database: "proxy", Table: "signing-keys"}}, &proto.ReadResponse{})
+	assert.EqualValues(t, http.StatusNotFound, merrors.Parse(err.Error()).Code)
+
+	stream := &listStream{}
+	err = s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "signing-keys", Limit: 2}}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"einstein", "marie"}, stream.keys())
+
+	rsp = &proto.ReadResponse{}
+	where := map[string]*proto.Field{"kind": {Type: "string", Value: "key"}}
+	err = s.Read(context.Background(), &proto.ReadRequest{Options: &proto.ReadOptions{Database: "proxy", Table: "signing-keys", Where: where}}, rsp)
+	assert.NoError(t, err)
+	assert.Len(t, rsp.Records, 1)
+
+	swept, err := s.Sweep(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, swept)
+	_, err = os.Stat(filepath.Join(s.Config.Datapath, "databases", "proxy", "signing-keys", "feynman"))
+	assert.True(t, os.IsNotExist(err))
+	count, err := s.index.DocCount()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	// marie expires in an hour
+	swept, err = s.Sweep(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, swept)
+}
+
+// countingBackend counts the values read from the backend.
+type countingBackend struct {
+	storage.Backend
+	gets *int
+}
+
+func (b countingBackend) Get(database, table, key string) ([]byte, error) {
+	*b.gets++
+	return b.Backend.Get(database, table, key)
+}
+
+func TestListFiltersExpiredRecordsWithoutReadingThem(t *testing.T) {
+	s, cleanup := newTestService(t, map[string][]string{"signing-keys": {"einstein"}})
+	defer cleanup()
+
+	for key, expiry := range map[string]int64{"marie": time.Now().Add(time.Hour).Unix(), "feynman": time.Now().Add(-time.Minute).Unix()} {
+		opts := &proto.WriteOptions{Database: "proxy", Table: "signing-keys", Expiry: expiry}
+		err := s.Write(context.Background(), &proto.WriteRequest{Record: &proto.Record{Key: key}, Options: opts}, &proto.WriteResponse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gets := 0
+	s.backend = countingBackend{Backend: s.backend, gets: &gets}
+	stream := &listStream{}
+	err := s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "signing-keys"}}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"einstein", "marie"}, stream.keys())
+	assert.Equal(t, 0, gets)
+
+	// the sweeper only reads the expired record
+	swept, err := s.Sweep(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, swept)
+	assert.Equal(t, 1, gets)
+}
+
+func TestBackends(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	for _, typ := range []string{"bolt", "redis"} {
+		t.Run(typ, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "store")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+
+			cfg := config.New()
+			cfg.Datapath = root
+			cfg.Backend.Type = typ
+			cfg.Backend.Redis.Addr = mr.Addr()
+			s, err := New(Logger(log.NewLogger()), Config(cfg))
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeAccount(t, s, "einstein", "einstein@example.org")
+			writeAccount(t, s, "marie", "marie@example.org")
+			assert.Equal(t, []string{"marie"}, findAccounts(t, s, "marie@example.org"))
+
+			err = s.Delete(context.Background(), &proto.DeleteRequest{Key: "marie", Options: &proto.DeleteOptions{Database: "proxy", Table: "accounts"}}, &proto.DeleteResponse{})
+			assert.NoError(t, err)
+
+			// the records are kept by the backend, not in the data path
+			_, err = os.Stat(filepath.Join(root, "databases"))
+			assert.True(t, os.IsNotExist(err))
+
+			s = restart(t, s)
+			stream := &listStream{}
+			err = s.List(context.Background(), &proto.ListRequest{Options: &proto.ListOptions{Database: "proxy", Table: "accounts"}}, stream)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"einstein"}, stream.keys())
+			assert.Equal(t, []string{"einstein"}, findAccounts(t, s, "einstein@example.org"))
+
+			dbs := &proto.DatabasesResponse{}
+			assert.NoError(t, s.Databases(context.Background(), &proto.DatabasesRequest{}, dbs))
+			assert.Equal(t, []string{"proxy"}, dbs.Databases)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/owncloud/ocis/store/pkg/storage"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// RunSweeper deletes expired records in the given interval until the context is done.
+func (s *Service) RunSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	s.sweep()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *Service) sweep() {
+	swept, err := s.Sweep(time.Now())
+	if err != nil {
+		s.log.Error().Err(err).Msg("could not sweep expired records")
+	}
+	if s.metrics != nil {
+		s.metrics.Swept.WithLabelValues().Add(float64(swept))
+	}
+	s.log.Debug().Int("swept", swept).Msg("swept expired records")
+}
+
+// Sweep deletes the expired records and their index documents. It returns the number of deleted records. The expired
+// records are found in the index, only they are read.
+func (s *Service) Sweep(now time.Time) (int, error) {
+	ids, err := s.expiredIDs(now)
+	if err != nil {
+		return 0, err
+	}
+	swept := 0
+	for id := range ids {
+		if s.sweepRecord(id, now) {
+			swept++
+		}
+	}
+	return swept, nil
+}
+
+// sweepRecord deletes an expired record, unless it was rewritten in the meantime.
+func (s *Service) sweepRecord(id string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.expiredAt(id, now) {
+		return false
+	}
+	database, table, key, err := storage.ParseID(id)
+	if err != nil {
+		return false
+	}
+	if err := s.journal(id); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not journal delete")
+		return false
+	}
+	if err := s.backend.Delete(database, table, key); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not delete expired record")
+		return false
+	}
+	s.publish(&proto.WatchResponse{
+		Type:     proto.WatchResponse_TYPE_EXPIRE,
+		Database: database,
+		Table:    table,
+		Key:      key,
+	})
+	if err := s.index.Delete(id); err != nil {
+		s.log.Error().Err(err).Str("id", id).Msg("could not remove expired record from index")
+		return true
+	}
+	s.commit(id)
+	return true
+}
+
+// expiredAt checks if the record with the given id expired before now.
+func (s *Service) expiredAt(id string, now time.Time) bool {
+	data, err := s.get(id)
+	if err != nil {
+		return false
+	}
+	rec := &proto.Record{}
+	if err := protojson.Unmarshal(data, rec); err != nil {
+		return false
+	}
+	return rec.ExpiresAt > 0 && !now.Before(time.Unix(rec.ExpiresAt, 0))
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+)
+
+// watchBufferSize is the number of events buffered for a watcher. Watchers which fall further behind are dropped.
+const watchBufferSize = 256
+
+// watcher receives the events of the records matching its options.
+type watcher struct {
+	opts   *proto.WatchOptions
+	events chan *proto.WatchResponse
+}
+
+// matches checks if the event is about a record the watcher watches.
+func (w *watcher) matches(ev *proto.WatchResponse) bool {
+	return ev.Database == w.opts.GetDatabase() &&
+		(w.opts.GetTable() == "" || ev.Table == w.opts.GetTable()) &&
+		strings.HasPrefix(ev.Key, w.opts.GetPrefix())
+}
+
+// Watch implements the StoreHandler interface. The events are sent until the client cancels the stream.
+func (s *Service) Watch(ctx context.Context, wreq *proto.WatchRequest, stream proto.Store_WatchStream) error {
+	opts := wreq.GetOptions()
+	if err := validateName("database", opts.GetDatabase()); err != nil {
+		return merrors.BadRequest(s.id, "%v", err)
+	}
+
+	w := s.addWatcher(opts)
+	defer s.removeWatcher(w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.events:
+			if !ok {
+				s.log.Warn().Interface("options", opts).Msg("dropped watcher which fell behind")
+				return merrors.InternalServerError(s.id, "watcher fell behind, events were dropped")
+			}
+			if err := stream.Send(ev); err != nil {
+				s.log.Debug().Err(err).Msg("could not send event")
+				return nil
+			}
+		}
+	}
+}
+
+func (s *Service) addWatcher(opts *proto.WatchOptions) *watcher {
+	w := &watcher{
+		opts:   opts,
+		events: make(chan *proto.WatchResponse, watchBufferSize),
+	}
+
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	if s.watchers == nil {
+		s.watchers = map[*watcher]struct{}{}
+	}
+	s.watchers[w] = struct{}{}
+	return w
+}
+
+func (s *Service) removeWatcher(w *watcher) {
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	if _, ok := s.watchers[w]; ok {
+		delete(s.watchers, w)
+		close(w.events)
+	}
+}
+
+// publish sends an event to all matching watchers without blocking. A watcher whose buffer is full is removed and
+// its channel closed, so its stream ends instead of silently missing events. Changes are published while s.mu is
+// held, so watchers receive the events of a record in the order they were applied.
+func (s *Service) publish(ev *proto.WatchResponse) {
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	for w := range s.watchers {
+		if !w.matches(ev) {
+			continue
+		}
+		select {
+		case w.events <- ev:
+		default:
+			delete(s.watchers, w)
+			close(w.events)
+		}
+	}
+}
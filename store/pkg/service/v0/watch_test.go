@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/store/pkg/proto/v0"
+	"github.com/stretchr/testify/assert"
+)
+
+// watchStream passes the sent events to a channel.
+type watchStream struct {
+	events chan *proto.WatchResponse
+	// block makes Send wait until the stream is unblocked, to simulate a slow client
+	block chan struct{}
+}
+
+func (s *watchStream) Context() context.Context  { return context.Background() }
+func (s *watchStream) SendMsg(interface{}) error { return nil }
+func (s *watchStream) RecvMsg(interface{}) error { return nil }
+func (s *watchStream) Close() error              { return nil }
+func (s *watchStream) Send(r *proto.WatchResponse) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.events <- r
+	return nil
+}
+
+// watch starts watching in the background and returns the watcher and a channel which receives the result of Watch
+// once the context is done.
+func watch(ctx context.Context, t *testing.T, s *Service, opts *proto.WatchOptions, stream *watchStream) (*watcher, <-chan error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(ctx, &proto.WatchRequest{Options: opts}, stream)
+	}()
+
+	for i := 0; i < 100; i++ {
+		s.watchersMu.Lock()
+		for w := range s.watchers {
+			s.watchersMu.Unlock()
+			return w, done
+		}
+		s.watchersMu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("watcher wasn't added")
+	return nil, nil
+}
+
+func newWatchStream() *watchStream {
+	return &watchStream{events: make(chan *proto.WatchResponse, 2*watchBufferSize)}
+}
+
+func nextEvent(t *testing.T, stream *watchStream) *proto.WatchResponse {
+	select {
+	case ev := <-stream.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newWatchStream()
+	_, done := watch(ctx, t, s, &proto.WatchOptions{Database: "proxy", Table: "accounts", Prefix: "ein"}, stream)
+
+	writeAccount(t, s, "marie", "marie@example.org")
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	rec := &proto.Record{Key: "einstein"}
+	err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "proxy", Table: "sessions"}}, &proto.WriteResponse{})
+	assert.NoError(t, err)
+	err = s.Delete(context.Background(), &proto.DeleteRequest{Key: "einstein", Options: &proto.DeleteOptions{Database: "proxy", Table: "accounts"}}, &proto.DeleteResponse{})
+	assert.NoError(t, err)
+	rec = &proto.Record{Key: "einstein-expired"}
+	err = s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "proxy", Table: "accounts", Ttl: 1}}, &proto.WriteResponse{})
+	assert.NoError(t, err)
+	_, err = s.Sweep(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	ev := nextEvent(t, stream)
+	assert.Equal(t, proto.WatchResponse_TYPE_WRITE, ev.Type)
+	assert.Equal(t, "einstein", ev.Key)
+	assert.Equal(t, "einstein@example.org", ev.Record.Metadata["mail"].Value)
+
+	ev = nextEvent(t, stream)
+	assert.Equal(t, proto.WatchResponse_TYPE_DELETE, ev.Type)
+	assert.Equal(t, "proxy", ev.Database)
+	assert.Equal(t, "accounts", ev.Table)
+	assert.Equal(t, "einstein", ev.Key)
+	assert.Nil(t, ev.Record)
+
+	ev = nextEvent(t, stream)
+	assert.Equal(t, proto.WatchResponse_TYPE_WRITE, ev.Type)
+	assert.Equal(t, "einstein-expired", ev.Key)
+
+	ev = nextEvent(t, stream)
+	assert.Equal(t, proto.WatchResponse_TYPE_EXPIRE, ev.Type)
+	assert.Equal(t, "einstein-expired", ev.Key)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Empty(t, stream.events)
+	assert.Empty(t, s.watchers)
+}
+
+func TestWatchAllTables(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := newWatchStream()
+	watch(ctx, t, s, &proto.WatchOptions{Database: "proxy"}, stream)
+
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	rec := &proto.Record{Key: "s1"}
+	err := s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "proxy", Table: "sessions"}}, &proto.WriteResponse{})
+	assert.NoError(t, err)
+	err = s.Write(context.Background(), &proto.WriteRequest{Record: rec, Options: &proto.WriteOptions{Database: "ocs", Table: "sessions"}}, &proto.WriteResponse{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "accounts", nextEvent(t, stream).Table)
+	assert.Equal(t, "sessions", nextEvent(t, stream).Table)
+	select {
+	case ev := <-stream.events:
+		t.Fatalf("unexpected event %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchRequiresDatabase(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	err := s.Watch(context.Background(), &proto.WatchRequest{Options: &proto.WatchOptions{Table: "accounts"}}, &watchStream{})
+	assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+}
+
+func TestSlowWatcherIsDropped(t *testing.T) {
+	s, cleanup := newTestService(t, nil)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := newWatchStream()
+	stream.block = make(chan struct{})
+	w, done := watch(ctx, t, s, &proto.WatchOptions{Database: "proxy"}, stream)
+
+	// wait until the first event is taken by the blocked Send
+	writeAccount(t, s, "einstein", "einstein@example.org")
+	for len(w.events) > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the buffer overflows with the last event
+	for i := 0; i <= watchBufferSize; i++ {
+		writeAccount(t, s, "einstein", "einstein@example.org")
+	}
+	s.watchersMu.Lock()
+	assert.Empty(t, s.watchers)
+	s.watchersMu.Unlock()
+
+	close(stream.block)
+	select {
+	case err := <-done:
+		assert.EqualValues(t, http.StatusInternalServerError, merrors.Parse(err.Error()).Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't end")
+	}
+	// the buffered events are sent before the stream ends
+	assert.Len(t, stream.events, watchBufferSize+1)
+}
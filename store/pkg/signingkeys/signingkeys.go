@@ -0,0 +1,127 @@
+// Package signingkeys persists the signing keyrings of pre-signed urls in the store service. It is shared by the
+// proxy, which verifies urls with the keys, and the services managing them.
+package signingkeys
+
+import (
+	"context"
+	"net/http"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
+)
+
+const (
+	// Database the keyrings are stored in
+	Database = "proxy"
+	// Table the keyrings are stored in, keyed by account id
+	Table = "signing-keys"
+
+	// maxAttempts limits how often a keyring update is retried when it is changed concurrently
+	maxAttempts = 5
+)
+
+// ReadError is returned by Update when the keyring could not be read, to tell it apart from a failed write.
+type ReadError struct {
+	error
+}
+
+// UpdateFunc is passed the current keyring, nil if the user has none, and returns the new keyring and whether it
+// changed.
+type UpdateFunc func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error)
+
+// Keyrings reads and writes the signing keyrings of users.
+type Keyrings struct {
+	store storepb.StoreService
+}
+
+// New returns the keyrings kept in the given store service.
+func New(store storepb.StoreService) Keyrings {
+	return Keyrings{store: store}
+}
+
+// Read returns nil if the user has no signing keys yet. The version of the record is returned for conditional
+// writes.
+func (k Keyrings) Read(ctx context.Context, userID string) (*signedurl.Keyring, uint64, error) {
+	res, err := k.store.Read(ctx, &storepb.ReadRequest{
+		Options: &storepb.ReadOptions{
+			Database: Database,
+			Table:    Table,
+		},
+		Key: userID,
+	})
+	if err != nil {
+		if merrors.FromError(err).Code == http.StatusNotFound {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	if len(res.Records) == 0 {
+		return nil, 0, nil
+	}
+	keyring, err := signedurl.ParseKeyring(res.Records[0].Value)
+	return keyring, res.Records[0].Version, err
+}
+
+// Update reads the keyring of the user, passes it to update and writes the result back if update reports a change.
+// The write fails if the keyring was changed since it was read, in that case update is applied again to the new
+// keyring. An empty keyring is deleted. Errors of reading the keyring are returned as ReadError.
+func (k Keyrings) Update(ctx context.Context, userID string, update UpdateFunc) (*signedurl.Keyring, error) {
+	for attempt := 1; ; attempt++ {
+		keyring, version, err := k.Read(ctx, userID)
+		if err != nil {
+			return nil, ReadError{err}
+		}
+		exists := keyring != nil
+
+		keyring, changed, err := update(keyring)
+		if err != nil || !changed {
+			return keyring, err
+		}
+
+		if len(keyring.Keys) == 0 {
+			return keyring, k.Delete(ctx, userID)
+		}
+		err = k.write(ctx, userID, keyring, version, exists)
+		if err == nil || merrors.FromError(err).Code != http.StatusConflict || attempt == maxAttempts {
+			return keyring, err
+		}
+	}
+}
+
+// Delete removes all signing keys of the user. Deleting a keyring which does not exist is not an error.
+func (k Keyrings) Delete(ctx context.Context, userID string) error {
+	_, err := k.store.Delete(ctx, &storepb.DeleteRequest{
+		Options: &storepb.DeleteOptions{
+			Database: Database,
+			Table:    Table,
+		},
+		Key: userID,
+	})
+	if err != nil && merrors.FromError(err).Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// write writes the keyring if the stored one still has the given version, or doesn't exist if exists is false.
+// Records written before the store versioned them are read with version 1, so they are written conditionally too.
+func (k Keyrings) write(ctx context.Context, userID string, keyring *signedurl.Keyring, version uint64, exists bool) error {
+	value, err := keyring.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = k.store.Write(ctx, &storepb.WriteRequest{
+		Options: &storepb.WriteOptions{
+			Database:  Database,
+			Table:     Table,
+			IfVersion: version,
+			IfAbsent:  !exists,
+		},
+		Record: &storepb.Record{
+			Key:   userID,
+			Value: value,
+		},
+	})
+	return err
+}
@@ -0,0 +1,140 @@
+package signingkeys
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/client"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/ocis-pkg/signedurl"
+	"github.com/owncloud/ocis/store/pkg/config"
+	storepb "github.com/owncloud/ocis/store/pkg/proto/v0"
+	svc "github.com/owncloud/ocis/store/pkg/service/v0"
+	"github.com/stretchr/testify/assert"
+)
+
+// serviceClient calls the store service directly instead of going through grpc.
+type serviceClient struct {
+	storepb.StoreService
+	s *svc.Service
+	// beforeWrite is called before every write, to simulate concurrent changes
+	beforeWrite func()
+}
+
+func (c *serviceClient) Read(ctx context.Context, in *storepb.ReadRequest, opts ...client.CallOption) (*storepb.ReadResponse, error) {
+	res := &storepb.ReadResponse{}
+	return res, c.s.Read(ctx, in, res)
+}
+
+func (c *serviceClient) Write(ctx context.Context, in *storepb.WriteRequest, opts ...client.CallOption) (*storepb.WriteResponse, error) {
+	if c.beforeWrite != nil {
+		c.beforeWrite()
+	}
+	res := &storepb.WriteResponse{}
+	return res, c.s.Write(ctx, in, res)
+}
+
+func (c *serviceClient) Delete(ctx context.Context, in *storepb.DeleteRequest, opts ...client.CallOption) (*storepb.DeleteResponse, error) {
+	res := &storepb.DeleteResponse{}
+	return res, c.s.Delete(ctx, in, res)
+}
+
+func newTestKeyrings(t *testing.T) (Keyrings, *serviceClient) {
+	root, err := ioutil.TempDir("", "signingkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.New()
+	cfg.Datapath = root
+	s, err := svc.New(svc.Logger(log.NewLogger()), svc.Config(cfg))
+	if err != nil {
+		os.RemoveAll(root)
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		os.RemoveAll(root)
+	})
+	c := &serviceClient{s: s}
+	return New(c), c
+}
+
+func rotate(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+	if keyring == nil {
+		keyring = &signedurl.Keyring{}
+	}
+	_, err := keyring.Rotate(time.Now(), time.Hour)
+	return keyring, true, err
+}
+
+func TestUpdate(t *testing.T) {
+	k, _ := newTestKeyrings(t)
+	ctx := context.Background()
+
+	keyring, _, err := k.Read(ctx, "einstein")
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+
+	_, err = k.Update(ctx, "einstein", rotate)
+	assert.NoError(t, err)
+	_, err = k.Update(ctx, "einstein", rotate)
+	assert.NoError(t, err)
+
+	keyring, version, err := k.Read(ctx, "einstein")
+	assert.NoError(t, err)
+	assert.Len(t, keyring.Keys, 2)
+	assert.NotZero(t, version)
+
+	assert.NoError(t, k.Delete(ctx, "einstein"))
+	assert.NoError(t, k.Delete(ctx, "einstein"))
+	keyring, _, err = k.Read(ctx, "einstein")
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+}
+
+func TestUpdateRetriesConcurrentChanges(t *testing.T) {
+	k, c := newTestKeyrings(t)
+	ctx := context.Background()
+	_, err := k.Update(ctx, "einstein", rotate)
+	assert.NoError(t, err)
+
+	// another client rotates the key between our read and write
+	concurrent := New(&serviceClient{s: c.s})
+	c.beforeWrite = func() {
+		c.beforeWrite = nil
+		_, err := concurrent.Update(ctx, "einstein", rotate)
+		assert.NoError(t, err)
+	}
+
+	calls := 0
+	_, err = k.Update(ctx, "einstein", func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+		calls++
+		return rotate(keyring)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// neither rotation was lost
+	keyring, _, err := k.Read(ctx, "einstein")
+	assert.NoError(t, err)
+	assert.Len(t, keyring.Keys, 3)
+}
+
+func TestUpdateDeletesEmptyKeyrings(t *testing.T) {
+	k, _ := newTestKeyrings(t)
+	ctx := context.Background()
+	keyring, err := k.Update(ctx, "einstein", rotate)
+	assert.NoError(t, err)
+	current, _ := keyring.Current()
+
+	_, err = k.Update(ctx, "einstein", func(keyring *signedurl.Keyring) (*signedurl.Keyring, bool, error) {
+		return keyring, keyring.Revoke(current.ID), nil
+	})
+	assert.NoError(t, err)
+	keyring, _, err = k.Read(ctx, "einstein")
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+}
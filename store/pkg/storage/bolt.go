@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt provides an embedded bbolt implementation of the Backend interface. Every database is a bucket, which contains
+// a nested bucket for every table.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// NewBolt opens or creates the bbolt file at path.
+func NewBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Bolt{db: db}, nil
+}
+
+// NewBoltReadOnly opens the existing bbolt file at path for reading, writes fail. Other readers may open the file at
+// the same time, a store holding it open for writing makes it fail.
+func NewBoltReadOnly(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Get reads a key from the bucket of its table.
+func (b *Bolt) Get(database, table, key string) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		t := b.table(tx, database, table)
+		if t == nil {
+			return &notFoundErr{database, table, key}
+		}
+		v := t.Get([]byte(key))
+		if v == nil {
+			return &notFoundErr{database, table, key}
+		}
+		// v is only valid during the transaction
+		value = append([]byte{}, v...)
+		return nil
+	})
+	return value, err
+}
+
+// Put writes a key to the bucket of its table, the buckets are created if they don't exist.
+func (b *Bolt) Put(database, table, key string, value []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		db, err := tx.CreateBucketIfNotExists([]byte(database))
+		if err != nil {
+			return err
+		}
+		t, err := db.CreateBucketIfNotExists([]byte(table))
+		if err != nil {
+			return err
+		}
+		return t.Put([]byte(key), value)
+	})
+}
+
+// Delete removes a key from the bucket of its table.
+func (b *Bolt) Delete(database, table, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		t := b.table(tx, database, table)
+		if t == nil || t.Get([]byte(key)) == nil {
+			return &notFoundErr{database, table, key}
+		}
+		return t.Delete([]byte(key))
+	})
+}
+
+// Apply implements the Transactional interface with a single bbolt transaction.
+func (b *Bolt) Apply(database, table string, changes []Change) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		db, err := tx.CreateBucketIfNotExists([]byte(database))
+		if err != nil {
+			return err
+		}
+		t, err := db.CreateBucketIfNotExists([]byte(table))
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if c.Value == nil {
+				err = t.Delete([]byte(c.Key))
+			} else {
+				err = t.Put([]byte(c.Key), c.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Keys returns the keys of a table bucket, bbolt keeps them sorted.
+func (b *Bolt) Keys(database, table string) ([]string, error) {
+	keys := make([]string, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		t := b.table(tx, database, table)
+		if t == nil {
+			return nil
+		}
+		return t.ForEach(func(k, v []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// Databases returns the names of the top level buckets.
+func (b *Bolt) Databases() ([]string, error) {
+	names := make([]string, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+	return names, err
+}
+
+// Tables returns the names of the nested buckets of a database.
+func (b *Bolt) Tables(database string) ([]string, error) {
+	names := make([]string, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		db := tx.Bucket([]byte(database))
+		if db == nil {
+			return nil
+		}
+		return db.ForEach(func(k, v []byte) error {
+			// nested buckets have no value
+			if v == nil {
+				names = append(names, string(k))
+			}
+			return nil
+		})
+	})
+	return names, err
+}
+
+// Close closes the bbolt file.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// table returns the bucket of a table, nil if it doesn't exist.
+func (b *Bolt) table(tx *bolt.Tx, database, table string) *bolt.Bucket {
+	db := tx.Bucket([]byte(database))
+	if db == nil {
+		return nil
+	}
+	return db.Bucket([]byte(table))
+}
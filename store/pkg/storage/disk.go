@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+)
+
+// Disk provides a local filesystem implementation of the Backend interface. Every key is stored in its own file,
+// named after the encoded names of its database, table and key.
+type Disk struct {
+	root string
+	log  log.Logger
+}
+
+// NewDisk creates a new disk backend which keeps its files in the databases directory of the data path.
+func NewDisk(datapath string, logger log.Logger) (*Disk, error) {
+	d := &Disk{
+		root: datapath,
+		log:  logger,
+	}
+	recordsDir := filepath.Join(datapath, "databases")
+	fi, err := os.Stat(recordsDir)
+	switch {
+	case os.IsNotExist(err):
+		// create store directory
+		if err := os.MkdirAll(recordsDir, 0700); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !fi.IsDir():
+		return nil, fmt.Errorf("%s is not a directory", recordsDir)
+	}
+	return d, nil
+}
+
+// Get reads the file of a key.
+func (d *Disk) Get(database, table, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(d.root, "databases", ID(database, table, key)))
+	if os.IsNotExist(err) {
+		return nil, &notFoundErr{database, table, key}
+	}
+	return data, err
+}
+
+// Put atomically replaces the file of a key.
+func (d *Disk) Put(database, table, key string, value []byte) error {
+	return WriteFile(filepath.Join(d.root, "tmp"), filepath.Join(d.root, "databases", ID(database, table, key)), value)
+}
+
+// Delete removes the file of a key.
+func (d *Disk) Delete(database, table, key string) error {
+	err := os.Remove(filepath.Join(d.root, "databases", ID(database, table, key)))
+	if os.IsNotExist(err) {
+		return &notFoundErr{database, table, key}
+	}
+	return err
+}
+
+// Keys lists the files of a table directory.
+func (d *Disk) Keys(database, table string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(d.root, "databases", EncodeName(database), EncodeName(table)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		keys = append(keys, f.Name())
+	}
+	keys = d.decodeNames(keys)
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Databases lists the database directories.
+func (d *Disk) Databases() ([]string, error) {
+	return d.readDirnames(filepath.Join(d.root, "databases"))
+}
+
+// Tables lists the table directories of a database.
+func (d *Disk) Tables(database string) ([]string, error) {
+	return d.readDirnames(filepath.Join(d.root, "databases", EncodeName(database)))
+}
+
+// Close does nothing, the files are closed after every operation.
+func (d *Disk) Close() error {
+	return nil
+}
+
+func (d *Disk) readDirnames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(0)
+	if err != nil {
+		return nil, err
+	}
+	names = d.decodeNames(names)
+	sort.Strings(names)
+	return names, nil
+}
+
+// decodeNames decodes file names, names which can't be decoded are skipped.
+func (d *Disk) decodeNames(encoded []string) []string {
+	names := make([]string, 0, len(encoded))
+	for _, e := range encoded {
+		name, err := DecodeName(e)
+		if err != nil {
+			d.log.Error().Err(err).Str("name", e).Msg("could not decode name")
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// WriteFile atomically replaces a file. The data is written to a temporary file in tmpDir which is renamed once it is
+// synced, tmpDir must be on the same filesystem as the file.
+func WriteFile(tmpDir, file string, data []byte) error {
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(tmpDir, "store")
+	if err != nil {
+		return err
+	}
+	// fails once the file was renamed
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"fmt"
+)
+
+type notFoundErr struct {
+	database, table, key string
+}
+
+func (e *notFoundErr) Error() string {
+	return fmt.Sprintf("key %s not found in table %s of database %s", e.key, e.table, e.database)
+}
+
+// IsNotFoundErr can be returned by backend Get and Delete operations
+func IsNotFoundErr(e error) bool {
+	_, ok := e.(*notFoundErr)
+	return ok
+}
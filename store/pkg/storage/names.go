@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EncodeName encodes the name of a database, table or key, so it can be used as a file name. Bytes other than ASCII
+// letters, digits, '-', '_' and '.' are percent-encoded. A leading '.' is encoded as well, so names like "..", "." or
+// hidden files can't occur.
+func EncodeName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_':
+			b.WriteByte(c)
+		case c == '.' && i > 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// DecodeName reverses EncodeName.
+func DecodeName(encoded string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(encoded); i++ {
+		if encoded[i] != '%' {
+			b.WriteByte(encoded[i])
+			continue
+		}
+		if i+2 >= len(encoded) {
+			return "", fmt.Errorf("invalid escape in %s", encoded)
+		}
+		c, err := strconv.ParseUint(encoded[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %s", encoded)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// ID returns the id of a record, which is also the path of its file relative to the databases directory of the disk
+// backend.
+// file: /var/tmp/ocis/store/databases/{database}/{table}/{record.key}, all names encoded with EncodeName.
+func ID(database string, table string, key string) string {
+	return filepath.Join(EncodeName(database), EncodeName(table), EncodeName(key))
+}
+
+// ParseID reverses ID.
+func ParseID(id string) (database, table, key string, err error) {
+	parts := strings.Split(filepath.ToSlash(id), "/")
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("%s is not a record", id)
+	}
+	if database, err = DecodeName(parts[0]); err != nil {
+		return "", "", "", err
+	}
+	if table, err = DecodeName(parts[1]); err != nil {
+		return "", "", "", err
+	}
+	if key, err = DecodeName(parts[2]); err != nil {
+		return "", "", "", err
+	}
+	return database, table, key, nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nastyName is a name made of the bytes most likely to break the path mapping.
+type nastyName string
+
+// Generate implements quick.Generator.
+func (nastyName) Generate(r *rand.Rand, size int) reflect.Value {
+	alphabet := []string{".", "..", "/", "\\", "%", "%2E", "%2F", "\x00", "a", "Z", "-", "_", " ", "ä", "\xff", "~"}
+	var b strings.Builder
+	for i := r.Intn(size + 1); i > 0; i-- {
+		b.WriteString(alphabet[r.Intn(len(alphabet))])
+	}
+	return reflect.ValueOf(nastyName(b.String()))
+}
+
+func TestEncodeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"signing-keys", "signing-keys"},
+		{"einstein@example.org", "einstein%40example.org"},
+		{".", "%2E"},
+		{"..", "%2E."},
+		{"../../etc/passwd", "%2E.%2F..%2Fetc%2Fpasswd"},
+		{".hidden", "%2Ehidden"},
+		{"a/b", "a%2Fb"},
+		{"100%", "100%25"},
+		{"ä", "%C3%A4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.encoded, EncodeName(tt.name))
+			decoded, err := DecodeName(tt.encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, decoded)
+		})
+	}
+}
+
+func TestDecodeInvalidName(t *testing.T) {
+	for _, encoded := range []string{"%", "%2", "a%zz", "%G0"} {
+		_, err := DecodeName(encoded)
+		assert.Error(t, err, encoded)
+	}
+}
+
+func TestNameEncodingRoundTrip(t *testing.T) {
+	roundTrip := func(name string) bool {
+		decoded, err := DecodeName(EncodeName(name))
+		return err == nil && decoded == name
+	}
+	nastyRoundTrip := func(name nastyName) bool {
+		return roundTrip(string(name))
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 10000}))
+	assert.NoError(t, quick.Check(nastyRoundTrip, &quick.Config{MaxCount: 10000}))
+}
+
+func TestIDStaysInsideTheTable(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "var", "tmp", "ocis", "store", "databases")
+	inside := func(database, table, key nastyName) bool {
+		if database == "" || table == "" || key == "" {
+			return true
+		}
+		id := ID(string(database), string(table), string(key))
+		parts := strings.Split(filepath.ToSlash(id), "/")
+		if len(parts) != 3 {
+			return false
+		}
+		for _, part := range parts {
+			if part == "" || strings.HasPrefix(part, ".") || strings.ContainsAny(part, "/\\\x00") {
+				return false
+			}
+		}
+		if !strings.HasPrefix(filepath.Join(root, id), root+string(filepath.Separator)) {
+			return false
+		}
+		d, tb, k, err := ParseID(id)
+		return err == nil && d == string(database) && tb == string(table) && k == string(key)
+	}
+	assert.NoError(t, quick.Check(inside, &quick.Config{MaxCount: 10000}))
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// redisPrefix is the prefix of all redis keys used by the store.
+	redisPrefix = "store:"
+)
+
+var (
+	// redisLeaseTTL is how long the lease of a store instance is valid without being renewed. It is renewed every
+	// third of it.
+	redisLeaseTTL = 15 * time.Second
+
+	// ErrLeaseHeld is returned when another store instance uses the redis database.
+	ErrLeaseHeld = errors.New("the redis database is used by another store instance")
+	// ErrLeaseLost is returned by writes after the lease could not be renewed in time.
+	ErrLeaseLost = errors.New("the lease on the redis database was lost")
+
+	// renewLease extends the lease if it is still held by the given owner.
+	renewLease = redis.NewScript(1, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+	// releaseLease deletes the lease if it is still held by the given owner.
+	releaseLease = redis.NewScript(1, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+)
+
+// Redis provides a redis implementation of the Backend interface. Values are stored as strings, the keys of every
+// table in a sorted set and the names of the databases and tables in sets, so they can be listed without scanning the
+// keyspace.
+//
+// The metadata index, the journal and the watchers of the store are kept by the store instance, so only one instance
+// may use a redis database, just like a bolt file. This is enforced with a lease, which the instance holds while the
+// backend is open. Redis is not a way to scale the store horizontally.
+type Redis struct {
+	pool  *redis.Pool
+	owner string
+	// lost is set to 1 when the lease could not be renewed, writes fail from then on
+	lost int32
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRedis connects to the redis server at addr, selects the given database and takes the lease on it. If another
+// instance holds the lease, it waits until the lease would have expired before giving up, so a restarted instance
+// can take over the lease of its crashed predecessor.
+func NewRedis(addr, password string, db int) (*Redis, error) {
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, err
+	}
+	r := &Redis{
+		pool: &redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 5 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", addr, redis.DialPassword(password), redis.DialDatabase(db))
+			},
+		},
+		owner: hex.EncodeToString(owner),
+		stop:  make(chan struct{}),
+	}
+
+	if err := r.acquireLease(); err != nil {
+		r.pool.Close()
+		return nil, err
+	}
+
+	r.wg.Add(1)
+	go r.renewLease()
+	return r, nil
+}
+
+// acquireLease takes the lease, waiting at most redisLeaseTTL for another instance to give it up. It fails early if
+// the server can't be reached.
+func (r *Redis) acquireLease() error {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	deadline := time.Now().Add(redisLeaseTTL)
+	for {
+		_, err := redis.String(conn.Do("SET", leaseKey(), r.owner, "NX", "PX", redisLeaseTTL.Milliseconds()))
+		if err != redis.ErrNil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrLeaseHeld
+		}
+		time.Sleep(redisLeaseTTL / 10)
+	}
+}
+
+// renewLease renews the lease until the backend is closed. If the lease is lost, because another instance took it
+// over or the server was unreachable for too long, the backend refuses all further writes.
+func (r *Redis) renewLease() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(redisLeaseTTL / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		conn := r.pool.Get()
+		ok, err := redis.Int(renewLease.Do(conn, leaseKey(), r.owner, redisLeaseTTL.Milliseconds()))
+		conn.Close()
+		switch {
+		case err == nil && ok == 1:
+			renewed = time.Now()
+		case err == nil || time.Since(renewed) >= redisLeaseTTL:
+			atomic.StoreInt32(&r.lost, 1)
+			return
+		}
+	}
+}
+
+// checkLease returns ErrLeaseLost after the lease was lost.
+func (r *Redis) checkLease() error {
+	if atomic.LoadInt32(&r.lost) == 1 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Get reads the string of a key.
+func (r *Redis) Get(database, table, key string) ([]byte, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	value, err := redis.Bytes(conn.Do("GET", recordKey(database, table, key)))
+	if err == redis.ErrNil {
+		return nil, &notFoundErr{database, table, key}
+	}
+	return value, err
+}
+
+// Put sets the string of a key and adds the key, table and database to their sets in one transaction.
+func (r *Redis) Put(database, table, key string, value []byte) error {
+	if err := r.checkLease(); err != nil {
+		return err
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("SET", recordKey(database, table, key), value)
+	conn.Send("ZADD", keysKey(database, table), 0, key)
+	conn.Send("SADD", tablesKey(database), table)
+	conn.Send("SADD", databasesKey(), database)
+	_, err := conn.Do("EXEC")
+	return err
+}
+
+// Delete removes the string of a key and the key from the sorted set of its table in one transaction.
+func (r *Redis) Delete(database, table, key string) error {
+	if err := r.checkLease(); err != nil {
+		return err
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("DEL", recordKey(database, table, key))
+	conn.Send("ZREM", keysKey(database, table), key)
+	replies, err := redis.Ints(conn.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+	if replies[0] == 0 {
+		return &notFoundErr{database, table, key}
+	}
+	return nil
+}
+
+// Apply implements the Transactional interface with a single MULTI/EXEC transaction.
+func (r *Redis) Apply(database, table string, changes []Change) error {
+	if err := r.checkLease(); err != nil {
+		return err
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	for _, c := range changes {
+		if c.Value == nil {
+			conn.Send("DEL", recordKey(database, table, c.Key))
+			conn.Send("ZREM", keysKey(database, table), c.Key)
+			continue
+		}
+		conn.Send("SET", recordKey(database, table, c.Key), c.Value)
+		conn.Send("ZADD", keysKey(database, table), 0, c.Key)
+	}
+	conn.Send("SADD", tablesKey(database), table)
+	conn.Send("SADD", databasesKey(), database)
+	_, err := conn.Do("EXEC")
+	return err
+}
+
+// Keys returns the sorted set of a table. All members have the same score, so redis orders them lexically.
+func (r *Redis) Keys(database, table string) ([]string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	return redis.Strings(conn.Do("ZRANGE", keysKey(database, table), 0, -1))
+}
+
+// Databases returns the set of databases.
+func (r *Redis) Databases() ([]string, error) {
+	return r.members(databasesKey())
+}
+
+// Tables returns the set of tables of a database.
+func (r *Redis) Tables(database string) ([]string, error) {
+	return r.members(tablesKey(database))
+}
+
+// Close releases the lease and closes the connections to the redis server.
+func (r *Redis) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+
+	conn := r.pool.Get()
+	_, err := releaseLease.Do(conn, leaseKey(), r.owner)
+	conn.Close()
+	if perr := r.pool.Close(); err == nil {
+		err = perr
+	}
+	return err
+}
+
+func (r *Redis) members(set string) ([]string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	names, err := redis.Strings(conn.Do("SMEMBERS", set))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// The names in redis keys are encoded with EncodeName, so the separators can't occur in them.
+
+func leaseKey() string {
+	return redisPrefix + "lease"
+}
+
+func databasesKey() string {
+	return redisPrefix + "databases"
+}
+
+func tablesKey(database string) string {
+	return redisPrefix + "tables:" + EncodeName(database)
+}
+
+func keysKey(database, table string) string {
+	return redisPrefix + "keys:" + EncodeName(database) + "/" + EncodeName(table)
+}
+
+func recordKey(database, table, key string) string {
+	return redisPrefix + "records:" + EncodeName(database) + "/" + EncodeName(table) + "/" + EncodeName(key)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/config"
+)
+
+// Backend defines the operations the store needs to keep its records. Values are opaque to the backend, the store
+// persists marshalled records and keeps the metadata index itself.
+type Backend interface {
+	// Get returns the value of a key, a notFoundErr if the key doesn't exist.
+	Get(database, table, key string) ([]byte, error)
+	// Put creates or replaces the value of a key.
+	Put(database, table, key string, value []byte) error
+	// Delete removes a key, a notFoundErr is returned if the key doesn't exist.
+	Delete(database, table, key string) error
+	// Keys returns all keys of a table in lexical order.
+	Keys(database, table string) ([]string, error)
+	// Databases returns the names of all databases.
+	Databases() ([]string, error)
+	// Tables returns the names of all tables of a database.
+	Tables(database string) ([]string, error)
+	// Close releases the resources of the backend.
+	Close() error
+}
+
+// Change is a put of a value to a key, or a delete of the key if the value is nil.
+type Change struct {
+	Key   string
+	Value []byte
+}
+
+// Transactional is implemented by backends which can apply several changes of a table in one transaction.
+type Transactional interface {
+	// Apply applies all changes or none of them. Deleting a key which doesn't exist is not an error.
+	Apply(database, table string, changes []Change) error
+}
+
+// New returns the backend configured in cfg.
+func New(cfg *config.Config, logger log.Logger) (Backend, error) {
+	switch cfg.Backend.Type {
+	case "", "disk":
+		return NewDisk(cfg.Datapath, logger)
+	case "bolt":
+		path := boltPath(cfg)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		return NewBolt(path)
+	case "redis":
+		return NewRedis(cfg.Backend.Redis.Addr, cfg.Backend.Redis.Password, cfg.Backend.Redis.DB)
+	default:
+		return nil, fmt.Errorf("unknown backend %s", cfg.Backend.Type)
+	}
+}
+
+// NewReadOnly returns the backend configured in cfg for reading the records. Nothing is created for a disk backend
+// and a bolt file is opened read-only. The lease of a redis backend is taken like by New, so no store can start while
+// the records are read.
+func NewReadOnly(cfg *config.Config, logger log.Logger) (Backend, error) {
+	switch cfg.Backend.Type {
+	case "", "disk":
+		return &Disk{root: cfg.Datapath, log: logger}, nil
+	case "bolt":
+		return NewBoltReadOnly(boltPath(cfg))
+	default:
+		return New(cfg, logger)
+	}
+}
+
+// boltPath returns the path of the bolt file, it defaults to store.db in the data path.
+func boltPath(cfg *config.Config) string {
+	if cfg.Backend.Bolt.Path == "" {
+		return filepath.Join(cfg.Datapath, "store.db")
+	}
+	return cfg.Backend.Bolt.Path
+}
+
+// Walk calls fn for every key of every table of a backend.
+func Walk(b Backend, fn func(database, table, key string) error) error {
+	databases, err := b.Databases()
+	if err != nil {
+		return err
+	}
+	for _, database := range databases {
+		tables, err := b.Tables(database)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			keys, err := b.Keys(database, table)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				if err := fn(database, table, key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Copy copies all keys from one backend to another and returns the number of copied keys. Existing keys of the
+// destination are overwritten.
+func Copy(from, to Backend) (int, error) {
+	copied := 0
+	err := Walk(from, func(database, table, key string) error {
+		value, err := from.Get(database, table, key)
+		if err != nil {
+			if IsNotFoundErr(err) {
+				// deleted since it was listed
+				return nil
+			}
+			return err
+		}
+		if err := to.Put(database, table, key, value); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/store/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBackends returns an empty backend of every type.
+func newTestBackends(t *testing.T) map[string]Backend {
+	root, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	backends := map[string]Backend{}
+	for _, typ := range []string{"disk", "bolt", "redis"} {
+		cfg := config.New()
+		cfg.Datapath = filepath.Join(root, typ)
+		cfg.Backend.Type = typ
+		cfg.Backend.Redis.Addr = mr.Addr()
+		b, err := New(cfg, log.NewLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { b.Close() })
+		backends[typ] = b
+	}
+	return backends
+}
+
+func TestBackend(t *testing.T) {
+	for typ, b := range newTestBackends(t) {
+		b := b
+		t.Run(typ, func(t *testing.T) {
+			_, err := b.Get("proxy", "accounts", "einstein")
+			assert.True(t, IsNotFoundErr(err))
+			assert.True(t, IsNotFoundErr(b.Delete("proxy", "accounts", "einstein")))
+
+			keys := []string{"marie", "einstein", "nested/key", "..", "Zoe", "ä"}
+			for _, key := range keys {
+				assert.NoError(t, b.Put("proxy", "accounts", key, []byte("v:"+key)))
+			}
+			assert.NoError(t, b.Put("proxy", "sessions", "s1", []byte("session")))
+			assert.NoError(t, b.Put("ocs", "signing-keys", "einstein", []byte("key")))
+
+			value, err := b.Get("proxy", "accounts", "nested/key")
+			assert.NoError(t, err)
+			assert.Equal(t, "v:nested/key", string(value))
+
+			assert.NoError(t, b.Put("proxy", "accounts", "marie", []byte("changed")))
+			value, err = b.Get("proxy", "accounts", "marie")
+			assert.NoError(t, err)
+			assert.Equal(t, "changed", string(value))
+
+			listed, err := b.Keys("proxy", "accounts")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"..", "Zoe", "einstein", "marie", "nested/key", "ä"}, listed)
+
+			listed, err = b.Keys("proxy", "missing")
+			assert.NoError(t, err)
+			assert.Empty(t, listed)
+
+			databases, err := b.Databases()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"ocs", "proxy"}, databases)
+
+			tables, err := b.Tables("proxy")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"accounts", "sessions"}, tables)
+
+			tables, err = b.Tables("missing")
+			assert.NoError(t, err)
+			assert.Empty(t, tables)
+
+			assert.NoError(t, b.Delete("proxy", "accounts", "einstein"))
+			_, err = b.Get("proxy", "accounts", "einstein")
+			assert.True(t, IsNotFoundErr(err))
+			listed, err = b.Keys("proxy", "accounts")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"..", "Zoe", "marie", "nested/key", "ä"}, listed)
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	backends := newTestBackends(t)
+	from := backends["disk"]
+	for _, key := range []string{"einstein", "marie", "nested/key"} {
+		assert.NoError(t, from.Put("proxy", "accounts", key, []byte("v:"+key)))
+	}
+	assert.NoError(t, from.Put("ocs", "signing-keys", "einstein", []byte("key")))
+
+	for _, typ := range []string{"bolt", "redis"} {
+		to := backends[typ]
+		t.Run(typ, func(t *testing.T) {
+			assert.NoError(t, to.Put("proxy", "accounts", "marie", []byte("old")))
+
+			n, err := Copy(from, to)
+			assert.NoError(t, err)
+			assert.Equal(t, 4, n)
+
+			var copied []string
+			err = Walk(to, func(database, table, key string) error {
+				value, err := to.Get(database, table, key)
+				copied = append(copied, database+"/"+table+"/"+key+"="+string(value))
+				return err
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{
+				"ocs/signing-keys/einstein=key",
+				"proxy/accounts/einstein=v:einstein",
+				"proxy/accounts/marie=v:marie",
+				"proxy/accounts/nested/key=v:nested/key",
+			}, copied)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	backends := newTestBackends(t)
+	for _, typ := range []string{"bolt", "redis"} {
+		b := backends[typ].(Transactional)
+		t.Run(typ, func(t *testing.T) {
+			assert.NoError(t, backends[typ].Put("proxy", "accounts", "marie", []byte("old")))
+
+			err := b.Apply("proxy", "accounts", []Change{
+				{Key: "einstein", Value: []byte("new")},
+				{Key: "marie"},
+				{Key: "missing"},
+			})
+			assert.NoError(t, err)
+
+			value, err := backends[typ].Get("proxy", "accounts", "einstein")
+			assert.NoError(t, err)
+			assert.Equal(t, "new", string(value))
+			_, err = backends[typ].Get("proxy", "accounts", "marie")
+			assert.True(t, IsNotFoundErr(err))
+			keys, err := backends[typ].Keys("proxy", "accounts")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"einstein"}, keys)
+		})
+	}
+	_, ok := backends["disk"].(Transactional)
+	assert.False(t, ok)
+}
+
+func TestUnknownBackend(t *testing.T) {
+	cfg := config.New()
+	cfg.Backend.Type = "etcd"
+	_, err := New(cfg, log.NewLogger())
+	assert.Error(t, err)
+}
+
+func TestRedisLease(t *testing.T) {
+	ttl := redisLeaseTTL
+	redisLeaseTTL = 300 * time.Millisecond
+	t.Cleanup(func() { redisLeaseTTL = ttl })
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	first, err := NewRedis(mr.Addr(), "", 0)
+	assert.NoError(t, err)
+
+	// a second instance can't use the database, even after the lease was renewed
+	_, err = NewRedis(mr.Addr(), "", 0)
+	assert.Equal(t, ErrLeaseHeld, err)
+
+	// the lease is released on close
+	assert.NoError(t, first.Close())
+	second, err := NewRedis(mr.Addr(), "", 0)
+	assert.NoError(t, err)
+	assert.NoError(t, second.Put("proxy", "accounts", "einstein", []byte("v")))
+
+	// writes fail once the lease was taken over
+	assert.NoError(t, mr.Set(leaseKey(), "other"))
+	time.Sleep(redisLeaseTTL)
+	assert.Equal(t, ErrLeaseLost, second.Put("proxy", "accounts", "einstein", []byte("v")))
+	assert.Equal(t, ErrLeaseLost, second.Delete("proxy", "accounts", "einstein"))
+	value, err := second.Get("proxy", "accounts", "einstein")
+	assert.NoError(t, err)
+	assert.Equal(t, "v", string(value))
+	assert.NoError(t, second.Close())
+	got, err := mr.Get(leaseKey())
+	assert.NoError(t, err)
+	assert.Equal(t, "other", got)
+}
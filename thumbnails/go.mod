@@ -8,6 +8,9 @@ require (
 	contrib.go.opencensus.io/exporter/zipkin v0.1.1
 	github.com/UnnoTed/fileb0x v1.1.4
 	github.com/cespare/reflex v0.2.0
+	github.com/chai2010/webp v1.1.0
+	github.com/cs3org/go-cs3apis v0.0.0-20201118090759-87929f5bae21
+	github.com/cs3org/reva v1.5.2-0.20210125114636-0c10b333ee69
 	github.com/go-test/deep v1.0.2-0.20181118220953-042da051cf31 // indirect
 	github.com/gogo/protobuf v1.3.1 // indirect
 	github.com/golang/protobuf v1.4.3
@@ -31,8 +34,9 @@ require (
 	go.opencensus.io v0.22.5
 	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a // indirect
 	golang.org/x/image v0.0.0-20190802002840-cff245a6509b
+	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
 	google.golang.org/genproto v0.0.0-20200918140846-d0d605568037 // indirect
-	gopkg.in/square/go-jose.v2 v2.5.1
+	google.golang.org/grpc v1.33.2
 )
 
 replace (
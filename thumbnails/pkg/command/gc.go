@@ -0,0 +1,36 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/micro/cli/v2"
+	"github.com/owncloud/ocis/thumbnails/pkg/config"
+	"github.com/owncloud/ocis/thumbnails/pkg/flagset"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/storage"
+)
+
+// GC is the entrypoint for the gc command.
+func GC(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "gc",
+		Usage: "Remove thumbnails exceeding the max age or max size and clean up the filesystem storage",
+		Flags: flagset.GCWithConfig(cfg),
+		Before: func(c *cli.Context) error {
+			return ParseConfig(c, cfg)
+		},
+		Action: func(c *cli.Context) error {
+			logger := NewLogger(cfg)
+
+			res, err := storage.NewFileSystemStorage(cfg.Thumbnail.FileSystemStorage, logger).GC(time.Now())
+			if err != nil {
+				fmt.Println(fmt.Errorf("could not clean up thumbnails %w", err))
+				return err
+			}
+
+			fmt.Printf("removed %d thumbnails (%d bytes) and %d dangling links\n", res.Removed, res.RemovedBytes, res.DanglingLinks)
+			fmt.Printf("%d thumbnails (%d bytes) remaining\n", res.Files, res.Bytes)
+			return nil
+		},
+	}
+}
@@ -39,6 +39,7 @@ func Execute() error {
 		Commands: []*cli.Command{
 			Server(cfg),
 			Health(cfg),
+			GC(cfg),
 			PrintVersion(cfg),
 		},
 	}
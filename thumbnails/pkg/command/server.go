@@ -31,6 +31,7 @@ func Server(cfg *config.Config) *cli.Command {
 		Flags: flagset.ServerWithConfig(cfg),
 		Before: func(c *cli.Context) error {
 			cfg.Thumbnail.Resolutions = c.StringSlice("thumbnail-resolution")
+			cfg.Thumbnail.Converters = c.StringSlice("thumbnail-converter")
 
 			return ParseConfig(c, cfg)
 		},
@@ -32,19 +32,32 @@ type Tracing struct {
 	Service   string
 }
 
+// TokenManager is the config for using the reva token manager
+type TokenManager struct {
+	JWTSecret string
+}
+
 // Config combines all available configuration parts.
 type Config struct {
-	File      string
-	Log       Log
-	Debug     Debug
-	Server    Server
-	Tracing   Tracing
-	Thumbnail Thumbnail
+	File         string
+	Log          Log
+	Debug        Debug
+	Server       Server
+	Tracing      Tracing
+	TokenManager TokenManager
+	Thumbnail    Thumbnail
 }
 
 // FileSystemStorage defines the available filesystem storage configuration.
 type FileSystemStorage struct {
 	RootDirectory string
+	// MaxSize limits the total size of all thumbnails in bytes, the least recently used thumbnails are removed
+	// first. 0 disables the limit.
+	MaxSize int64
+	// MaxAge in seconds removes thumbnails that were not accessed for the given time. 0 disables it.
+	MaxAge int
+	// GCInterval in seconds defines how often the janitor cleans up the storage. 0 disables the janitor.
+	GCInterval int
 }
 
 // WebDavSource defines the available webdav source configuration.
@@ -53,16 +66,55 @@ type WebDavSource struct {
 	Insecure bool
 }
 
+// CS3Source defines the available cs3 source configuration.
+type CS3Source struct {
+	GatewayAddress string
+	// Namespace the file paths of the requests are relative to.
+	Namespace string
+	Insecure  bool
+}
+
 // FileSystemSource defines the available filesystem source configuration.
 type FileSystemSource struct {
 	BasePath string
 }
 
+// Pregenerate defines the available configuration for generating the thumbnails of uploaded files in advance.
+type Pregenerate struct {
+	Enabled bool
+	// Workers limits the number of files whose thumbnails are generated concurrently.
+	Workers int
+	// MaxQueue limits the number of uploaded files waiting for a worker, further events are blocked until there is
+	// room in the queue.
+	MaxQueue int
+}
+
 // Thumbnail defines the available thumbnail related configuration.
 type Thumbnail struct {
-	Resolutions       []string
+	Resolutions []string
+	// JpegQuality ranges from 1 to 100, higher values produce larger thumbnails.
+	JpegQuality int
+	// Converters are external commands generating the images of files which can't be decoded, in the form
+	// `<mimetype>=<command>`.
+	Converters []string
+	// ConverterTimeout in seconds after which a converter is killed.
+	ConverterTimeout int
+	// MaxInputSize in bytes of the source files, bigger files are rejected.
+	MaxInputSize int64
+	// MaxInputPixels of the source images, bigger images are rejected before they are decoded.
+	MaxInputPixels int64
+	// Workers limits the number of thumbnails generated concurrently. 0 uses the number of CPUs.
+	Workers int
+	// MaxQueue limits the number of requests waiting for a worker, further requests are rejected.
+	MaxQueue int
+	// RequestTimeout in seconds after which the generation of a thumbnail is cancelled.
+	RequestTimeout int
+	// Source the files are downloaded from, either `webdav` or `cs3`.
+	Source            string
 	FileSystemStorage FileSystemStorage
 	WebDavSource      WebDavSource
+	CS3Source         CS3Source
+	Pregenerate       Pregenerate
 }
 
 // New initializes a new configuration with or without defaults.
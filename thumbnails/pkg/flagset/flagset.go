@@ -146,6 +146,41 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_ROOT"},
 			Destination: &cfg.Thumbnail.FileSystemStorage.RootDirectory,
 		},
+		&cli.Int64Flag{
+			Name:        "filesystemstorage-max-size",
+			Value:       0,
+			Usage:       "Max total size of the stored thumbnails in bytes, 0 disables the limit",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_MAX_SIZE"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.MaxSize,
+		},
+		&cli.IntFlag{
+			Name:        "filesystemstorage-max-age",
+			Value:       0,
+			Usage:       "Remove thumbnails which were not accessed for the given seconds, 0 disables the limit",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_MAX_AGE"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.MaxAge,
+		},
+		&cli.IntFlag{
+			Name:        "filesystemstorage-gc-interval",
+			Value:       3600,
+			Usage:       "Interval in seconds in which the storage is cleaned up, 0 disables the cleanup",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_GC_INTERVAL"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.GCInterval,
+		},
+		&cli.StringFlag{
+			Name:        "jwt-secret",
+			Value:       "Pive-Fumkiu4",
+			Usage:       "Used to dismantle the access token, should equal reva's jwt-secret",
+			EnvVars:     []string{"THUMBNAILS_JWT_SECRET"},
+			Destination: &cfg.TokenManager.JWTSecret,
+		},
+		&cli.StringFlag{
+			Name:        "source",
+			Value:       "webdav",
+			Usage:       "Source of the files, either webdav or cs3",
+			EnvVars:     []string{"THUMBNAILS_SOURCE"},
+			Destination: &cfg.Thumbnail.Source,
+		},
 		&cli.StringFlag{
 			Name:        "webdavsource-baseurl",
 			Value:       "https://localhost:9200/remote.php/webdav/",
@@ -160,12 +195,142 @@ func ServerWithConfig(cfg *config.Config) []cli.Flag {
 			EnvVars:     []string{"THUMBNAILS_WEBDAVSOURCE_INSECURE"},
 			Destination: &cfg.Thumbnail.WebDavSource.Insecure,
 		},
+		&cli.StringFlag{
+			Name:        "cs3source-gateway-addr",
+			Value:       "127.0.0.1:9142",
+			Usage:       "Address of the reva gateway",
+			EnvVars:     []string{"THUMBNAILS_CS3SOURCE_GATEWAY_ADDR"},
+			Destination: &cfg.Thumbnail.CS3Source.GatewayAddress,
+		},
+		&cli.StringFlag{
+			Name:        "cs3source-namespace",
+			Value:       "/home",
+			Usage:       "Namespace the file paths are relative to",
+			EnvVars:     []string{"THUMBNAILS_CS3SOURCE_NAMESPACE"},
+			Destination: &cfg.Thumbnail.CS3Source.Namespace,
+		},
+		&cli.BoolFlag{
+			Name:        "cs3source-insecure",
+			Value:       false,
+			Usage:       "Whether to skip certificate checks of the data gateway",
+			EnvVars:     []string{"THUMBNAILS_CS3SOURCE_INSECURE"},
+			Destination: &cfg.Thumbnail.CS3Source.Insecure,
+		},
 		&cli.StringSliceFlag{
 			Name:    "thumbnail-resolution",
 			Value:   cli.NewStringSlice("16x16", "32x32", "64x64", "128x128", "1920x1080", "3840x2160", "7680x4320"),
 			Usage:   "--thumbnail-resolution 16x16 [--thumbnail-resolution 32x32]",
 			EnvVars: []string{"THUMBNAILS_RESOLUTIONS"},
 		},
+		&cli.IntFlag{
+			Name:        "thumbnail-jpeg-quality",
+			Value:       90,
+			Usage:       "Quality of jpg thumbnails, ranges from 1 to 100",
+			EnvVars:     []string{"THUMBNAILS_JPEG_QUALITY"},
+			Destination: &cfg.Thumbnail.JpegQuality,
+		},
+		&cli.StringSliceFlag{
+			Name:    "thumbnail-converter",
+			Usage:   `--thumbnail-converter "application/pdf=pdftoppm -png -singlefile -scale-to 1920 {input}" [--thumbnail-converter "image/svg+xml=rsvg-convert"]`,
+			EnvVars: []string{"THUMBNAILS_CONVERTERS"},
+		},
+		&cli.IntFlag{
+			Name:        "thumbnail-converter-timeout",
+			Value:       30,
+			Usage:       "Seconds after which a converter is killed",
+			EnvVars:     []string{"THUMBNAILS_CONVERTER_TIMEOUT"},
+			Destination: &cfg.Thumbnail.ConverterTimeout,
+		},
+		&cli.Int64Flag{
+			Name:        "thumbnail-max-input-size",
+			Value:       50 * 1024 * 1024,
+			Usage:       "Max size of the source files in bytes",
+			EnvVars:     []string{"THUMBNAILS_MAX_INPUT_SIZE"},
+			Destination: &cfg.Thumbnail.MaxInputSize,
+		},
+		&cli.Int64Flag{
+			Name:        "thumbnail-max-input-pixels",
+			Value:       50000000,
+			Usage:       "Max number of pixels of the source images",
+			EnvVars:     []string{"THUMBNAILS_MAX_INPUT_PIXELS"},
+			Destination: &cfg.Thumbnail.MaxInputPixels,
+		},
+		&cli.IntFlag{
+			Name:        "thumbnail-workers",
+			Value:       0,
+			Usage:       "Number of thumbnails generated concurrently, 0 uses the number of CPUs",
+			EnvVars:     []string{"THUMBNAILS_WORKERS"},
+			Destination: &cfg.Thumbnail.Workers,
+		},
+		&cli.IntFlag{
+			Name:        "thumbnail-max-queue",
+			Value:       100,
+			Usage:       "Max number of requests waiting for a worker, further requests are rejected",
+			EnvVars:     []string{"THUMBNAILS_MAX_QUEUE"},
+			Destination: &cfg.Thumbnail.MaxQueue,
+		},
+		&cli.IntFlag{
+			Name:        "thumbnail-request-timeout",
+			Value:       30,
+			Usage:       "Seconds after which the generation of a thumbnail is cancelled",
+			EnvVars:     []string{"THUMBNAILS_REQUEST_TIMEOUT"},
+			Destination: &cfg.Thumbnail.RequestTimeout,
+		},
+		&cli.BoolFlag{
+			Name:        "pregenerate",
+			Value:       false,
+			Usage:       "Generate the thumbnails of uploaded files in advance, requires the cs3 source",
+			EnvVars:     []string{"THUMBNAILS_PREGENERATE"},
+			Destination: &cfg.Thumbnail.Pregenerate.Enabled,
+		},
+		&cli.IntFlag{
+			Name:        "pregenerate-workers",
+			Value:       1,
+			Usage:       "Number of uploaded files whose thumbnails are generated concurrently",
+			EnvVars:     []string{"THUMBNAILS_PREGENERATE_WORKERS"},
+			Destination: &cfg.Thumbnail.Pregenerate.Workers,
+		},
+		&cli.IntFlag{
+			Name:        "pregenerate-max-queue",
+			Value:       1000,
+			Usage:       "Max number of uploaded files waiting for their thumbnails to be generated",
+			EnvVars:     []string{"THUMBNAILS_PREGENERATE_MAX_QUEUE"},
+			Destination: &cfg.Thumbnail.Pregenerate.MaxQueue,
+		},
+	}
+}
+
+// GCWithConfig applies cfg to the gc flagset
+func GCWithConfig(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config-file",
+			Value:       "",
+			Usage:       "Path to config file",
+			EnvVars:     []string{"THUMBNAILS_CONFIG_FILE"},
+			Destination: &cfg.File,
+		},
+		&cli.StringFlag{
+			Name:        "filesystemstorage-root",
+			Value:       filepath.Join(os.TempDir(), "ocis-thumbnails/"),
+			Usage:       "Root path of the filesystem storage directory",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_ROOT"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.RootDirectory,
+		},
+		&cli.Int64Flag{
+			Name:        "filesystemstorage-max-size",
+			Value:       0,
+			Usage:       "Max total size of the stored thumbnails in bytes, 0 disables the limit",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_MAX_SIZE"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.MaxSize,
+		},
+		&cli.IntFlag{
+			Name:        "filesystemstorage-max-age",
+			Value:       0,
+			Usage:       "Remove thumbnails which were not accessed for the given seconds, 0 disables the limit",
+			EnvVars:     []string{"THUMBNAILS_FILESYSTEMSTORAGE_MAX_AGE"},
+			Destination: &cfg.Thumbnail.FileSystemStorage.MaxAge,
+		},
 	}
 }
 
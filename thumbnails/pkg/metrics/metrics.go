@@ -16,6 +16,13 @@ type Metrics struct {
 	Latency   *prometheus.SummaryVec
 	Duration  *prometheus.HistogramVec
 	BuildInfo *prometheus.GaugeVec
+
+	CacheSize      *prometheus.GaugeVec
+	CacheHits      *prometheus.CounterVec
+	CacheMisses    *prometheus.CounterVec
+	CacheEvictions *prometheus.CounterVec
+
+	PregenerateBacklog *prometheus.GaugeVec
 }
 
 // New initializes the available metrics.
@@ -45,6 +52,36 @@ func New() *Metrics {
 			Name:      "build_info",
 			Help:      "Build information",
 		}, []string{"version"}),
+		CacheSize: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "cache_size_bytes",
+			Help:      "Size of all stored thumbnails in bytes, updated by the janitor",
+		}, []string{}),
+		CacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "cache_hits_total",
+			Help:      "How many thumbnails were loaded from the storage",
+		}, []string{}),
+		CacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "cache_misses_total",
+			Help:      "How many thumbnails were not found in the storage",
+		}, []string{}),
+		CacheEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "cache_evictions_total",
+			Help:      "How many thumbnails were removed by the janitor",
+		}, []string{}),
+		PregenerateBacklog: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "pregenerate_backlog",
+			Help:      "How many uploaded files are waiting for their thumbnails to be generated",
+		}, []string{}),
 	}
 
 	_ = prometheus.Register(
@@ -63,5 +100,25 @@ func New() *Metrics {
 		m.BuildInfo,
 	)
 
+	_ = prometheus.Register(
+		m.CacheSize,
+	)
+
+	_ = prometheus.Register(
+		m.CacheHits,
+	)
+
+	_ = prometheus.Register(
+		m.CacheMisses,
+	)
+
+	_ = prometheus.Register(
+		m.CacheEvictions,
+	)
+
+	_ = prometheus.Register(
+		m.PregenerateBacklog,
+	)
+
 	return m
 }
@@ -23,18 +23,24 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 type GetRequest_FileType int32
 
 const (
-	GetRequest_PNG GetRequest_FileType = 0
-	GetRequest_JPG GetRequest_FileType = 1
+	GetRequest_PNG  GetRequest_FileType = 0
+	GetRequest_JPG  GetRequest_FileType = 1
+	GetRequest_GIF  GetRequest_FileType = 2
+	GetRequest_WEBP GetRequest_FileType = 3
 )
 
 var GetRequest_FileType_name = map[int32]string{
 	0: "PNG",
 	1: "JPG",
+	2: "GIF",
+	3: "WEBP",
 }
 
 var GetRequest_FileType_value = map[string]int32{
-	"PNG": 0,
-	"JPG": 1,
+	"PNG":  0,
+	"JPG":  1,
+	"GIF":  2,
+	"WEBP": 3,
 }
 
 func (x GetRequest_FileType) String() string {
@@ -45,16 +51,79 @@ func (GetRequest_FileType) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_e354cb4f8a62b6c2, []int{0, 0}
 }
 
+type GetRequest_Mode int32
+
+const (
+	GetRequest_FIT   GetRequest_Mode = 0
+	GetRequest_FILL  GetRequest_Mode = 1
+	GetRequest_SMART GetRequest_Mode = 2
+	GetRequest_EXACT GetRequest_Mode = 3
+)
+
+var GetRequest_Mode_name = map[int32]string{
+	0: "FIT",
+	1: "FILL",
+	2: "SMART",
+	3: "EXACT",
+}
+
+var GetRequest_Mode_value = map[string]int32{
+	"FIT":   0,
+	"FILL":  1,
+	"SMART": 2,
+	"EXACT": 3,
+}
+
+func (x GetRequest_Mode) String() string {
+	return proto.EnumName(GetRequest_Mode_name, int32(x))
+}
+
+func (GetRequest_Mode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_e354cb4f8a62b6c2, []int{0, 1}
+}
+
+type GetRequest_Resampling int32
+
+const (
+	GetRequest_APPROX_BILINEAR GetRequest_Resampling = 0
+	GetRequest_CATMULL_ROM     GetRequest_Resampling = 1
+	GetRequest_LANCZOS         GetRequest_Resampling = 2
+)
+
+var GetRequest_Resampling_name = map[int32]string{
+	0: "APPROX_BILINEAR",
+	1: "CATMULL_ROM",
+	2: "LANCZOS",
+}
+
+var GetRequest_Resampling_value = map[string]int32{
+	"APPROX_BILINEAR": 0,
+	"CATMULL_ROM":     1,
+	"LANCZOS":         2,
+}
+
+func (x GetRequest_Resampling) String() string {
+	return proto.EnumName(GetRequest_Resampling_name, int32(x))
+}
+
+func (GetRequest_Resampling) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_e354cb4f8a62b6c2, []int{0, 2}
+}
+
 type GetRequest struct {
-	Filepath             string              `protobuf:"bytes,1,opt,name=filepath,proto3" json:"filepath,omitempty"`
-	Filetype             GetRequest_FileType `protobuf:"varint,2,opt,name=filetype,proto3,enum=com.owncloud.ocis.thumbnails.v0.GetRequest_FileType" json:"filetype,omitempty"`
-	Etag                 string              `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
-	Width                int32               `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
-	Height               int32               `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
-	Authorization        string              `protobuf:"bytes,6,opt,name=authorization,proto3" json:"authorization,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+	Filepath             string                `protobuf:"bytes,1,opt,name=filepath,proto3" json:"filepath,omitempty"`
+	Filetype             GetRequest_FileType   `protobuf:"varint,2,opt,name=filetype,proto3,enum=com.owncloud.ocis.thumbnails.v0.GetRequest_FileType" json:"filetype,omitempty"`
+	Etag                 string                `protobuf:"bytes,3,opt,name=etag,proto3" json:"etag,omitempty"`
+	Width                int32                 `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	Height               int32                 `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	Authorization        string                `protobuf:"bytes,6,opt,name=authorization,proto3" json:"authorization,omitempty"`
+	Mimetype             string                `protobuf:"bytes,7,opt,name=mimetype,proto3" json:"mimetype,omitempty"`
+	Mode                 GetRequest_Mode       `protobuf:"varint,8,opt,name=mode,proto3,enum=com.owncloud.ocis.thumbnails.v0.GetRequest_Mode" json:"mode,omitempty"`
+	Resampling           GetRequest_Resampling `protobuf:"varint,9,opt,name=resampling,proto3,enum=com.owncloud.ocis.thumbnails.v0.GetRequest_Resampling" json:"resampling,omitempty"`
+	AccessToken          string                `protobuf:"bytes,10,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
 }
 
 func (m *GetRequest) Reset()         { *m = GetRequest{} }
@@ -124,6 +193,34 @@ func (m *GetRequest) GetAuthorization() string {
 	return ""
 }
 
+func (m *GetRequest) GetMimetype() string {
+	if m != nil {
+		return m.Mimetype
+	}
+	return ""
+}
+
+func (m *GetRequest) GetMode() GetRequest_Mode {
+	if m != nil {
+		return m.Mode
+	}
+	return GetRequest_FIT
+}
+
+func (m *GetRequest) GetResampling() GetRequest_Resampling {
+	if m != nil {
+		return m.Resampling
+	}
+	return GetRequest_APPROX_BILINEAR
+}
+
+func (m *GetRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
 type GetResponse struct {
 	Thumbnail            []byte   `protobuf:"bytes,1,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
 	Mimetype             string   `protobuf:"bytes,2,opt,name=mimetype,proto3" json:"mimetype,omitempty"`
@@ -171,34 +268,182 @@ func (m *GetResponse) GetMimetype() string {
 	return ""
 }
 
+type GetThumbnailsRequest struct {
+	Requests             []*GetRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	Authorization        string        `protobuf:"bytes,2,opt,name=authorization,proto3" json:"authorization,omitempty"`
+	AccessToken          string        `protobuf:"bytes,3,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *GetThumbnailsRequest) Reset()         { *m = GetThumbnailsRequest{} }
+func (m *GetThumbnailsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetThumbnailsRequest) ProtoMessage()    {}
+func (*GetThumbnailsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e354cb4f8a62b6c2, []int{2}
+}
+
+func (m *GetThumbnailsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetThumbnailsRequest.Unmarshal(m, b)
+}
+func (m *GetThumbnailsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetThumbnailsRequest.Marshal(b, m, deterministic)
+}
+func (m *GetThumbnailsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetThumbnailsRequest.Merge(m, src)
+}
+func (m *GetThumbnailsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetThumbnailsRequest.Size(m)
+}
+func (m *GetThumbnailsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetThumbnailsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetThumbnailsRequest proto.InternalMessageInfo
+
+func (m *GetThumbnailsRequest) GetRequests() []*GetRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+func (m *GetThumbnailsRequest) GetAuthorization() string {
+	if m != nil {
+		return m.Authorization
+	}
+	return ""
+}
+
+func (m *GetThumbnailsRequest) GetAccessToken() string {
+	if m != nil {
+		return m.AccessToken
+	}
+	return ""
+}
+
+type GetThumbnailsResponse struct {
+	Index                int32    `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Thumbnail            []byte   `protobuf:"bytes,2,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
+	Mimetype             string   `protobuf:"bytes,3,opt,name=mimetype,proto3" json:"mimetype,omitempty"`
+	Status               int32    `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	Error                string   `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetThumbnailsResponse) Reset()         { *m = GetThumbnailsResponse{} }
+func (m *GetThumbnailsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetThumbnailsResponse) ProtoMessage()    {}
+func (*GetThumbnailsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e354cb4f8a62b6c2, []int{3}
+}
+
+func (m *GetThumbnailsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetThumbnailsResponse.Unmarshal(m, b)
+}
+func (m *GetThumbnailsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetThumbnailsResponse.Marshal(b, m, deterministic)
+}
+func (m *GetThumbnailsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetThumbnailsResponse.Merge(m, src)
+}
+func (m *GetThumbnailsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetThumbnailsResponse.Size(m)
+}
+func (m *GetThumbnailsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetThumbnailsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetThumbnailsResponse proto.InternalMessageInfo
+
+func (m *GetThumbnailsResponse) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *GetThumbnailsResponse) GetThumbnail() []byte {
+	if m != nil {
+		return m.Thumbnail
+	}
+	return nil
+}
+
+func (m *GetThumbnailsResponse) GetMimetype() string {
+	if m != nil {
+		return m.Mimetype
+	}
+	return ""
+}
+
+func (m *GetThumbnailsResponse) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *GetThumbnailsResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("com.owncloud.ocis.thumbnails.v0.GetRequest_FileType", GetRequest_FileType_name, GetRequest_FileType_value)
+	proto.RegisterEnum("com.owncloud.ocis.thumbnails.v0.GetRequest_Mode", GetRequest_Mode_name, GetRequest_Mode_value)
+	proto.RegisterEnum("com.owncloud.ocis.thumbnails.v0.GetRequest_Resampling", GetRequest_Resampling_name, GetRequest_Resampling_value)
 	proto.RegisterType((*GetRequest)(nil), "com.owncloud.ocis.thumbnails.v0.GetRequest")
 	proto.RegisterType((*GetResponse)(nil), "com.owncloud.ocis.thumbnails.v0.GetResponse")
+	proto.RegisterType((*GetThumbnailsRequest)(nil), "com.owncloud.ocis.thumbnails.v0.GetThumbnailsRequest")
+	proto.RegisterType((*GetThumbnailsResponse)(nil), "com.owncloud.ocis.thumbnails.v0.GetThumbnailsResponse")
 }
 
 func init() { proto.RegisterFile("pkg/proto/v0/thumbnails.proto", fileDescriptor_e354cb4f8a62b6c2) }
 
 var fileDescriptor_e354cb4f8a62b6c2 = []byte{
-	// 309 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x4f, 0x4f, 0xc2, 0x40,
-	0x10, 0xc5, 0x2d, 0x50, 0xfe, 0x8c, 0x68, 0xc8, 0xc4, 0x98, 0x86, 0x60, 0x24, 0xc4, 0x03, 0x89,
-	0x66, 0x21, 0xe8, 0x27, 0xf0, 0x60, 0x13, 0x0f, 0x86, 0x54, 0x4e, 0xde, 0x4a, 0x19, 0xd9, 0x8d,
-	0x6d, 0x77, 0xa5, 0x53, 0x08, 0x26, 0x26, 0x7e, 0x74, 0xc3, 0x22, 0x45, 0x4f, 0xea, 0x69, 0xe7,
-	0xfd, 0x76, 0xf2, 0x66, 0xf6, 0x2d, 0x9c, 0x99, 0x97, 0xf9, 0xc0, 0x2c, 0x34, 0xeb, 0xc1, 0x72,
-	0x38, 0x60, 0x99, 0x27, 0xd3, 0x34, 0x54, 0x71, 0x26, 0x2c, 0xc3, 0xf3, 0x48, 0x27, 0x42, 0xaf,
-	0xd2, 0x28, 0xd6, 0xf9, 0x4c, 0xe8, 0x48, 0x65, 0xe2, 0x5b, 0xcf, 0x72, 0xd8, 0xfb, 0x28, 0x01,
-	0xf8, 0xc4, 0x01, 0xbd, 0xe6, 0x94, 0x31, 0xb6, 0xa1, 0xfe, 0xac, 0x62, 0x32, 0x21, 0x4b, 0xcf,
-	0xe9, 0x3a, 0xfd, 0x46, 0x50, 0x68, 0x1c, 0x6f, 0xef, 0x78, 0x6d, 0xc8, 0x2b, 0x75, 0x9d, 0xfe,
-	0xf1, 0xe8, 0x46, 0xfc, 0x62, 0x2f, 0xf6, 0xd6, 0xe2, 0x4e, 0xc5, 0x34, 0x59, 0x1b, 0x0a, 0x0a,
-	0x17, 0x44, 0xa8, 0x10, 0x87, 0x73, 0xaf, 0x6c, 0x27, 0xd9, 0x1a, 0x4f, 0xc0, 0x5d, 0xa9, 0x19,
-	0x4b, 0xaf, 0xd2, 0x75, 0xfa, 0x6e, 0xb0, 0x15, 0x78, 0x0a, 0x55, 0x49, 0x6a, 0x2e, 0xd9, 0x73,
-	0x2d, 0xfe, 0x52, 0x78, 0x01, 0x47, 0x61, 0xce, 0x52, 0x2f, 0xd4, 0x5b, 0xc8, 0x4a, 0xa7, 0x5e,
-	0xd5, 0x5a, 0xfd, 0x84, 0xbd, 0x0e, 0xd4, 0x77, 0xd3, 0xb1, 0x06, 0xe5, 0xf1, 0x83, 0xdf, 0x3a,
-	0xd8, 0x14, 0xf7, 0x63, 0xbf, 0xe5, 0xf4, 0x7c, 0x38, 0xb4, 0x6b, 0x66, 0x46, 0xa7, 0x19, 0x61,
-	0x07, 0x1a, 0xc5, 0x1b, 0x6c, 0x06, 0xcd, 0x60, 0x0f, 0x36, 0x01, 0x25, 0x2a, 0xd9, 0x87, 0xd0,
-	0x08, 0x0a, 0x3d, 0x7a, 0x87, 0xd6, 0x64, 0xd7, 0xf8, 0x48, 0x8b, 0xa5, 0x8a, 0x08, 0x15, 0x34,
-	0x7d, 0xe2, 0x02, 0xe3, 0xe5, 0x3f, 0x22, 0x6b, 0x5f, 0xfd, 0xad, 0x79, 0xbb, 0xf8, 0x6d, 0xed,
-	0xc9, 0xb5, 0x9f, 0x3e, 0xad, 0xda, 0xe3, 0xfa, 0x33, 0x00, 0x00, 0xff, 0xff, 0xb1, 0x29, 0x25,
-	0xcc, 0x1c, 0x02, 0x00, 0x00,
+	// 598 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xdd, 0x6e, 0xd3, 0x30,
+	0x14, 0x9e, 0x93, 0xa6, 0x3f, 0xa7, 0x1b, 0xb3, 0xcc, 0x98, 0xa2, 0x09, 0x44, 0xa9, 0xb8, 0xa8,
+	0x04, 0x4a, 0xab, 0x01, 0xbb, 0x45, 0xdd, 0x58, 0xa3, 0xa2, 0x76, 0x8b, 0xbc, 0x00, 0xd3, 0x6e,
+	0xa6, 0x2c, 0x35, 0x8d, 0xb5, 0x26, 0x0e, 0x89, 0xbb, 0x31, 0xae, 0xf6, 0x12, 0xbc, 0x03, 0xcf,
+	0xc5, 0x93, 0xa0, 0x38, 0x5d, 0xb6, 0xae, 0x08, 0xd6, 0xab, 0x9e, 0x73, 0x9c, 0xf3, 0xf9, 0xfb,
+	0xbe, 0x73, 0x5c, 0x78, 0x16, 0x9f, 0x8f, 0xdb, 0x71, 0x22, 0xa4, 0x68, 0x5f, 0x74, 0xda, 0x32,
+	0x98, 0x86, 0x67, 0x91, 0xc7, 0x27, 0xa9, 0xa5, 0x6a, 0xe4, 0xb9, 0x2f, 0x42, 0x4b, 0x5c, 0x46,
+	0xfe, 0x44, 0x4c, 0x47, 0x96, 0xf0, 0x79, 0x6a, 0xdd, 0xf9, 0xe6, 0xa2, 0xd3, 0xfc, 0x5d, 0x02,
+	0xb0, 0x99, 0xa4, 0xec, 0xdb, 0x94, 0xa5, 0x92, 0x6c, 0x41, 0xf5, 0x2b, 0x9f, 0xb0, 0xd8, 0x93,
+	0x81, 0x89, 0x1a, 0xa8, 0x55, 0xa3, 0x45, 0x4e, 0x9c, 0xfc, 0x4c, 0x5e, 0xc5, 0xcc, 0xd4, 0x1a,
+	0xa8, 0xf5, 0x68, 0xfb, 0xad, 0xf5, 0x1f, 0x78, 0xeb, 0x16, 0xda, 0xea, 0xf1, 0x09, 0x73, 0xaf,
+	0x62, 0x46, 0x0b, 0x14, 0x42, 0xa0, 0xc4, 0xa4, 0x37, 0x36, 0x75, 0x75, 0x93, 0x8a, 0xc9, 0x06,
+	0x18, 0x97, 0x7c, 0x24, 0x03, 0xb3, 0xd4, 0x40, 0x2d, 0x83, 0xe6, 0x09, 0xd9, 0x84, 0x72, 0xc0,
+	0xf8, 0x38, 0x90, 0xa6, 0xa1, 0xca, 0xb3, 0x8c, 0xbc, 0x84, 0x35, 0x6f, 0x2a, 0x03, 0x91, 0xf0,
+	0x1f, 0x9e, 0xe4, 0x22, 0x32, 0xcb, 0x0a, 0x6a, 0xbe, 0x98, 0xa9, 0x0a, 0x79, 0x98, 0x33, 0xaf,
+	0xe4, 0xaa, 0x6e, 0x72, 0xf2, 0x01, 0x4a, 0xa1, 0x18, 0x31, 0xb3, 0xaa, 0x14, 0x75, 0x96, 0x51,
+	0x34, 0x14, 0x23, 0x46, 0x55, 0x37, 0xf9, 0x0c, 0x90, 0xb0, 0xd4, 0x0b, 0xe3, 0x09, 0x8f, 0xc6,
+	0x66, 0x4d, 0x61, 0xed, 0x2c, 0x83, 0x45, 0x8b, 0x6e, 0x7a, 0x07, 0x89, 0xbc, 0x80, 0x55, 0xcf,
+	0xf7, 0x59, 0x9a, 0x9e, 0x4a, 0x71, 0xce, 0x22, 0x13, 0x14, 0xfb, 0x7a, 0x5e, 0x73, 0xb3, 0x52,
+	0xb3, 0x0d, 0xd5, 0x1b, 0x6b, 0x49, 0x05, 0x74, 0xe7, 0xc0, 0xc6, 0x2b, 0x59, 0xf0, 0xd1, 0xb1,
+	0x31, 0xca, 0x02, 0xbb, 0xdf, 0xc3, 0x1a, 0xa9, 0x42, 0xe9, 0xcb, 0xfe, 0xae, 0x83, 0xf5, 0x66,
+	0x1b, 0x4a, 0x19, 0xf3, 0xec, 0xa8, 0xd7, 0x77, 0xf1, 0x4a, 0x76, 0xd4, 0xeb, 0x0f, 0x06, 0x18,
+	0x91, 0x1a, 0x18, 0x47, 0xc3, 0x2e, 0x75, 0xb1, 0x96, 0x85, 0xfb, 0xc7, 0xdd, 0x3d, 0x17, 0xeb,
+	0xcd, 0xf7, 0x00, 0xb7, 0xf4, 0xc8, 0x63, 0x58, 0xef, 0x3a, 0x0e, 0x3d, 0x3c, 0x3e, 0xdd, 0xed,
+	0x0f, 0xfa, 0x07, 0xfb, 0x5d, 0x8a, 0x57, 0xc8, 0x3a, 0xd4, 0xf7, 0xba, 0xee, 0xf0, 0xd3, 0x60,
+	0x70, 0x4a, 0x0f, 0x87, 0x18, 0x91, 0x3a, 0x54, 0x06, 0xdd, 0x83, 0xbd, 0x93, 0xc3, 0x23, 0xac,
+	0x35, 0x6d, 0xa8, 0x2b, 0xa9, 0x69, 0x2c, 0xa2, 0x94, 0x91, 0xa7, 0x50, 0x2b, 0x7c, 0x50, 0x5b,
+	0xb6, 0x4a, 0x6f, 0x0b, 0x73, 0xc3, 0xd2, 0xe6, 0x87, 0xd5, 0xfc, 0x85, 0x60, 0xc3, 0x66, 0xd2,
+	0x2d, 0x5c, 0xbc, 0xd9, 0x5b, 0x1b, 0xaa, 0x49, 0x1e, 0xa6, 0x26, 0x6a, 0xe8, 0xad, 0xfa, 0xf6,
+	0xab, 0x25, 0xdc, 0xa7, 0x45, 0xf3, 0xe2, 0x42, 0x69, 0x7f, 0x5b, 0xa8, 0xfb, 0x63, 0xd1, 0x17,
+	0xc7, 0xf2, 0x13, 0xc1, 0x93, 0x7b, 0x54, 0x67, 0xf2, 0x37, 0xc0, 0xe0, 0xd1, 0x88, 0x7d, 0x57,
+	0xd2, 0x0d, 0x9a, 0x27, 0xf3, 0xa6, 0x68, 0xff, 0x32, 0x45, 0xbf, 0xb7, 0xc1, 0x9b, 0x50, 0x4e,
+	0xa5, 0x27, 0xa7, 0xe9, 0xec, 0xc9, 0xcc, 0xb2, 0xec, 0x1e, 0x96, 0x24, 0x22, 0x51, 0x4f, 0xa6,
+	0x46, 0xf3, 0x64, 0xfb, 0x5a, 0x03, 0x5c, 0x90, 0x3a, 0x62, 0xc9, 0x05, 0xf7, 0x19, 0xe1, 0xb0,
+	0x7a, 0x97, 0x2b, 0x59, 0xc6, 0xbc, 0xad, 0xd7, 0x0f, 0xfb, 0x78, 0xa6, 0xfe, 0x1a, 0xc1, 0xda,
+	0x9c, 0x2f, 0xe4, 0xdd, 0x43, 0xfa, 0x17, 0x46, 0xbe, 0xb5, 0xb3, 0x6c, 0x5b, 0x4e, 0xa0, 0x83,
+	0x76, 0x2b, 0x27, 0x86, 0xfa, 0x77, 0x3c, 0x2b, 0xab, 0x9f, 0x37, 0x7f, 0x06, 0x00, 0x53, 0x39,
+	0x2d, 0x44, 0x45, 0x05, 0x00, 0x00,
 }
@@ -35,6 +35,7 @@ var _ server.Option
 
 type ThumbnailService interface {
 	GetThumbnail(ctx context.Context, in *GetRequest, opts ...client.CallOption) (*GetResponse, error)
+	GetThumbnails(ctx context.Context, in *GetThumbnailsRequest, opts ...client.CallOption) (ThumbnailService_GetThumbnailsService, error)
 }
 
 type thumbnailService struct {
@@ -59,15 +60,66 @@ func (c *thumbnailService) GetThumbnail(ctx context.Context, in *GetRequest, opt
 	return out, nil
 }
 
+func (c *thumbnailService) GetThumbnails(ctx context.Context, in *GetThumbnailsRequest, opts ...client.CallOption) (ThumbnailService_GetThumbnailsService, error) {
+	req := c.c.NewRequest(c.name, "ThumbnailService.GetThumbnails", &GetThumbnailsRequest{})
+	stream, err := c.c.Stream(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(in); err != nil {
+		return nil, err
+	}
+	return &thumbnailServiceGetThumbnails{stream}, nil
+}
+
+type ThumbnailService_GetThumbnailsService interface {
+	Context() context.Context
+	SendMsg(interface{}) error
+	RecvMsg(interface{}) error
+	Close() error
+	Recv() (*GetThumbnailsResponse, error)
+}
+
+type thumbnailServiceGetThumbnails struct {
+	stream client.Stream
+}
+
+func (x *thumbnailServiceGetThumbnails) Close() error {
+	return x.stream.Close()
+}
+
+func (x *thumbnailServiceGetThumbnails) Context() context.Context {
+	return x.stream.Context()
+}
+
+func (x *thumbnailServiceGetThumbnails) SendMsg(m interface{}) error {
+	return x.stream.Send(m)
+}
+
+func (x *thumbnailServiceGetThumbnails) RecvMsg(m interface{}) error {
+	return x.stream.Recv(m)
+}
+
+func (x *thumbnailServiceGetThumbnails) Recv() (*GetThumbnailsResponse, error) {
+	m := new(GetThumbnailsResponse)
+	err := x.stream.Recv(m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for ThumbnailService service
 
 type ThumbnailServiceHandler interface {
 	GetThumbnail(context.Context, *GetRequest, *GetResponse) error
+	GetThumbnails(context.Context, *GetThumbnailsRequest, ThumbnailService_GetThumbnailsStream) error
 }
 
 func RegisterThumbnailServiceHandler(s server.Server, hdlr ThumbnailServiceHandler, opts ...server.HandlerOption) error {
 	type thumbnailService interface {
 		GetThumbnail(ctx context.Context, in *GetRequest, out *GetResponse) error
+		GetThumbnails(ctx context.Context, stream server.Stream) error
 	}
 	type ThumbnailService struct {
 		thumbnailService
@@ -83,3 +135,43 @@ type thumbnailServiceHandler struct {
 func (h *thumbnailServiceHandler) GetThumbnail(ctx context.Context, in *GetRequest, out *GetResponse) error {
 	return h.ThumbnailServiceHandler.GetThumbnail(ctx, in, out)
 }
+
+func (h *thumbnailServiceHandler) GetThumbnails(ctx context.Context, stream server.Stream) error {
+	m := new(GetThumbnailsRequest)
+	if err := stream.Recv(m); err != nil {
+		return err
+	}
+	return h.ThumbnailServiceHandler.GetThumbnails(ctx, m, &thumbnailServiceGetThumbnailsStream{stream})
+}
+
+type ThumbnailService_GetThumbnailsStream interface {
+	Context() context.Context
+	SendMsg(interface{}) error
+	RecvMsg(interface{}) error
+	Close() error
+	Send(*GetThumbnailsResponse) error
+}
+
+type thumbnailServiceGetThumbnailsStream struct {
+	stream server.Stream
+}
+
+func (x *thumbnailServiceGetThumbnailsStream) Close() error {
+	return x.stream.Close()
+}
+
+func (x *thumbnailServiceGetThumbnailsStream) Context() context.Context {
+	return x.stream.Context()
+}
+
+func (x *thumbnailServiceGetThumbnailsStream) SendMsg(m interface{}) error {
+	return x.stream.Send(m)
+}
+
+func (x *thumbnailServiceGetThumbnailsStream) RecvMsg(m interface{}) error {
+	return x.stream.Recv(m)
+}
+
+func (x *thumbnailServiceGetThumbnailsStream) Send(m *GetThumbnailsResponse) error {
+	return x.stream.Send(m)
+}
@@ -1,6 +1,12 @@
 package grpc
 
 import (
+	"time"
+
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/owncloud/ocis/ocis-pkg/events"
 	"github.com/owncloud/ocis/ocis-pkg/service/grpc"
 	"github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
 	svc "github.com/owncloud/ocis/thumbnails/pkg/service/v0"
@@ -24,18 +30,39 @@ func NewService(opts ...Option) grpc.Service {
 		grpc.Version(options.Config.Server.Version),
 	)
 
+	fsStorage := storage.NewFileSystemStorage(
+		options.Config.Thumbnail.FileSystemStorage,
+		options.Logger,
+	)
+	janitor := storage.NewJanitor(
+		fsStorage,
+		time.Duration(options.Config.Thumbnail.FileSystemStorage.GCInterval)*time.Second,
+		options.Logger,
+		options.Metrics,
+	)
+	go janitor.Run(options.Context)
+
+	var source imgsource.Source
+	switch options.Config.Thumbnail.Source {
+	case "cs3":
+		cs3Source, err := imgsource.NewCS3Source(options.Config.Thumbnail.CS3Source)
+		if err != nil {
+			options.Logger.Fatal().Err(err).Msg("could not create the cs3 source")
+		}
+		source = cs3Source
+	case "webdav", "":
+		source = imgsource.NewWebDavSource(options.Config.Thumbnail.WebDavSource)
+	default:
+		options.Logger.Fatal().Str("source", options.Config.Thumbnail.Source).Msg("unknown source")
+	}
+
 	var thumbnail proto.ThumbnailServiceHandler
 	{
 		thumbnail = svc.NewService(
 			svc.Config(options.Config),
 			svc.Logger(options.Logger),
-			svc.ThumbnailSource(imgsource.NewWebDavSource(options.Config.Thumbnail.WebDavSource)),
-			svc.ThumbnailStorage(
-				storage.NewFileSystemStorage(
-					options.Config.Thumbnail.FileSystemStorage,
-					options.Logger,
-				),
-			),
+			svc.ThumbnailSource(source),
+			svc.ThumbnailStorage(storage.NewInstrument(fsStorage, options.Metrics)),
 		)
 		thumbnail = svc.NewInstrument(thumbnail, options.Metrics)
 		thumbnail = svc.NewLogging(thumbnail, options.Logger)
@@ -46,6 +73,42 @@ func NewService(opts ...Option) grpc.Service {
 		thumbnail,
 	)
 
+	if options.Config.Thumbnail.Pregenerate.Enabled {
+		// the files are downloaded with access tokens minted by the service, only the cs3 source accepts them
+		if options.Config.Thumbnail.Source != "cs3" {
+			options.Logger.Fatal().Msg("pregenerating thumbnails requires the cs3 source")
+		}
+		gatewayClient, err := pool.GetGatewayServiceClient(options.Config.Thumbnail.CS3Source.GatewayAddress)
+		if err != nil {
+			options.Logger.Fatal().Err(err).Msg("could not create the gateway client")
+		}
+		pregenerator := svc.NewPregenerator(
+			svc.Config(options.Config),
+			svc.Logger(options.Logger),
+			svc.Metrics(options.Metrics),
+			svc.ThumbnailSource(source),
+			svc.ThumbnailStorage(storage.NewInstrument(fsStorage, options.Metrics)),
+			svc.GatewayClient(gatewayClient),
+		)
+		go pregenerator.Run(options.Context)
+
+		// only the server of this service receives the events through the ocis registry, the broker of the
+		// shared client is left as it is
+		if err := service.Server().Init(server.Broker(events.NewBroker())); err != nil {
+			options.Logger.Fatal().Err(err).Msg("could not configure the events broker")
+		}
+		// the queue makes sure only one instance of the service handles an upload
+		err = micro.RegisterSubscriber(
+			events.UploadFinishedTopic,
+			service.Server(),
+			pregenerator.Enqueue,
+			server.SubscriberQueue(options.Namespace+"."+options.Name),
+		)
+		if err != nil {
+			options.Logger.Fatal().Err(err).Msg("could not subscribe to the upload events")
+		}
+	}
+
 	service.Init()
 	return service
 }
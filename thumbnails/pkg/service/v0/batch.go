@@ -0,0 +1,112 @@
+package svc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	merrors "github.com/micro/go-micro/v2/errors"
+	v0proto "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail"
+)
+
+// maxBatchSize limits the number of thumbnails requested at once.
+const maxBatchSize = 1000
+
+// GetThumbnails retrieves the thumbnails of many files. The stored thumbnails are sent right away, the others as soon
+// as they are generated, so the responses are not in the order of the requests.
+func (g Thumbnail) GetThumbnails(ctx context.Context, req *v0proto.GetThumbnailsRequest, stream v0proto.ThumbnailService_GetThumbnailsStream) error {
+	if len(req.Requests) > maxBatchSize {
+		return merrors.BadRequest(g.serviceID, "too many thumbnails requested, the limit is %d", maxBatchSize)
+	}
+	username, err := g.usernameFromAccessToken(ctx, req.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	type job struct {
+		index int32
+		req   *v0proto.GetRequest
+		tr    thumbnail.Request
+	}
+	jobs := make([]job, 0, len(req.Requests))
+	for i, r := range req.Requests {
+		r.Authorization = req.Authorization
+		r.AccessToken = req.AccessToken
+		tr, ok := g.thumbnailRequest(r, username)
+		if !ok {
+			if err := stream.Send(batchResponse(int32(i), tr, nil, merrors.NotFound(g.serviceID, "unsupported mimetype"))); err != nil {
+				return err
+			}
+			continue
+		}
+		if thumbnail := g.manager.GetStored(tr); thumbnail != nil {
+			if err := stream.Send(batchResponse(int32(i), tr, thumbnail, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+		jobs = append(jobs, job{index: int32(i), req: r, tr: tr})
+	}
+
+	// stops the generation when the client is gone
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// at most as many thumbnails of a batch are generated concurrently as there are workers, which leaves the queue
+	// of the workers to the other requests
+	responses := make(chan *v0proto.GetThumbnailsResponse)
+	go func() {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, g.workerCount)
+		for _, j := range jobs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func(j job) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				thumbnail, err := g.generateOnce(ctx, j.req, j.tr)
+				if err == errUnsupported {
+					err = merrors.NotFound(g.serviceID, "unsupported mimetype")
+				}
+				select {
+				case responses <- batchResponse(j.index, j.tr, thumbnail, err):
+				case <-ctx.Done():
+				}
+			}(j)
+		}
+		wg.Wait()
+		close(responses)
+	}()
+
+	for rsp := range responses {
+		if err := stream.Send(rsp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchResponse(index int32, tr thumbnail.Request, thumbnail []byte, err error) *v0proto.GetThumbnailsResponse {
+	rsp := &v0proto.GetThumbnailsResponse{Index: index}
+	if err != nil {
+		e := merrors.Parse(err.Error())
+		rsp.Status = e.Code
+		rsp.Error = e.Detail
+		if rsp.Status == 0 {
+			rsp.Status = http.StatusInternalServerError
+			rsp.Error = err.Error()
+		}
+		return rsp
+	}
+	rsp.Status = http.StatusOK
+	rsp.Thumbnail = thumbnail
+	rsp.Mimetype = tr.Encoder.MimeType()
+	return rsp
+}
@@ -0,0 +1,80 @@
+package svc
+
+import (
+	"context"
+	"image"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	merrors "github.com/micro/go-micro/v2/errors"
+	v0proto "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchStream struct {
+	v0proto.ThumbnailService_GetThumbnailsStream
+	responses []*v0proto.GetThumbnailsResponse
+}
+
+func (s *batchStream) Send(rsp *v0proto.GetThumbnailsResponse) error {
+	s.responses = append(s.responses, rsp)
+	return nil
+}
+
+func TestGetThumbnails(t *testing.T) {
+	p, source, store := newTestPregenerator(t, 0)
+	g := p.thumbnail
+	accessToken := mintToken(t, "secret", &user.User{Id: &user.UserId{Idp: "https://localhost:9200"}, Username: "einstein"})
+
+	// the 16x16 thumbnail is already stored
+	stored := []byte("stored")
+	key := store.BuildKey(storage.Request{ETag: "33a64df551425fcc55e4d42a148795d9f25f89d4", Types: thumbnail.PngEncoder{}.Types(), Resolution: image.Rect(0, 0, 16, 16)})
+	assert.NoError(t, store.Set("einstein", key, stored))
+
+	stream := &batchStream{}
+	err := g.GetThumbnails(context.Background(), &v0proto.GetThumbnailsRequest{
+		AccessToken: accessToken,
+		Requests: []*v0proto.GetRequest{
+			{Filepath: "oc.png", Etag: "33a64df551425fcc55e4d42a148795d9f25f89d4", Width: 16, Height: 16},
+			{Filepath: "oc.png", Etag: "33a64df551425fcc55e4d42a148795d9f25f89d4", Width: 32, Height: 32},
+			{Filepath: "archive.zip", Etag: "1872ade88f3013edeb33decd74a4f947", Width: 32, Height: 32, Mimetype: "application/zip"},
+			{Filepath: "missing.png", Etag: "979f4c8db98f7b82e768ef478d3c8612", Width: 32, Height: 32},
+		},
+	}, stream)
+	assert.NoError(t, err)
+
+	rsps := stream.responses
+	sort.Slice(rsps, func(i, j int) bool { return rsps[i].Index < rsps[j].Index })
+	if assert.Len(t, rsps, 4) {
+		assert.EqualValues(t, http.StatusOK, rsps[0].Status)
+		assert.Equal(t, stored, rsps[0].Thumbnail)
+		assert.EqualValues(t, http.StatusOK, rsps[1].Status)
+		assert.NotEmpty(t, rsps[1].Thumbnail)
+		assert.Equal(t, "image/png", rsps[1].Mimetype)
+		assert.EqualValues(t, http.StatusNotFound, rsps[2].Status)
+		assert.EqualValues(t, http.StatusInternalServerError, rsps[3].Status)
+		assert.NotEmpty(t, rsps[3].Error)
+	}
+	// the stored thumbnail and the unsupported file are not downloaded
+	assert.EqualValues(t, 2, atomic.LoadInt32(&source.gets))
+}
+
+func TestGetThumbnailsRejectsInvalidBatches(t *testing.T) {
+	p, _, _ := newTestPregenerator(t, 0)
+	g := p.thumbnail
+
+	err := g.GetThumbnails(context.Background(), &v0proto.GetThumbnailsRequest{
+		Requests: []*v0proto.GetRequest{{Filepath: "oc.png"}},
+	}, &batchStream{})
+	assert.EqualValues(t, http.StatusUnauthorized, merrors.Parse(err.Error()).Code)
+
+	err = g.GetThumbnails(context.Background(), &v0proto.GetThumbnailsRequest{
+		Requests: make([]*v0proto.GetRequest, maxBatchSize+1),
+	}, &batchStream{})
+	assert.EqualValues(t, http.StatusBadRequest, merrors.Parse(err.Error()).Code)
+}
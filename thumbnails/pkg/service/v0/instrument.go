@@ -37,3 +37,8 @@ func (i instrument) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, r
 	}
 	return err
 }
+
+// GetThumbnails implements the ThumbnailServiceHandler interface.
+func (i instrument) GetThumbnails(ctx context.Context, req *v0proto.GetThumbnailsRequest, stream v0proto.ThumbnailService_GetThumbnailsStream) error {
+	return i.next.GetThumbnails(ctx, req, stream)
+}
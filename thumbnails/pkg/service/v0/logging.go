@@ -41,3 +41,25 @@ func (l logging) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, rsp
 	}
 	return err
 }
+
+// GetThumbnails implements the ThumbnailServiceHandler interface.
+func (l logging) GetThumbnails(ctx context.Context, req *v0proto.GetThumbnailsRequest, stream v0proto.ThumbnailService_GetThumbnailsStream) error {
+	start := time.Now()
+	err := l.next.GetThumbnails(ctx, req, stream)
+
+	logger := l.logger.With().
+		Str("method", "Thumbnails.GetThumbnails").
+		Int("count", len(req.Requests)).
+		Dur("duration", time.Since(start)).
+		Logger()
+
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to execute")
+	} else {
+		logger.Debug().
+			Msg("")
+	}
+	return err
+}
@@ -3,8 +3,10 @@ package svc
 import (
 	"net/http"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/thumbnails/pkg/config"
+	"github.com/owncloud/ocis/thumbnails/pkg/metrics"
 	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/imgsource"
 	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/storage"
 )
@@ -19,6 +21,8 @@ type Options struct {
 	Middleware       []func(http.Handler) http.Handler
 	ThumbnailStorage storage.Storage
 	ImageSource      imgsource.Source
+	Metrics          *metrics.Metrics
+	GatewayClient    gateway.GatewayAPIClient
 }
 
 // newOptions initializes the available default options.
@@ -66,3 +70,17 @@ func ThumbnailSource(val imgsource.Source) Option {
 		o.ImageSource = val
 	}
 }
+
+// Metrics provides a function to set the metrics option.
+func Metrics(val *metrics.Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = val
+	}
+}
+
+// GatewayClient provides a function to set the gateway client option.
+func GatewayClient(val gateway.GatewayAPIClient) Option {
+	return func(o *Options) {
+		o.GatewayClient = val
+	}
+}
@@ -0,0 +1,228 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path"
+	"strings"
+	"sync"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/owncloud/ocis/ocis-pkg/events"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/thumbnails/pkg/metrics"
+	v0proto "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/imgsource"
+	"google.golang.org/grpc/metadata"
+)
+
+// errPregeneratorStopped is returned when files are queued after the pregenerator stopped.
+var errPregeneratorStopped = errors.New("pregenerator stopped")
+
+// NewPregenerator returns a Pregenerator which generates the thumbnails with its own workers, so uploads don't
+// occupy all workers of the interactive requests.
+func NewPregenerator(opts ...Option) *Pregenerator {
+	options := newOptions(opts...)
+	workers := options.Config.Thumbnail.Pregenerate.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	maxQueue := options.Config.Thumbnail.Pregenerate.MaxQueue
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &Pregenerator{
+		thumbnail: newThumbnail(options),
+		gateway:   options.GatewayClient,
+		secret:    options.Config.TokenManager.JWTSecret,
+		jobs:      make(chan *events.UploadFinished, maxQueue),
+		done:      make(chan struct{}),
+		workers:   workers,
+		logger:    options.Logger,
+		metrics:   options.Metrics,
+	}
+}
+
+// Pregenerator generates the thumbnails of uploaded files in the background, so they are already stored when the
+// files are listed for the first time.
+type Pregenerator struct {
+	thumbnail Thumbnail
+	gateway   gateway.GatewayAPIClient
+	secret    string
+	jobs      chan *events.UploadFinished
+	done      chan struct{}
+	workers   int
+	logger    log.Logger
+	metrics   *metrics.Metrics
+}
+
+// Enqueue queues an uploaded file. It blocks while the queue is full, which holds back the delivery of further
+// events instead of dropping them.
+func (p *Pregenerator) Enqueue(ctx context.Context, ev *events.UploadFinished) error {
+	select {
+	case <-p.done:
+		return errPregeneratorStopped
+	default:
+	}
+
+	select {
+	case p.jobs <- ev:
+		p.updateBacklog()
+		return nil
+	case <-p.done:
+		return errPregeneratorStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run generates the thumbnails of the queued files until the context is cancelled.
+func (p *Pregenerator) Run(ctx context.Context) {
+	defer close(p.done)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case ev := <-p.jobs:
+					p.updateBacklog()
+					if err := p.process(ctx, ev); err != nil {
+						p.logger.Warn().Err(err).Str("filepath", ev.Path).Msg("could not pregenerate thumbnails")
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// process generates the thumbnails of an uploaded file with an access token of its owner. Only events signed by
+// the proxy are processed.
+func (p *Pregenerator) process(ctx context.Context, ev *events.UploadFinished) error {
+	if err := ev.Verify(p.secret); err != nil {
+		return err
+	}
+	accessToken, err := p.impersonate(ctx, ev.Owner)
+	if err != nil {
+		return err
+	}
+	return p.thumbnail.pregenerate(ctx, ev, accessToken)
+}
+
+// impersonate mints an access token for the owner of an uploaded file. Nothing but the user id is taken from the
+// events, the user is looked up through the gateway.
+func (p *Pregenerator) impersonate(ctx context.Context, owner events.UserID) (string, error) {
+	if owner.OpaqueID == "" {
+		return "", errors.New("the owner of the file is missing")
+	}
+	id := &user.UserId{Idp: owner.Idp, OpaqueId: owner.OpaqueID}
+
+	// the gateway only needs the id to authorize the lookup
+	tkn, err := p.thumbnail.tokenManager.MintToken(ctx, &user.User{Id: id})
+	if err != nil {
+		return "", err
+	}
+	res, err := p.gateway.GetUser(metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), &user.GetUserRequest{UserId: id})
+	if err != nil {
+		return "", err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", fmt.Errorf("could not get the owner \"%s\": %s", owner.OpaqueID, res.Status.Message)
+	}
+	return p.thumbnail.tokenManager.MintToken(ctx, res.User)
+}
+
+func (p *Pregenerator) updateBacklog() {
+	if p.metrics != nil {
+		p.metrics.PregenerateBacklog.WithLabelValues().Set(float64(len(p.jobs)))
+	}
+}
+
+// pregenerate generates the thumbnails of the uploaded file in all configured resolutions which aren't stored yet.
+// The thumbnails are encoded in the file type the webdav service requests when the client doesn't ask for a
+// specific one.
+func (g Thumbnail) pregenerate(ctx context.Context, ev *events.UploadFinished, accessToken string) error {
+	username, err := g.usernameFromAccessToken(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	file := strings.TrimLeft(ev.Path, "/")
+	etag, err := g.uploadedETag(ctx, ev, file, accessToken)
+	if err != nil {
+		return err
+	}
+
+	req := &v0proto.GetRequest{
+		Filepath:    file,
+		Etag:        etag,
+		AccessToken: accessToken,
+	}
+	encoder := thumbnail.EncoderForType(strings.TrimPrefix(path.Ext(ev.Path), "."), thumbnail.JpegQuality(g.jpegQuality))
+	if encoder == nil {
+		encoder = thumbnail.PngEncoder{}
+	}
+
+	var missing []thumbnail.Request
+	for _, r := range g.resolutions {
+		tr := thumbnail.Request{
+			Resolution: r,
+			Encoder:    encoder,
+			ETag:       etag,
+			Username:   username,
+		}
+		if g.manager.GetStored(tr) == nil {
+			missing = append(missing, tr)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	// the source file is only downloaded and decoded once for all resolutions
+	err = g.withImage(ctx, req, func(img image.Image) error {
+		for _, tr := range missing {
+			if _, err := g.manager.Get(tr, img); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == errUnsupported {
+		return nil
+	}
+	return err
+}
+
+// uploadedETag returns the etag of the uploaded file. It is looked up in the source if the event doesn't contain it,
+// like the ones of tus uploads.
+func (g Thumbnail) uploadedETag(ctx context.Context, ev *events.UploadFinished, file, accessToken string) (string, error) {
+	if ev.ETag != "" {
+		return ev.ETag, nil
+	}
+	s, ok := g.source.(imgsource.Statter)
+	if !ok {
+		return "", errors.New("the etag of the uploaded file is missing")
+	}
+	info, err := s.Stat(imgsource.ContextSetAccessToken(ctx, accessToken), file)
+	if err != nil {
+		return "", err
+	}
+	return info.ETag, nil
+}
@@ -0,0 +1,158 @@
+package svc
+
+import (
+	"context"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/owncloud/ocis/ocis-pkg/events"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/thumbnails/pkg/config"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type countingSource struct {
+	imgsource.Source
+	gets int32
+}
+
+func (s *countingSource) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	atomic.AddInt32(&s.gets, 1)
+	return s.Source.Get(ctx, path)
+}
+
+// userGateway looks up the users it knows.
+type userGateway struct {
+	gateway.GatewayAPIClient
+	users []*user.User
+}
+
+func (g userGateway) GetUser(ctx context.Context, req *user.GetUserRequest, opts ...grpc.CallOption) (*user.GetUserResponse, error) {
+	for _, u := range g.users {
+		if u.Id.OpaqueId == req.UserId.OpaqueId {
+			return &user.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: u}, nil
+		}
+	}
+	return &user.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND, Message: "user not found"}}, nil
+}
+
+var einstein = &user.User{Id: &user.UserId{Idp: "https://localhost:9200", OpaqueId: "4c510ada-c86b-4815-8820-42cdf82c3d51"}, Username: "einstein"}
+
+func newTestPregenerator(t *testing.T, maxQueue int) (*Pregenerator, *countingSource, storage.Storage) {
+	wd, _ := os.Getwd()
+	cfg := config.New()
+	cfg.TokenManager.JWTSecret = "secret"
+	cfg.Thumbnail.Resolutions = []string{"16x16", "32x32", "64x64"}
+	cfg.Thumbnail.Pregenerate.MaxQueue = maxQueue
+
+	source := &countingSource{Source: imgsource.NewFileSystemSource(config.FileSystemSource{BasePath: filepath.Join(wd, "../../../testdata/")})}
+	store := storage.NewInMemoryStorage(0)
+	p := NewPregenerator(
+		Config(cfg),
+		Logger(log.NewLogger()),
+		ThumbnailSource(source),
+		ThumbnailStorage(store),
+		GatewayClient(userGateway{users: []*user.User{einstein}}),
+	)
+	return p, source, store
+}
+
+func TestPregenerate(t *testing.T) {
+	p, source, store := newTestPregenerator(t, 1)
+	ev := &events.UploadFinished{
+		Owner: events.UserID{Idp: einstein.Id.Idp, OpaqueID: einstein.Id.OpaqueId},
+		Path:  "/oc.png",
+		ETag:  "33a64df551425fcc55e4d42a148795d9f25f89d4",
+	}
+	ev.Sign("secret")
+
+	assert.NoError(t, p.process(context.Background(), ev))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&source.gets))
+	for _, size := range []int{16, 32, 64} {
+		key := store.BuildKey(storage.Request{ETag: ev.ETag, Types: thumbnail.PngEncoder{}.Types(), Resolution: image.Rect(0, 0, size, size)})
+		assert.NotNil(t, store.Get("einstein", key), "%dx%d", size, size)
+	}
+
+	// the stored thumbnails are not generated again
+	assert.NoError(t, p.process(context.Background(), ev))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&source.gets))
+}
+
+func TestPregenerateSkipsUnknownOwners(t *testing.T) {
+	p, source, _ := newTestPregenerator(t, 1)
+
+	for _, owner := range []events.UserID{{}, {Idp: einstein.Id.Idp, OpaqueID: "unknown"}} {
+		ev := &events.UploadFinished{
+			Owner: owner,
+			Path:  "/oc.png",
+			ETag:  "33a64df551425fcc55e4d42a148795d9f25f89d4",
+		}
+		ev.Sign("secret")
+		assert.Error(t, p.process(context.Background(), ev))
+	}
+	assert.EqualValues(t, 0, atomic.LoadInt32(&source.gets))
+}
+
+func TestPregenerateRejectsUnsignedEvents(t *testing.T) {
+	p, source, _ := newTestPregenerator(t, 1)
+
+	for _, secret := range []string{"", "other"} {
+		ev := &events.UploadFinished{
+			Owner: events.UserID{Idp: einstein.Id.Idp, OpaqueID: einstein.Id.OpaqueId},
+			Path:  "/oc.png",
+			ETag:  "33a64df551425fcc55e4d42a148795d9f25f89d4",
+		}
+		if secret != "" {
+			ev.Sign(secret)
+		}
+		assert.Equal(t, events.ErrInvalidSignature, p.process(context.Background(), ev))
+	}
+	assert.EqualValues(t, 0, atomic.LoadInt32(&source.gets))
+}
+
+func TestPregenerateLooksUpMissingETags(t *testing.T) {
+	p, source, store := newTestPregenerator(t, 1)
+	p.thumbnail.source = statSource{Source: source, info: imgsource.Info{ETag: "33a64df551425fcc55e4d42a148795d9f25f89d4"}}
+
+	// tus uploads are published without the etag
+	ev := &events.UploadFinished{
+		Owner: events.UserID{Idp: einstein.Id.Idp, OpaqueID: einstein.Id.OpaqueId},
+		Path:  "/oc.png",
+	}
+	ev.Sign("secret")
+
+	assert.NoError(t, p.process(context.Background(), ev))
+	key := store.BuildKey(storage.Request{ETag: "33a64df551425fcc55e4d42a148795d9f25f89d4", Types: thumbnail.PngEncoder{}.Types(), Resolution: image.Rect(0, 0, 16, 16)})
+	assert.NotNil(t, store.Get("einstein", key))
+}
+
+func TestPregeneratorEnqueueBlocksWhenFull(t *testing.T) {
+	p, _, _ := newTestPregenerator(t, 1)
+
+	assert.NoError(t, p.Enqueue(context.Background(), &events.UploadFinished{Path: "/a.png"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, p.Enqueue(ctx, &events.UploadFinished{Path: "/b.png"}))
+
+	// the queued file is processed once the pregenerator runs, which makes room for the next one
+	runCtx, stop := context.WithCancel(context.Background())
+	go p.Run(runCtx)
+	assert.NoError(t, p.Enqueue(context.Background(), &events.UploadFinished{Path: "/b.png"}))
+
+	stop()
+	<-p.done
+	assert.Equal(t, errPregeneratorStopped, p.Enqueue(context.Background(), &events.UploadFinished{Path: "/c.png"}))
+}
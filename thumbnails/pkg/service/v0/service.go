@@ -3,25 +3,60 @@ package svc
 import (
 	"context"
 	"image"
+	"mime"
+	"net/http"
+	"path"
+	"runtime"
+	"strings"
+	"time"
 
-	"gopkg.in/square/go-jose.v2/jwt"
-
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
 	merrors "github.com/micro/go-micro/v2/errors"
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	v0proto "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
 	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/generator"
 	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/imgsource"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
 )
 
+// errUnsupported is returned when there is no generator for the mimetype of a file.
+var errUnsupported = errors.New("unsupported mimetype")
+
 // NewService returns a service implementation for Service.
 func NewService(opts ...Option) v0proto.ThumbnailServiceHandler {
-	options := newOptions(opts...)
+	return newThumbnail(newOptions(opts...))
+}
+
+func newThumbnail(options Options) Thumbnail {
 	logger := options.Logger
 	resolutions, err := thumbnail.ParseResolutions(options.Config.Thumbnail.Resolutions)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("resolutions not configured correctly")
 	}
+	generators, err := generator.New(
+		generator.Converters(options.Config.Thumbnail.Converters),
+		generator.ConverterTimeout(time.Duration(options.Config.Thumbnail.ConverterTimeout)*time.Second),
+		generator.MaxPixels(options.Config.Thumbnail.MaxInputPixels),
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("converters not configured correctly")
+	}
+	tokenManager, err := jwt.New(map[string]interface{}{
+		"secret": options.Config.TokenManager.JWTSecret,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("could not initialize the token manager")
+	}
+	if thumbnail.EncoderForType("webp") == nil {
+		logger.Warn().Msg("webp thumbnails are not supported by this binary because it was built without cgo, they are encoded as png instead")
+	}
+	workerCount := options.Config.Thumbnail.Workers
+	if workerCount <= 0 {
+		workerCount = runtime.NumCPU()
+	}
 	svc := Thumbnail{
 		serviceID: options.Config.Server.Namespace + "." + options.Config.Server.Name,
 		manager: thumbnail.NewSimpleManager(
@@ -29,8 +64,18 @@ func NewService(opts ...Option) v0proto.ThumbnailServiceHandler {
 			options.ThumbnailStorage,
 			logger,
 		),
-		source: options.ImageSource,
-		logger: logger,
+		resolutions:    resolutions,
+		source:         options.ImageSource,
+		generators:     generators,
+		tokenManager:   tokenManager,
+		logger:         logger,
+		jpegQuality:    options.Config.Thumbnail.JpegQuality,
+		maxInputSize:   options.Config.Thumbnail.MaxInputSize,
+		maxInputPixels: options.Config.Thumbnail.MaxInputPixels,
+		timeout:        time.Duration(options.Config.Thumbnail.RequestTimeout) * time.Second,
+		workers:        newWorkers(workerCount, options.Config.Thumbnail.MaxQueue),
+		workerCount:    workerCount,
+		inflight:       &singleflight.Group{},
 	}
 
 	return svc
@@ -38,27 +83,61 @@ func NewService(opts ...Option) v0proto.ThumbnailServiceHandler {
 
 // Thumbnail implements the GRPC handler.
 type Thumbnail struct {
-	serviceID string
-	manager   thumbnail.Manager
-	source    imgsource.Source
-	logger    log.Logger
+	serviceID      string
+	manager        thumbnail.Manager
+	resolutions    thumbnail.Resolutions
+	source         imgsource.Source
+	generators     generator.Generators
+	tokenManager   token.Manager
+	logger         log.Logger
+	jpegQuality    int
+	maxInputSize   int64
+	maxInputPixels int64
+	timeout        time.Duration
+	workers        *workers
+	workerCount    int
+	inflight       *singleflight.Group
 }
 
 // GetThumbnail retrieves a thumbnail for an image
 func (g Thumbnail) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, rsp *v0proto.GetResponse) error {
-	encoder := thumbnail.EncoderForType(req.Filetype.String())
-	if encoder == nil {
-		g.logger.Debug().Str("filetype", req.Filetype.String()).Msg("unsupported filetype")
+	username, err := g.usernameFromAccessToken(ctx, req.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	tr, ok := g.thumbnailRequest(req, username)
+	if !ok {
+		g.logger.Debug().Str("mimetype", req.Mimetype).Str("filepath", req.Filepath).Msg("unsupported mimetype")
 		return nil
 	}
 
-	auth := req.Authorization
-	if auth == "" {
-		return merrors.BadRequest(g.serviceID, "authorization is missing")
+	thumbnail := g.manager.GetStored(tr)
+	if thumbnail == nil {
+		thumbnail, err = g.generateOnce(ctx, req, tr)
+		if err == errUnsupported {
+			g.logger.Debug().Str("filepath", req.Filepath).Msg("unsupported mimetype")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
-	username, err := usernameFromAuthorization(auth)
-	if err != nil {
-		return merrors.InternalServerError(g.serviceID, "could not get username: %v", err.Error())
+
+	rsp.Thumbnail = thumbnail
+	rsp.Mimetype = tr.Encoder.MimeType()
+	return nil
+}
+
+// thumbnailRequest maps the request to a thumbnail request. It returns false if the client sent the mimetype of the
+// file, it is unsupported and the source can't stat the file for the actual mimetype. Otherwise the mimetype is only
+// known after the file was stat'ed.
+func (g Thumbnail) thumbnailRequest(req *v0proto.GetRequest, username string) (thumbnail.Request, bool) {
+	encoder := thumbnail.EncoderForType(req.Filetype.String(), thumbnail.JpegQuality(g.jpegQuality))
+	if encoder == nil {
+		// the clients get the mimetype of the thumbnail in the response, so we can fall back to png
+		g.logger.Info().Str("filetype", req.Filetype.String()).Msg("unsupported filetype, falling back to png")
+		encoder = thumbnail.PngEncoder{}
 	}
 
 	tr := thumbnail.Request{
@@ -66,48 +145,168 @@ func (g Thumbnail) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, rs
 		Encoder:    encoder,
 		ETag:       req.Etag,
 		Username:   username,
+		// the modes and resamplings are named like the lower case values of the enums
+		Mode:       thumbnail.Mode(strings.ToLower(req.Mode.String())),
+		Resampling: thumbnail.Resampling(strings.ToLower(req.Resampling.String())),
 	}
+	if _, ok := g.source.(imgsource.Statter); ok || req.Mimetype == "" {
+		return tr, true
+	}
+	return tr, g.generatorFor(req.Mimetype) != nil
+}
 
-	thumbnail := g.manager.GetStored(tr)
-	if thumbnail != nil {
-		rsp.Thumbnail = thumbnail
-		rsp.Mimetype = tr.Encoder.MimeType()
-		return nil
+// generateOnce generates the thumbnail within the request timeout. Concurrent requests for the same thumbnail only
+// generate it once. The generation doesn't depend on the context of the request which started it, so it isn't
+// cancelled for the other requests waiting for it when that request goes away. Each request stops waiting when its
+// own context is done.
+func (g Thumbnail) generateOnce(ctx context.Context, req *v0proto.GetRequest, tr thumbnail.Request) ([]byte, error) {
+	key := strings.Join([]string{tr.Username, tr.ETag, tr.Resolution.String(), string(tr.Mode), string(tr.Resampling), tr.Encoder.Types()[0]}, "+")
+	ch := g.inflight.DoChan(key, func() (interface{}, error) {
+		gCtx := context.Background()
+		if g.timeout > 0 {
+			var cancel context.CancelFunc
+			gCtx, cancel = context.WithTimeout(gCtx, g.timeout)
+			defer cancel()
+		}
+		return g.generate(gCtx, req, tr)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, merrors.Timeout(g.serviceID, "stopped waiting for the thumbnail: %v", ctx.Err().Error())
+	}
+}
+
+// generate fetches the source file and generates the thumbnail as soon as a worker is free.
+func (g Thumbnail) generate(ctx context.Context, req *v0proto.GetRequest, tr thumbnail.Request) ([]byte, error) {
+	var thumbnail []byte
+	err := g.withImage(ctx, req, func(img image.Image) (err error) {
+		thumbnail, err = g.manager.Get(tr, img)
+		return err
+	})
+	return thumbnail, err
+}
+
+// withImage fetches the source file and calls fn with the generated image as soon as a worker is free. The worker is
+// occupied until fn returns. errUnsupported is returned if there is no generator for the mimetype of the file.
+func (g Thumbnail) withImage(ctx context.Context, req *v0proto.GetRequest, fn func(image.Image) error) error {
+	if err := g.workers.acquire(ctx); err != nil {
+		if err == errQueueFull {
+			return merrors.New(g.serviceID, err.Error(), http.StatusServiceUnavailable)
+		}
+		return merrors.Timeout(g.serviceID, "timed out waiting for a worker: %v", err.Error())
 	}
+	defer g.workers.release()
 
-	sCtx := imgsource.ContextSetAuthorization(ctx, auth)
-	img, err := g.source.Get(sCtx, req.Filepath)
+	sCtx := imgsource.ContextSetAuthorization(ctx, req.Authorization)
+	sCtx = imgsource.ContextSetAccessToken(sCtx, req.AccessToken)
+	info, err := g.stat(sCtx, req)
 	if err != nil {
-		return merrors.InternalServerError(g.serviceID, "could not get image from source: %v", err.Error())
+		return err
 	}
-	if img == nil {
-		return merrors.InternalServerError(g.serviceID, "could not get image from source")
+	gen := g.generatorFor(mimeTypeOf(req, info))
+	if gen == nil {
+		return errUnsupported
 	}
-	thumbnail, err = g.manager.Get(tr, img)
+	src, err := g.source.Get(sCtx, req.Filepath)
 	if err != nil {
-		return err
+		return merrors.InternalServerError(g.serviceID, "could not get image from source: %v", err.Error())
 	}
+	defer src.Close()
 
-	rsp.Thumbnail = thumbnail
-	rsp.Mimetype = tr.Encoder.MimeType()
-	return nil
+	img, err := gen.Generate(ctx, imgsource.LimitReader(src, g.maxInputSize))
+	switch cause := errors.Cause(err); {
+	case cause == imgsource.ErrTooLarge, cause == generator.ErrTooManyPixels:
+		return merrors.New(g.serviceID, err.Error(), http.StatusRequestEntityTooLarge)
+	case err != nil:
+		return merrors.InternalServerError(g.serviceID, "could not generate image: %v", err.Error())
+	}
+	return fn(img)
 }
 
-func usernameFromAuthorization(auth string) (string, error) {
-	tokenString := auth[len("Bearer "):] // strip the bearer prefix
-
-	var claims map[string]interface{}
-	token, err := jwt.ParseSigned(tokenString)
-	if err != nil {
-		return "", errors.Wrap(err, "could not parse auth token")
+// stat returns the info of the current version of the file. It makes sure the etag of the request matches it,
+// otherwise the thumbnail of an outdated version would be stored under the wrong etag. Sources which can't stat
+// files return an empty info.
+func (g Thumbnail) stat(ctx context.Context, req *v0proto.GetRequest) (imgsource.Info, error) {
+	s, ok := g.source.(imgsource.Statter)
+	if !ok {
+		return imgsource.Info{}, nil
 	}
-	err = token.UnsafeClaimsWithoutVerification(&claims)
+	info, err := s.Stat(ctx, req.Filepath)
 	if err != nil {
-		return "", errors.Wrap(err, "could not get claims from auth token")
+		return info, merrors.InternalServerError(g.serviceID, "could not stat the file in the source: %v", err.Error())
+	}
+	if normalizeETag(info.ETag) != normalizeETag(req.Etag) {
+		return info, merrors.New(g.serviceID, "etag does not match the current version of the file", http.StatusPreconditionFailed)
+	}
+	return info, nil
+}
+
+// normalizeETag strips the weak prefix and the quotes of an etag.
+func normalizeETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
+
+// mimeTypeOf returns the mimetype the source reports, the client can't be trusted to send the right one. The mimetype
+// the client sent is only used if the source doesn't know it, and the mimetype is only derived from the file extension
+// if neither knows it.
+func mimeTypeOf(req *v0proto.GetRequest, info imgsource.Info) string {
+	// storages which can't tell the type of a file report it as binary
+	if info.MimeType != "" && info.MimeType != "application/octet-stream" {
+		return info.MimeType
+	}
+	if req.Mimetype != "" {
+		return req.Mimetype
+	}
+	return mimeTypeByExtension(path.Ext(req.Filepath))
+}
+
+// extensionMimeTypes complements the mimetypes known to the mime package for extensions of files thumbnails can be
+// generated for.
+var extensionMimeTypes = map[string]string{
+	".bmp":      "image/bmp",
+	".tif":      "image/tiff",
+	".tiff":     "image/tiff",
+	".txt":      "text/plain",
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+}
+
+// mimeTypeByExtension returns the mimetype for a file extension or an empty string if it is unknown.
+func mimeTypeByExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if mimeType, ok := extensionMimeTypes[ext]; ok {
+		return mimeType
 	}
+	return mime.TypeByExtension(ext)
+}
 
-	identityMap := claims["kc.identity"].(map[string]interface{})
-	username := identityMap["kc.i.un"].(string)
+// generatorFor returns the generator for the mimetype or nil if it is unsupported. Files with an unknown mimetype are
+// treated as images.
+func (g Thumbnail) generatorFor(mimeType string) generator.Generator {
+	if mimeType == "" {
+		return generator.Image{MaxPixels: g.maxInputPixels}
+	}
+	return g.generators.ForMimeType(mimeType)
+}
 
-	return username, nil
+// usernameFromAccessToken verifies the reva access token and returns the username of its user. The thumbnails are
+// stored per user, so the username must never be taken from an unverified token.
+func (g Thumbnail) usernameFromAccessToken(ctx context.Context, accessToken string) (string, error) {
+	if accessToken == "" {
+		return "", merrors.Unauthorized(g.serviceID, "access token is missing")
+	}
+	u, err := g.tokenManager.DismantleToken(ctx, accessToken)
+	if err != nil {
+		return "", merrors.Unauthorized(g.serviceID, "invalid access token: %v", err.Error())
+	}
+	if u == nil || u.Username == "" {
+		return "", merrors.Unauthorized(g.serviceID, "access token has no username")
+	}
+	return u.Username, nil
 }
@@ -0,0 +1,187 @@
+package svc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
+	merrors "github.com/micro/go-micro/v2/errors"
+	v0proto "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
+	"github.com/owncloud/ocis/thumbnails/pkg/thumbnail/imgsource"
+	"github.com/stretchr/testify/assert"
+)
+
+// statSource reports the same info for every file.
+type statSource struct {
+	imgsource.Source
+	info imgsource.Info
+}
+
+func (s statSource) Stat(ctx context.Context, path string) (imgsource.Info, error) {
+	return s.info, nil
+}
+
+func TestStat(t *testing.T) {
+	g := Thumbnail{serviceID: "com.owncloud.api.thumbnails", source: statSource{info: imgsource.Info{ETag: `"979f4c8db98f7b82e768ef478d3c8612"`}}}
+
+	_, err := g.stat(context.Background(), &v0proto.GetRequest{Etag: "979f4c8db98f7b82e768ef478d3c8612"})
+	assert.NoError(t, err)
+	_, err = g.stat(context.Background(), &v0proto.GetRequest{Etag: `W/"979f4c8db98f7b82e768ef478d3c8612"`})
+	assert.NoError(t, err)
+
+	_, err = g.stat(context.Background(), &v0proto.GetRequest{Etag: "1872ade88f3013edeb33decd74a4f947"})
+	assert.EqualValues(t, http.StatusPreconditionFailed, merrors.Parse(err.Error()).Code)
+}
+
+func TestMimeTypeOf(t *testing.T) {
+	tests := []struct {
+		req      *v0proto.GetRequest
+		info     imgsource.Info
+		expected string
+	}{
+		{&v0proto.GetRequest{Filepath: "a.png", Mimetype: "text/plain"}, imgsource.Info{MimeType: "image/jpeg"}, "image/jpeg"},
+		{&v0proto.GetRequest{Filepath: "a.png", Mimetype: "text/plain"}, imgsource.Info{MimeType: "application/octet-stream"}, "text/plain"},
+		{&v0proto.GetRequest{Filepath: "a.png", Mimetype: "text/plain"}, imgsource.Info{}, "text/plain"},
+		{&v0proto.GetRequest{Filepath: "a.png"}, imgsource.Info{MimeType: "image/jpeg"}, "image/jpeg"},
+		{&v0proto.GetRequest{Filepath: "a.png"}, imgsource.Info{MimeType: "application/octet-stream"}, "image/png"},
+		{&v0proto.GetRequest{Filepath: "a.png"}, imgsource.Info{}, "image/png"},
+		{&v0proto.GetRequest{Filepath: "README.MD"}, imgsource.Info{}, "text/markdown"},
+		{&v0proto.GetRequest{Filepath: "a"}, imgsource.Info{}, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, mimeTypeOf(tt.req, tt.info), tt)
+	}
+}
+
+func TestGetThumbnailUsesTheMimeTypeOfTheSource(t *testing.T) {
+	p, source, _ := newTestPregenerator(t, 0)
+	g := p.thumbnail
+	etag := "33a64df551425fcc55e4d42a148795d9f25f89d4"
+	accessToken := mintToken(t, "secret", einstein)
+	req := &v0proto.GetRequest{Filepath: "oc.png", Etag: etag, Width: 32, Height: 32, AccessToken: accessToken}
+
+	// the source knows better than the extension
+	g.source = statSource{Source: source, info: imgsource.Info{ETag: etag, MimeType: "application/zip"}}
+	rsp := &v0proto.GetResponse{}
+	assert.NoError(t, g.GetThumbnail(context.Background(), req, rsp))
+	assert.Empty(t, rsp.Thumbnail)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&source.gets))
+
+	// the mimetype sent by the client doesn't override the one of the source
+	req.Mimetype = "image/png"
+	assert.NoError(t, g.GetThumbnail(context.Background(), req, rsp))
+	assert.Empty(t, rsp.Thumbnail)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&source.gets))
+	req.Mimetype = ""
+
+	// the extension is used if the source doesn't know the mimetype
+	g.source = statSource{Source: source, info: imgsource.Info{ETag: etag}}
+	assert.NoError(t, g.GetThumbnail(context.Background(), req, rsp))
+	assert.NotEmpty(t, rsp.Thumbnail)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&source.gets))
+}
+
+func mintToken(t *testing.T, secret string, u *user.User) string {
+	m, err := jwt.New(map[string]interface{}{"secret": secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkn, err := m.MintToken(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tkn
+}
+
+func TestUsernameFromAccessToken(t *testing.T) {
+	m, err := jwt.New(map[string]interface{}{"secret": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := Thumbnail{serviceID: "com.owncloud.api.thumbnails", tokenManager: m}
+	einstein := &user.User{Id: &user.UserId{Idp: "https://localhost:9200", OpaqueId: "4c510ada-c86b-4815-8820-42cdf82c3d51"}, Username: "einstein"}
+
+	tests := []struct {
+		name     string
+		token    string
+		username string
+	}{
+		{name: "valid", token: mintToken(t, "secret", einstein), username: "einstein"},
+		{name: "forged", token: mintToken(t, "other secret", einstein)},
+		{name: "no username", token: mintToken(t, "secret", &user.User{Id: einstein.Id})},
+		{
+			// an unsigned konnect token claiming to be einstein
+			name:  "konnect",
+			token: "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJrYy5pZGVudGl0eSI6eyJrYy5pLnVuIjoiZWluc3RlaW4ifX0.",
+		},
+		{name: "malformed", token: "not a token"},
+		{name: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, err := g.usernameFromAccessToken(context.Background(), tt.token)
+			if tt.username != "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.username, username)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.EqualValues(t, http.StatusUnauthorized, merrors.Parse(err.Error()).Code)
+			}
+		})
+	}
+}
+
+// blockingSource blocks every download until release is closed.
+type blockingSource struct {
+	*countingSource
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s blockingSource) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return s.countingSource.Get(ctx, path)
+}
+
+func TestGenerateOnceOutlivesCancelledCaller(t *testing.T) {
+	p, source, _ := newTestPregenerator(t, 0)
+	g := p.thumbnail
+	blocking := blockingSource{countingSource: source, started: make(chan struct{}, 1), release: make(chan struct{})}
+	g.source = blocking
+
+	req := &v0proto.GetRequest{Filepath: "oc.png", Etag: "33a64df551425fcc55e4d42a148795d9f25f89d4", Width: 32, Height: 32}
+	tr, _ := g.thumbnailRequest(req, "einstein")
+
+	// the first request starts the generation and goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := g.generateOnce(ctx, req, tr)
+		first <- err
+	}()
+	<-blocking.started
+
+	second := make(chan []byte)
+	go func() {
+		thumbnail, err := g.generateOnce(context.Background(), req, tr)
+		assert.NoError(t, err)
+		second <- thumbnail
+	}()
+	// give the second request the time to join the generation
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.EqualValues(t, http.StatusRequestTimeout, merrors.Parse((<-first).Error()).Code)
+
+	// the second request still gets the thumbnail, which was only downloaded once
+	close(blocking.release)
+	assert.NotEmpty(t, <-second)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&source.gets))
+}
@@ -26,6 +26,9 @@ func (t tracing) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, rsp
 	span.Annotate([]trace.Attribute{
 		trace.StringAttribute("filepath", req.Filepath),
 		trace.StringAttribute("filetype", req.Filetype.String()),
+		trace.StringAttribute("mimetype", req.Mimetype),
+		trace.StringAttribute("mode", req.Mode.String()),
+		trace.StringAttribute("resampling", req.Resampling.String()),
 		trace.StringAttribute("etag", req.Etag),
 		trace.Int64Attribute("width", int64(req.Width)),
 		trace.Int64Attribute("height", int64(req.Height)),
@@ -33,3 +36,15 @@ func (t tracing) GetThumbnail(ctx context.Context, req *v0proto.GetRequest, rsp
 
 	return t.next.GetThumbnail(ctx, req, rsp)
 }
+
+// GetThumbnails implements the ThumbnailServiceHandler interface.
+func (t tracing) GetThumbnails(ctx context.Context, req *v0proto.GetThumbnailsRequest, stream v0proto.ThumbnailService_GetThumbnailsStream) error {
+	ctx, span := trace.StartSpan(ctx, "Thumbnails.GetThumbnails")
+	defer span.End()
+
+	span.Annotate([]trace.Attribute{
+		trace.Int64Attribute("count", int64(len(req.Requests))),
+	}, "Execute Thumbnails.GetThumbnails handler")
+
+	return t.next.GetThumbnails(ctx, req, stream)
+}
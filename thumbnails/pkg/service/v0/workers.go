@@ -0,0 +1,52 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errQueueFull is returned when too many requests are waiting for a worker.
+var errQueueFull = errors.New("too many thumbnail requests in the queue")
+
+// workers limits the number of thumbnails generated concurrently. Requests exceeding the limit wait in a queue of
+// limited size.
+type workers struct {
+	slots    chan struct{}
+	waiting  int32
+	maxQueue int32
+}
+
+func newWorkers(size, maxQueue int) *workers {
+	return &workers{
+		slots:    make(chan struct{}, size),
+		maxQueue: int32(maxQueue),
+	}
+}
+
+// acquire blocks until a worker is free. It fails if the queue is full or the context is done while waiting.
+func (w *workers) acquire(ctx context.Context) error {
+	select {
+	case w.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if atomic.AddInt32(&w.waiting, 1) > w.maxQueue {
+		atomic.AddInt32(&w.waiting, -1)
+		return errQueueFull
+	}
+	defer atomic.AddInt32(&w.waiting, -1)
+
+	select {
+	case w.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a worker acquired before.
+func (w *workers) release() {
+	<-w.slots
+}
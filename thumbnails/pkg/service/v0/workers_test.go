@@ -0,0 +1,38 @@
+package svc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWorkers(t *testing.T) {
+	w := newWorkers(1, 1)
+	if err := w.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second request waits in the queue
+	acquired := make(chan error)
+	go func() {
+		acquired <- w.acquire(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the queue is full
+	if err := w.acquire(context.Background()); err != errQueueFull {
+		t.Errorf("expected errQueueFull got %v", err)
+	}
+
+	w.release()
+	if err := <-acquired; err != nil {
+		t.Errorf("expected the queued request to get a worker got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded got %v", err)
+	}
+	w.release()
+}
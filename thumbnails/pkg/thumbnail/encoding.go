@@ -2,6 +2,7 @@ package thumbnail
 
 import (
 	"image"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
@@ -9,6 +10,7 @@ import (
 )
 
 // Encoder encodes the thumbnail to a specific format.
+// Only the pixels are encoded, thumbnails never contain metadata like the exif data of the source.
 type Encoder interface {
 	// Encode encodes the image to a format.
 	Encode(io.Writer, image.Image) error
@@ -37,11 +39,18 @@ func (e PngEncoder) MimeType() string {
 }
 
 // JpegEncoder encodes to jpg.
-type JpegEncoder struct{}
+type JpegEncoder struct {
+	// Quality ranges from 1 to 100, higher is better. 0 uses jpeg.DefaultQuality.
+	Quality int
+}
 
 // Encode encodes to jpg
 func (e JpegEncoder) Encode(w io.Writer, i image.Image) error {
-	return jpeg.Encode(w, i, nil)
+	quality := e.Quality
+	if quality <= 0 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+	return jpeg.Encode(w, i, &jpeg.Options{Quality: quality})
 }
 
 // Types returns the jpg suffixes.
@@ -54,14 +63,57 @@ func (e JpegEncoder) MimeType() string {
 	return "image/jpeg"
 }
 
+// GifEncoder encodes to gif. Animations are not preserved, the thumbnail only consists of a single frame.
+type GifEncoder struct{}
+
+// Encode encodes to gif format
+func (e GifEncoder) Encode(w io.Writer, i image.Image) error {
+	return gif.Encode(w, i, nil)
+}
+
+// Types returns the gif suffix
+func (e GifEncoder) Types() []string {
+	return []string{"gif"}
+}
+
+// MimeType returns the mimetype for gif files.
+func (e GifEncoder) MimeType() string {
+	return "image/gif"
+}
+
+// EncoderOption defines a single option for the encoders returned by EncoderForType.
+type EncoderOption func(o *EncoderOptions)
+
+// EncoderOptions defines the available options for the encoders.
+type EncoderOptions struct {
+	JpegQuality int
+}
+
+// JpegQuality sets the quality used to encode jpg thumbnails.
+func JpegQuality(val int) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.JpegQuality = val
+	}
+}
+
 // EncoderForType returns the encoder for a given file type
 // or nil if the type is not supported.
-func EncoderForType(fileType string) Encoder {
+// The webp encoder requires cgo, EncoderForType returns nil for webp if the binary was built without it.
+func EncoderForType(fileType string, opts ...EncoderOption) Encoder {
+	options := EncoderOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+
 	switch strings.ToLower(fileType) {
 	case "png":
 		return PngEncoder{}
 	case "jpg", "jpeg":
-		return JpegEncoder{}
+		return JpegEncoder{Quality: options.JpegQuality}
+	case "gif":
+		return GifEncoder{}
+	case "webp":
+		return webpEncoder()
 	default:
 		return nil
 	}
@@ -0,0 +1,9 @@
+//go:build !cgo
+// +build !cgo
+
+package thumbnail
+
+// webpEncoder returns nil because the webp encoder is implemented with cgo.
+func webpEncoder() Encoder {
+	return nil
+}
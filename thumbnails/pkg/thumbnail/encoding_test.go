@@ -1,6 +1,11 @@
 package thumbnail
 
-import "testing"
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
 
 func TestEncoderForType(t *testing.T) {
 	table := map[string]Encoder{
@@ -10,6 +15,10 @@ func TestEncoderForType(t *testing.T) {
 		"JPEG":    JpegEncoder{},
 		"png":     PngEncoder{},
 		"PNG":     PngEncoder{},
+		"gif":     GifEncoder{},
+		"GIF":     GifEncoder{},
+		"webp":    webpEncoder(),
+		"WEBP":    webpEncoder(),
 		"invalid": nil,
 	}
 
@@ -20,3 +29,41 @@ func TestEncoderForType(t *testing.T) {
 		}
 	}
 }
+
+func TestEncoderForTypeJpegQuality(t *testing.T) {
+	e := EncoderForType("jpg", JpegQuality(50))
+	if e != (JpegEncoder{Quality: 50}) {
+		t.Errorf("expected jpg encoder with quality 50 got %v", e)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		for y := 0; y < 16; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 16), G: uint8(y * 16), B: 128, A: 255})
+		}
+	}
+
+	for _, ft := range []string{"png", "jpg", "gif", "webp"} {
+		e := EncoderForType(ft, JpegQuality(80))
+		if e == nil {
+			// webp is not available without cgo
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := e.Encode(buf, img); err != nil {
+			t.Fatalf("could not encode %s: %v", ft, err)
+		}
+		decoded, format, err := image.Decode(buf)
+		if err != nil {
+			t.Fatalf("could not decode %s: %v", ft, err)
+		}
+		if format != e.Types()[0] && !(ft == "jpg" && format == "jpeg") {
+			t.Errorf("expected format %s got %s", ft, format)
+		}
+		if decoded.Bounds() != img.Bounds() {
+			t.Errorf("expected bounds %v got %v", img.Bounds(), decoded.Bounds())
+		}
+	}
+}
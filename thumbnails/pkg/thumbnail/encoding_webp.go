@@ -0,0 +1,33 @@
+//go:build cgo
+// +build cgo
+
+package thumbnail
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// WebpEncoder encodes to webp.
+type WebpEncoder struct{}
+
+// Encode encodes to webp format
+func (e WebpEncoder) Encode(w io.Writer, i image.Image) error {
+	return webp.Encode(w, i, &webp.Options{Quality: webp.DefaulQuality})
+}
+
+// Types returns the webp suffix
+func (e WebpEncoder) Types() []string {
+	return []string{"webp"}
+}
+
+// MimeType returns the mimetype for webp files.
+func (e WebpEncoder) MimeType() string {
+	return "image/webp"
+}
+
+func webpEncoder() Encoder {
+	return WebpEncoder{}
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/pkg/errors"
+)
+
+const (
+	// commandInputPlaceholder is replaced with the path of the source file in the arguments of a command.
+	// Commands without it read the file from stdin.
+	commandInputPlaceholder = "{input}"
+	// commandMaxOutput limits the size of the image a command may write to stdout.
+	commandMaxOutput = 64 * 1024 * 1024
+	// commandMaxStderr limits how much of stderr is kept for the error message.
+	commandMaxStderr = 4 * 1024
+)
+
+// NewCommand parses the command line of an external converter. Images with more than maxPixels written by the
+// converter are rejected, 0 disables the check.
+func NewCommand(command string, timeout time.Duration, maxPixels int64) (Command, error) {
+	args, err := shellquote.Split(command)
+	if err != nil {
+		return Command{}, err
+	}
+	if len(args) == 0 {
+		return Command{}, errors.New("command is empty")
+	}
+	return Command{
+		args:      args,
+		timeout:   timeout,
+		maxPixels: maxPixels,
+	}, nil
+}
+
+// Command generates the image with an external converter like pdftoppm or rsvg-convert. The converter has to write
+// an image in one of the supported formats to stdout.
+//
+// The converter runs in an empty temporary working directory with a minimal environment, it is killed when the
+// timeout is exceeded and its output is limited. Use a wrapper like bwrap or firejail in the command for a stricter
+// sandbox.
+type Command struct {
+	args      []string
+	timeout   time.Duration
+	maxPixels int64
+}
+
+// Generate runs the converter and decodes the image it writes to stdout.
+func (g Command) Generate(ctx context.Context, r io.Reader) (image.Image, error) {
+	dir, err := ioutil.TempDir("", "ocis-thumbnails-converter")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the working directory of the converter")
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input")
+	args := make([]string, len(g.args))
+	var stdin io.Reader = r
+	for i, arg := range g.args {
+		if strings.Contains(arg, commandInputPlaceholder) {
+			arg = strings.Replace(arg, commandInputPlaceholder, input, -1)
+			stdin = nil
+		}
+		args[i] = arg
+	}
+	if stdin == nil {
+		if err := writeFile(input, r); err != nil {
+			return nil, errors.Wrap(err, "could not write the input of the converter")
+		}
+	}
+
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	stdout := &limitedBuffer{limit: commandMaxOutput}
+	stderr := &limitedBuffer{limit: commandMaxStderr, truncate: true}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("converter %s timed out after %s", args[0], g.timeout)
+		}
+		return nil, errors.Wrapf(err, "converter %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	img, err := decode(stdout.Bytes(), g.maxPixels)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode the output of converter %s", args[0])
+	}
+	return img, nil
+}
+
+func writeFile(name string, r io.Reader) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// limitedBuffer fails writes exceeding the limit, or drops the excess if truncate is set.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	truncate bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		if !b.truncate {
+			return 0, fmt.Errorf("output exceeds %d bytes", b.limit)
+		}
+		b.Buffer.Write(p[:b.limit-b.Len()])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
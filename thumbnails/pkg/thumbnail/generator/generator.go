@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime"
+	"strings"
+)
+
+// ErrTooManyPixels is returned for images exceeding the max pixels.
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// Generator generates the image a thumbnail is scaled from out of the content of a file.
+type Generator interface {
+	Generate(ctx context.Context, r io.Reader) (image.Image, error)
+}
+
+// Generators maps mimetypes to the generators which can handle them.
+type Generators map[string]Generator
+
+// New returns the builtin generators for images and text files. Every converter adds or replaces the generator for
+// a mimetype with an external command.
+func New(opts ...Option) (Generators, error) {
+	options := newOptions(opts...)
+	g := Generators{
+		"text/plain":    Text{},
+		"text/markdown": Text{},
+	}
+	for _, mimeType := range imageMimeTypes {
+		g[mimeType] = Image{MaxPixels: options.MaxPixels}
+	}
+
+	for _, c := range options.Converters {
+		parts := strings.SplitN(c, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid converter %s, expected <mimetype>=<command>", c)
+		}
+		cmd, err := NewCommand(strings.TrimSpace(parts[1]), options.ConverterTimeout, options.MaxPixels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid converter %s: %v", c, err)
+		}
+		g[normalizeMimeType(parts[0])] = cmd
+	}
+	return g, nil
+}
+
+// ForMimeType returns the generator for the mimetype or nil if the mimetype is not supported.
+// Generators registered for a wildcard like `image/*` handle all mimetypes of the type.
+func (g Generators) ForMimeType(mimeType string) Generator {
+	mimeType = normalizeMimeType(mimeType)
+	if gen, ok := g[mimeType]; ok {
+		return gen
+	}
+	if i := strings.Index(mimeType, "/"); i > 0 {
+		return g[mimeType[:i]+"/*"]
+	}
+	return nil
+}
+
+// normalizeMimeType removes the parameters like the charset from a mimetype.
+func normalizeMimeType(mimeType string) string {
+	if mt, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// decode decodes an image after checking its size. A limit of 0 or less disables the check.
+func decode(data []byte, maxPixels int64) (image.Image, error) {
+	if maxPixels > 0 {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+			return nil, ErrTooManyPixels
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestForMimeType(t *testing.T) {
+	g, err := New(
+		Converters([]string{"application/pdf=pdftoppm -png {input}", "video/*=ffmpegthumbnailer -i {input} -o -"}),
+		ConverterTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := map[string]string{
+		"image/png":                 "generator.Image",
+		"IMAGE/JPEG":                "generator.Image",
+		"text/plain; charset=utf-8": "generator.Text",
+		"text/markdown":             "generator.Text",
+		"application/pdf":           "generator.Command",
+		"video/mp4":                 "generator.Command",
+		"application/zip":           "<nil>",
+		"":                          "<nil>",
+	}
+	for mimeType, expected := range table {
+		if got := fmt.Sprintf("%T", g.ForMimeType(mimeType)); got != expected {
+			t.Errorf("%s: expected %s got %s", mimeType, expected, got)
+		}
+	}
+}
+
+func TestNewInvalidConverters(t *testing.T) {
+	for _, c := range []string{"application/pdf", "application/pdf=", `application/pdf=pdftoppm "{input}`} {
+		if _, err := New(Converters([]string{c})); err == nil {
+			t.Errorf("%s: expected an error", c)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	img, err := Text{}.Generate(context.Background(), strings.NewReader("# Title\n\n\tsome text ünicode\n"+strings.Repeat("x", 1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, textPreviewSize, textPreviewSize) {
+		t.Fatalf("unexpected bounds %v", img.Bounds())
+	}
+
+	black := 0
+	for y := 0; y < textPreviewSize; y++ {
+		for x := 0; x < textPreviewSize; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r == 0 {
+				black++
+			}
+		}
+	}
+	if black == 0 {
+		t.Error("expected the text to be rendered")
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	table := map[string]string{
+		"abc":        "abc",
+		"\tab":       "    a",
+		"ä\x00b":     "� b",
+		"abcdefghij": "abcde",
+	}
+	for in, expected := range table {
+		if got := printable(in, 5); got != expected {
+			t.Errorf("%q: expected %q got %q", in, expected, got)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range []string{"cat", "cat {input}"} {
+		cmd, err := NewCommand(command, time.Second, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := cmd.Generate(context.Background(), bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if img.Bounds() != src.Bounds() {
+			t.Errorf("%s: expected bounds %v got %v", command, src.Bounds(), img.Bounds())
+		}
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	table := map[string]string{
+		"sleep 5":                     "timed out",
+		"sh -c 'echo no >&2; exit 1'": "no",
+		"echo not an image":           "could not decode",
+	}
+	for command, expected := range table {
+		cmd, err := NewCommand(command, 100*time.Millisecond, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cmd.Generate(context.Background(), strings.NewReader(""))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q got %v", command, expected, err)
+		}
+	}
+}
+
+func TestImageMaxPixels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (Image{MaxPixels: 9999}).Generate(context.Background(), bytes.NewReader(buf.Bytes())); err != ErrTooManyPixels {
+		t.Errorf("expected ErrTooManyPixels got %v", err)
+	}
+	if _, err := (Image{MaxPixels: 10000}).Generate(context.Background(), bytes.NewReader(buf.Bytes())); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+
+	cmd, err := NewCommand("cat", time.Second, 9999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cmd.Generate(context.Background(), bytes.NewReader(buf.Bytes())); errors.Cause(err) != ErrTooManyPixels {
+		t.Errorf("expected ErrTooManyPixels got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"image"
+	"io"
+	"io/ioutil"
+
+	// Register the decoders for the supported image formats. Only the first frame of animated gifs is decoded.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// imageMimeTypes are the mimetypes of the images which can be decoded.
+var imageMimeTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/bmp",
+	"image/x-ms-bmp",
+	"image/tiff",
+	"image/webp",
+}
+
+// Image decodes raster images.
+type Image struct {
+	// MaxPixels rejects bigger images before they are decoded, 0 disables the check.
+	MaxPixels int64
+}
+
+// Generate decodes the image and rotates or flips it according to its exif orientation. Nothing but the pixels is
+// kept, so the metadata of the source never ends up in a thumbnail.
+func (g Image) Generate(ctx context.Context, r io.Reader) (image.Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decode(data, g.MaxPixels)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOrientation(data).Apply(img), nil
+}
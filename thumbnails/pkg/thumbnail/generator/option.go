@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"time"
+)
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for the generators.
+type Options struct {
+	Converters       []string
+	ConverterTimeout time.Duration
+	MaxPixels        int64
+}
+
+// newOptions initializes the available default options.
+func newOptions(opts ...Option) Options {
+	opt := Options{}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Converters provides a function to set the converters option. Converters have the form `<mimetype>=<command>`.
+func Converters(val []string) Option {
+	return func(o *Options) {
+		o.Converters = val
+	}
+}
+
+// ConverterTimeout provides a function to set the converter timeout option.
+func ConverterTimeout(val time.Duration) Option {
+	return func(o *Options) {
+		o.ConverterTimeout = val
+	}
+}
+
+// MaxPixels provides a function to set the max pixels option. Bigger images are rejected before they are decoded.
+func MaxPixels(val int64) Option {
+	return func(o *Options) {
+		o.MaxPixels = val
+	}
+}
@@ -0,0 +1,172 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// Orientation is the exif orientation of an image. It describes how the stored pixels have to be transformed to
+// display the image upright.
+type Orientation int
+
+// The exif orientations, see https://www.exif.org/Exif2-2.PDF
+const (
+	// OrientationNormal needs no transformation.
+	OrientationNormal Orientation = 1
+	// OrientationFlipH is mirrored horizontally.
+	OrientationFlipH Orientation = 2
+	// OrientationRotate180 is rotated by 180°.
+	OrientationRotate180 Orientation = 3
+	// OrientationFlipV is mirrored vertically.
+	OrientationFlipV Orientation = 4
+	// OrientationTranspose is mirrored along the top-left to bottom-right diagonal.
+	OrientationTranspose Orientation = 5
+	// OrientationRotate90 needs to be rotated by 90° clockwise.
+	OrientationRotate90 Orientation = 6
+	// OrientationTransverse is mirrored along the top-right to bottom-left diagonal.
+	OrientationTransverse Orientation = 7
+	// OrientationRotate270 needs to be rotated by 270° clockwise.
+	OrientationRotate270 Orientation = 8
+)
+
+const (
+	exifOrientationTag = 0x0112
+	exifTypeShort      = 3
+)
+
+// ParseOrientation reads the exif orientation of a jpeg or tiff image. OrientationNormal is returned if the image
+// has no or an invalid orientation.
+func ParseOrientation(data []byte) Orientation {
+	var tiff []byte
+	switch {
+	case len(data) > 2 && data[0] == 0xff && data[1] == 0xd8:
+		tiff = jpegExif(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		tiff = data
+	}
+
+	o := tiffOrientation(tiff)
+	if o < OrientationNormal || o > OrientationRotate270 {
+		return OrientationNormal
+	}
+	return o
+}
+
+// jpegExif returns the tiff structure of the exif segment of a jpeg image or nil if there is none.
+func jpegExif(data []byte) []byte {
+	exifHeader := []byte("Exif\x00\x00")
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// fill byte
+			i++
+			continue
+		case marker == 0xd8 || marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			// markers without a payload
+			i += 2
+			continue
+		case marker == 0xda || marker == 0xd9:
+			// start of scan or end of image, the exif segment has to come before
+			return nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xe1 && bytes.HasPrefix(segment, exifHeader) {
+			return segment[len(exifHeader):]
+		}
+		i = end
+	}
+	return nil
+}
+
+// tiffOrientation reads the orientation tag from the first ifd of a tiff structure.
+func tiffOrientation(tiff []byte) Orientation {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		if order.Uint16(tiff[entry+2:entry+4]) != exifTypeShort {
+			return 0
+		}
+		return Orientation(order.Uint16(tiff[entry+8 : entry+10]))
+	}
+	return 0
+}
+
+// Apply transforms the image so that it is displayed upright.
+func (o Orientation) Apply(img image.Image) image.Image {
+	if o <= OrientationNormal || o > OrientationRotate270 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= OrientationTranspose {
+		dw, dh = h, w
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case OrientationFlipH:
+				sx, sy = w-1-x, y
+			case OrientationRotate180:
+				sx, sy = w-1-x, h-1-y
+			case OrientationFlipV:
+				sx, sy = x, h-1-y
+			case OrientationTranspose:
+				sx, sy = y, x
+			case OrientationRotate90:
+				sx, sy = y, h-1-x
+			case OrientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case OrientationRotate270:
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// The samples in testdata/orientation show the same 32x16 image with a red, green, blue and white quadrant (top left
+// to bottom right) when they are displayed upright. The pixels are stored rotated and flipped according to the exif
+// orientation given in the file name.
+func TestOrientationSamples(t *testing.T) {
+	quadrants := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{4, 4, color.RGBA{R: 255, A: 255}},
+		{28, 4, color.RGBA{G: 255, A: 255}},
+		{4, 12, color.RGBA{B: 255, A: 255}},
+		{28, 12, color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+
+	for o := OrientationNormal; o <= OrientationRotate270; o++ {
+		f, err := os.Open(fmt.Sprintf("../../../testdata/orientation/landscape_%d.jpg", o))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := Image{}.Generate(context.Background(), f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("orientation %d: could not generate the image: %v", o, err)
+		}
+		if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 16 {
+			t.Errorf("orientation %d: expected 32x16 got %dx%d", o, img.Bounds().Dx(), img.Bounds().Dy())
+			continue
+		}
+		for _, q := range quadrants {
+			if !similar(img.At(q.x, q.y), q.expected) {
+				t.Errorf("orientation %d: expected %v at %d,%d got %v", o, q.expected, q.x, q.y, img.At(q.x, q.y))
+			}
+		}
+	}
+}
+
+func TestParseOrientation(t *testing.T) {
+	for o := OrientationNormal; o <= OrientationRotate270; o++ {
+		data, err := ioutil.ReadFile(fmt.Sprintf("../../../testdata/orientation/landscape_%d.jpg", o))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ParseOrientation(data); got != o {
+			t.Errorf("expected orientation %d got %d", o, got)
+		}
+	}
+
+	table := map[string][]byte{
+		"empty":           {},
+		"png":             []byte("\x89PNG\r\n\x1a\n"),
+		"jpeg no exif":    {0xff, 0xd8, 0xff, 0xda, 0x00, 0x02},
+		"truncated jpeg":  {0xff, 0xd8, 0xff, 0xe1, 0xff, 0xff, 'E', 'x', 'i', 'f'},
+		"invalid value":   []byte("II*\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x09\x00\x00\x00"),
+		"invalid offset":  []byte("MM\x00*\xff\xff\xff\xff"),
+		"truncated entry": []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12"),
+	}
+	for name, data := range table {
+		if got := ParseOrientation(data); got != OrientationNormal {
+			t.Errorf("%s: expected the normal orientation got %d", name, got)
+		}
+	}
+
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x06\x00\x00\x00")
+	if got := ParseOrientation(tiff); got != OrientationRotate90 {
+		t.Errorf("tiff: expected orientation %d got %d", OrientationRotate90, got)
+	}
+}
+
+func TestApplyKeepsBounds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 10, 14, 12))
+	img.Set(10, 10, color.RGBA{R: 255, A: 255})
+
+	rotated := OrientationRotate90.Apply(img)
+	if rotated.Bounds() != image.Rect(0, 0, 2, 4) {
+		t.Fatalf("expected bounds 2x4 got %v", rotated.Bounds())
+	}
+	// the top left pixel ends up in the top right corner
+	if !similar(rotated.At(1, 0), color.RGBA{R: 255, A: 255}) {
+		t.Errorf("expected red at 1,0 got %v", rotated.At(1, 0))
+	}
+
+	if OrientationNormal.Apply(img) != image.Image(img) {
+		t.Error("expected the normal orientation to return the image unchanged")
+	}
+}
+
+// similar compares colors with a tolerance for jpeg artifacts.
+func similar(a, b color.Color) bool {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	const tolerance = 0x2000
+	diff := func(x, y uint32) bool {
+		if x > y {
+			return x-y > tolerance
+		}
+		return y-x > tolerance
+	}
+	return !diff(ar, br) && !diff(ag, bg) && !diff(ab, bb)
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// textPreviewSize is the width and height of the rendered text snippet.
+	textPreviewSize = 320
+	// textPreviewPadding is the space around the text.
+	textPreviewPadding = 8
+	// textMaxBytes limits how much of a file is read to render the snippet.
+	textMaxBytes = 16 * 1024
+	// textTabWidth is the number of spaces a tab is expanded to.
+	textTabWidth = 4
+)
+
+// Text renders the beginning of a text file like plain text or markdown.
+type Text struct{}
+
+// Generate renders the first lines of the text in a monospace font on a white background. Lines which are too long
+// are cut off.
+func (g Text) Generate(ctx context.Context, r io.Reader) (image.Image, error) {
+	face := basicfont.Face7x13
+	columns := (textPreviewSize - 2*textPreviewPadding) / face.Advance
+	rows := (textPreviewSize - 2*textPreviewPadding) / face.Height
+
+	img := image.NewRGBA(image.Rect(0, 0, textPreviewSize, textPreviewSize))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(r, textMaxBytes))
+	scanner.Buffer(make([]byte, 0, 4096), textMaxBytes)
+	for row := 0; row < rows && scanner.Scan(); row++ {
+		d.Dot = fixed.P(textPreviewPadding, textPreviewPadding+row*face.Height+face.Ascent)
+		d.DrawString(printable(scanner.Text(), columns))
+	}
+	// a truncated last line is no reason to fail
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, err
+	}
+	return img, nil
+}
+
+// printable expands tabs, replaces characters the font can't render and cuts the line after the given columns.
+func printable(line string, columns int) string {
+	var b strings.Builder
+	n := 0
+	for _, c := range line {
+		if n >= columns {
+			break
+		}
+		switch {
+		case c == '\t':
+			for i := 0; i < textTabWidth && n < columns; i++ {
+				b.WriteRune(' ')
+				n++
+			}
+			continue
+		case c > unicode.MaxASCII:
+			c = utf8.RuneError
+		case !unicode.IsPrint(c):
+			c = ' '
+		}
+		b.WriteRune(c)
+		n++
+	}
+	return b.String()
+}
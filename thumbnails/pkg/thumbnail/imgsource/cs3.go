@@ -0,0 +1,120 @@
+package imgsource
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/owncloud/ocis/thumbnails/pkg/config"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// transferTokenHeader is the header the data gateway expects the transfer token in.
+const transferTokenHeader = "X-Reva-Transfer"
+
+// NewCS3Source creates a new cs3 instance.
+func NewCS3Source(cfg config.CS3Source) (CS3, error) {
+	conn, err := grpc.Dial(cfg.GatewayAddress, grpc.WithInsecure())
+	if err != nil {
+		return CS3{}, errors.Wrapf(err, `could not connect to the gateway "%s"`, cfg.GatewayAddress)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: cfg.Insecure}
+	return CS3{
+		namespace: cfg.Namespace,
+		gateway:   gateway.NewGatewayAPIClient(conn),
+		client:    &http.Client{Transport: transport},
+	}, nil
+}
+
+// CS3 implements the Source interface for the reva gateway.
+type CS3 struct {
+	namespace string
+	gateway   gateway.GatewayAPIClient
+	client    *http.Client
+}
+
+// Get downloads the file through the data gateway.
+func (s CS3) Get(ctx context.Context, file string) (io.ReadCloser, error) {
+	ctx, tkn, err := s.outgoingContext(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, `could not get the image "%s"`, file)
+	}
+
+	res, err := s.gateway.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{
+		Ref: s.reference(file),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, `could not initiate the download of the image "%s"`, file)
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, fmt.Errorf("could not initiate the download of the image \"%s\": %s", file, res.Status.Message)
+	}
+
+	var endpoint, transferToken string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			endpoint, transferToken = p.DownloadEndpoint, p.Token
+		}
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("could not get the image \"%s\": no download endpoint available", file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, `could not get the image "%s"`, file)
+	}
+	req.Header.Set(transferTokenHeader, transferToken)
+	req.Header.Set(token.TokenHeader, tkn)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, `could not get the image "%s"`, file)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not get the image \"%s\". Request returned with statuscode %d ", file, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// Stat returns the etag and the mimetype of the current version of the file.
+func (s CS3) Stat(ctx context.Context, file string) (Info, error) {
+	ctx, _, err := s.outgoingContext(ctx)
+	if err != nil {
+		return Info{}, errors.Wrapf(err, `could not stat "%s"`, file)
+	}
+
+	res, err := s.gateway.Stat(ctx, &provider.StatRequest{Ref: s.reference(file)})
+	if err != nil {
+		return Info{}, errors.Wrapf(err, `could not stat "%s"`, file)
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return Info{}, fmt.Errorf("could not stat \"%s\": %s", file, res.Status.Message)
+	}
+	return Info{ETag: res.Info.Etag, MimeType: res.Info.MimeType}, nil
+}
+
+func (s CS3) reference(file string) *provider.Reference {
+	return &provider.Reference{
+		Spec: &provider.Reference_Path{Path: path.Join(s.namespace, file)},
+	}
+}
+
+func (s CS3) outgoingContext(ctx context.Context) (context.Context, string, error) {
+	tkn, ok := ContextGetAccessToken(ctx)
+	if !ok || tkn == "" {
+		return nil, "", errors.New("access token is missing")
+	}
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), tkn, nil
+}
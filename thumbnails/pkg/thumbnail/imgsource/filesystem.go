@@ -2,7 +2,7 @@ package imgsource
 
 import (
 	"context"
-	"image"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -22,18 +22,12 @@ type FileSystem struct {
 	basePath string
 }
 
-// Get retrieves an image from the filesystem.
-func (s FileSystem) Get(ctx context.Context, file string) (image.Image, error) {
+// Get opens a file from the filesystem.
+func (s FileSystem) Get(ctx context.Context, file string) (io.ReadCloser, error) {
 	imgPath := filepath.Join(s.basePath, file)
 	f, err := os.Open(filepath.Clean(imgPath))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to load the file %s from %s", file, imgPath)
 	}
-
-	img, _, err := image.Decode(f)
-	if err != nil {
-		return nil, errors.Wrap(err, "Get: Decode:")
-	}
-
-	return img, nil
+	return f, nil
 }
@@ -2,18 +2,21 @@ package imgsource
 
 import (
 	"context"
-	"image"
+	"errors"
+	"io"
 )
 
 type key int
 
 const (
 	auth key = iota
+	accessToken
 )
 
-// Source defines the interface for image sources
+// Source defines the interface for the sources of the files thumbnails are generated from.
 type Source interface {
-	Get(ctx context.Context, path string) (image.Image, error)
+	// Get returns the content of the file. The caller has to close it.
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
 }
 
 // ContextSetAuthorization puts the authorization in the context.
@@ -29,3 +32,63 @@ func ContextGetAuthorization(ctx context.Context) (string, bool) {
 	}
 	return val.(string), true
 }
+
+// Info describes the current version of a file.
+type Info struct {
+	ETag string
+	// MimeType is empty if the source doesn't know it.
+	MimeType string
+}
+
+// Statter is implemented by sources which can tell the etag and the mimetype of the current version of a file.
+type Statter interface {
+	// Stat returns the info of the current version of the file.
+	Stat(ctx context.Context, path string) (Info, error)
+}
+
+// ContextSetAccessToken puts the reva access token in the context.
+func ContextSetAccessToken(parent context.Context, token string) context.Context {
+	return context.WithValue(parent, accessToken, token)
+}
+
+// ContextGetAccessToken gets the reva access token from the context.
+func ContextGetAccessToken(ctx context.Context) (string, bool) {
+	val := ctx.Value(accessToken)
+	if val == nil {
+		return "", false
+	}
+	return val.(string), true
+}
+
+// ErrTooLarge is returned when reading a source file exceeding the size limit.
+var ErrTooLarge = errors.New("source file is too large")
+
+// LimitReader returns a reader which fails with ErrTooLarge after n bytes were read. A limit of 0 or less disables
+// the check.
+func LimitReader(r io.ReadCloser, n int64) io.ReadCloser {
+	if n <= 0 {
+		return r
+	}
+	return &limitedReader{ReadCloser: r, remaining: n}
+}
+
+type limitedReader struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	// read one byte more than allowed to tell a file of exactly the limit apart from a bigger one
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,34 @@
+package imgsource
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestLimitReader(t *testing.T) {
+	table := []struct {
+		content string
+		limit   int64
+		err     error
+	}{
+		{"0123456789", 0, nil},
+		{"0123456789", 10, nil},
+		{"0123456789", 11, nil},
+		{"0123456789", 9, ErrTooLarge},
+		{"0123456789", 1, ErrTooLarge},
+	}
+	for _, row := range table {
+		r := LimitReader(ioutil.NopCloser(strings.NewReader(row.content)), row.limit)
+		data, err := ioutil.ReadAll(r)
+		if err != row.err {
+			t.Errorf("limit %d: expected error %v got %v", row.limit, row.err, err)
+		}
+		if err == nil && string(data) != row.content {
+			t.Errorf("limit %d: expected %s got %s", row.limit, row.content, data)
+		}
+		if err != nil && int64(len(data)) > row.limit {
+			t.Errorf("limit %d: read %d bytes", row.limit, len(data))
+		}
+	}
+}
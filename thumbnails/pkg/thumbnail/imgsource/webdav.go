@@ -3,11 +3,13 @@ package imgsource
 import (
 	"context"
 	"crypto/tls"
+	"encoding/xml"
 	"fmt"
-	"image"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/owncloud/ocis/thumbnails/pkg/config"
 	"github.com/pkg/errors"
@@ -15,48 +17,103 @@ import (
 
 // NewWebDavSource creates a new webdav instance.
 func NewWebDavSource(cfg config.WebDavSource) WebDav {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: cfg.Insecure}
 	return WebDav{
-		baseURL:  cfg.BaseURL,
-		insecure: cfg.Insecure,
+		baseURL: cfg.BaseURL,
+		client:  &http.Client{Transport: transport},
 	}
 }
 
 // WebDav implements the Source interface for webdav services
 type WebDav struct {
-	baseURL  string
-	insecure bool
+	baseURL string
+	client  *http.Client
 }
 
 // Get downloads the file from a webdav service
-func (s WebDav) Get(ctx context.Context, file string) (image.Image, error) {
-	u, _ := url.Parse(s.baseURL)
-	u.Path = path.Join(u.Path, file)
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+func (s WebDav) Get(ctx context.Context, file string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, file, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, `could not get the image "%s"`, file)
 	}
 
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: s.insecure}
-
-	auth, ok := ContextGetAuthorization(ctx)
-	if !ok {
-		return nil, fmt.Errorf("could not get image \"%s\" error: authorization is missing", file)
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not get the image \"%s\". Request returned with statuscode %d ", file, resp.StatusCode)
 	}
-	req.Header.Add("Authorization", auth)
+	return resp.Body, nil
+}
+
+// propfindBody requests the properties returned by Stat.
+const propfindBody = `<?xml version="1.0" encoding="UTF-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/><d:getcontenttype/></d:prop></d:propfind>`
+
+// multistatus is the part of a PROPFIND response Stat needs. The elements are matched by their local names.
+type multistatus struct {
+	Responses []struct {
+		Propstats []struct {
+			Prop struct {
+				ETag        string `xml:"getetag"`
+				ContentType string `xml:"getcontenttype"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// Stat returns the getetag and getcontenttype properties of the file.
+func (s WebDav) Stat(ctx context.Context, file string) (Info, error) {
+	resp, err := s.do(ctx, "PROPFIND", file, strings.NewReader(propfindBody))
 	if err != nil {
-		return nil, errors.Wrapf(err, `could not get the image "%s"`, file)
+		return Info{}, errors.Wrapf(err, `could not stat "%s"`, file)
 	}
+	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("could not get the image \"%s\". Request returned with statuscode %d ", file, resp.StatusCode)
+	if resp.StatusCode != http.StatusMultiStatus {
+		return Info{}, fmt.Errorf("could not stat \"%s\". Request returned with statuscode %d ", file, resp.StatusCode)
+	}
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return Info{}, errors.Wrapf(err, `could not stat "%s"`, file)
 	}
 
-	img, _, err := image.Decode(resp.Body)
+	var info Info
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstats {
+			// missing properties are listed with a 404 status
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ETag != "" {
+				info.ETag = ps.Prop.ETag
+			}
+			if ps.Prop.ContentType != "" {
+				info.MimeType = ps.Prop.ContentType
+			}
+		}
+	}
+	return info, nil
+}
+
+// do sends a request for the file with the authorization of the context.
+func (s WebDav) do(ctx context.Context, method, file string, body io.Reader) (*http.Response, error) {
+	u, _ := url.Parse(s.baseURL)
+	u.Path = path.Join(u.Path, file)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
-		return nil, errors.Wrapf(err, `could not decode the image "%s"`, file)
+		return nil, err
 	}
-	return img, nil
+
+	auth, ok := ContextGetAuthorization(ctx)
+	if !ok || auth == "" {
+		return nil, errors.New("authorization is missing")
+	}
+	req.Header.Add("Authorization", auth)
+	if method == "PROPFIND" {
+		req.Header.Set("Depth", "0")
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	}
+
+	return s.client.Do(req)
 }
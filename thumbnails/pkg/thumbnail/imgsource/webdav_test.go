@@ -0,0 +1,58 @@
+package imgsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owncloud/ocis/thumbnails/pkg/config"
+)
+
+const propfindResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+  <d:response>
+    <d:href>/remote.php/webdav/file.txt</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"979f4c8db98f7b82e768ef478d3c8612"</d:getetag>
+        <d:getcontenttype>text/plain</d:getcontenttype>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+func TestWebDavStat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PROPFIND" || r.Header.Get("Depth") != "0" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/remote.php/webdav/file.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(propfindResponse))
+	}))
+	defer srv.Close()
+
+	s := NewWebDavSource(config.WebDavSource{BaseURL: srv.URL + "/remote.php/webdav/"})
+	ctx := ContextSetAuthorization(context.Background(), "Bearer token")
+
+	info, err := s.Stat(ctx, "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ETag != `"979f4c8db98f7b82e768ef478d3c8612"` || info.MimeType != "text/plain" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	if _, err := s.Stat(ctx, "missing.txt"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := s.Stat(context.Background(), "file.txt"); err == nil {
+		t.Error("expected an error without authorization")
+	}
+}
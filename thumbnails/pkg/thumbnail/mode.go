@@ -0,0 +1,150 @@
+package thumbnail
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// Mode defines how an image is fitted into the requested resolution.
+type Mode string
+
+const (
+	// ModeFit scales the image to fit into the resolution, keeping the aspect ratio.
+	ModeFit Mode = "fit"
+	// ModeFill scales the image to cover the resolution and crops the center.
+	ModeFill Mode = "fill"
+	// ModeSmart scales the image to cover the resolution and crops the part with the highest entropy.
+	ModeSmart Mode = "smart"
+	// ModeExact scales the image to the resolution, ignoring the aspect ratio.
+	ModeExact Mode = "exact"
+)
+
+// Resampling defines the filter used to scale an image.
+type Resampling string
+
+const (
+	// ResamplingApproxBiLinear is fast, but produces lower quality thumbnails.
+	ResamplingApproxBiLinear Resampling = "approx_bilinear"
+	// ResamplingCatmullRom is slower, but produces sharper thumbnails.
+	ResamplingCatmullRom Resampling = "catmull_rom"
+	// ResamplingLanczos is the slowest, but produces the sharpest thumbnails.
+	ResamplingLanczos Resampling = "lanczos"
+)
+
+// lanczos is the Lanczos3 kernel.
+var lanczos = &draw.Kernel{
+	Support: 3,
+	At: func(t float64) float64 {
+		if t == 0 {
+			return 1
+		}
+		if t <= -3 || t >= 3 {
+			return 0
+		}
+		pt := math.Pi * t
+		return 3 * math.Sin(pt) * math.Sin(pt/3) / (pt * pt)
+	},
+}
+
+// scaler returns the scaler for the resampling, unknown resamplings use draw.ApproxBiLinear.
+func (r Resampling) scaler() draw.Scaler {
+	switch r {
+	case ResamplingCatmullRom:
+		return draw.CatmullRom
+	case ResamplingLanczos:
+		return lanczos
+	default:
+		return draw.ApproxBiLinear
+	}
+}
+
+// smartCropSampleSize is the size the image is scaled down to before searching the crop with the highest entropy.
+const smartCropSampleSize = 128
+
+// fitRatio returns the biggest rectangle with the aspect ratio of ratio which fits into bounds.
+func fitRatio(ratio image.Rectangle, bounds image.Rectangle) image.Rectangle {
+	if ratio.Dx() <= 0 || ratio.Dy() <= 0 {
+		return image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	}
+	w := bounds.Dx()
+	h := int(math.Round(float64(w) * float64(ratio.Dy()) / float64(ratio.Dx())))
+	if h > bounds.Dy() {
+		h = bounds.Dy()
+		w = int(math.Round(float64(h) * float64(ratio.Dx()) / float64(ratio.Dy())))
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return image.Rect(0, 0, w, h)
+}
+
+// centerCrop returns the centered part of bounds with the aspect ratio of target.
+func centerCrop(bounds image.Rectangle, target image.Rectangle) image.Rectangle {
+	crop := fitRatio(target, bounds)
+	offset := image.Pt((bounds.Dx()-crop.Dx())/2, (bounds.Dy()-crop.Dy())/2)
+	return crop.Add(bounds.Min).Add(offset)
+}
+
+// smartCrop returns the part of the image with the aspect ratio of target which has the highest entropy. The crop
+// only moves along the axis which is cut off.
+func smartCrop(img image.Image, target image.Rectangle) image.Rectangle {
+	bounds := img.Bounds()
+	crop := fitRatio(target, bounds)
+	if crop.Dx() == bounds.Dx() && crop.Dy() == bounds.Dy() {
+		return bounds
+	}
+
+	// the entropy is calculated on a small grayscale version of the image to keep it fast
+	scale := math.Min(1, float64(smartCropSampleSize)/math.Max(float64(bounds.Dx()), float64(bounds.Dy())))
+	sample := image.NewGray(image.Rect(0, 0, int(math.Max(1, float64(bounds.Dx())*scale)), int(math.Max(1, float64(bounds.Dy())*scale))))
+	draw.ApproxBiLinear.Scale(sample, sample.Bounds(), img, bounds, draw.Src, nil)
+	window := image.Rect(0, 0,
+		int(math.Max(1, math.Min(float64(sample.Rect.Dx()), float64(crop.Dx())*scale))),
+		int(math.Max(1, math.Min(float64(sample.Rect.Dy()), float64(crop.Dy())*scale))),
+	)
+
+	best, bestEntropy := image.Point{}, -1.0
+	for y := 0; y+window.Dy() <= sample.Rect.Dy(); y++ {
+		for x := 0; x+window.Dx() <= sample.Rect.Dx(); x++ {
+			if e := entropy(sample, window.Add(image.Pt(x, y))); e > bestEntropy {
+				best, bestEntropy = image.Pt(x, y), e
+			}
+		}
+	}
+
+	offset := image.Pt(int(float64(best.X)/scale), int(float64(best.Y)/scale))
+	// rounding must not move the crop out of the image
+	if offset.X+crop.Dx() > bounds.Dx() {
+		offset.X = bounds.Dx() - crop.Dx()
+	}
+	if offset.Y+crop.Dy() > bounds.Dy() {
+		offset.Y = bounds.Dy() - crop.Dy()
+	}
+	return crop.Add(bounds.Min).Add(offset)
+}
+
+// entropy calculates the shannon entropy of the luminance histogram of a part of a grayscale image.
+func entropy(img *image.Gray, r image.Rectangle) float64 {
+	var histogram [256]int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			histogram[img.GrayAt(x, y).Y]++
+		}
+	}
+
+	total := float64(r.Dx() * r.Dy())
+	e := 0.0
+	for _, n := range histogram {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / total
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -0,0 +1,86 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+)
+
+func TestFitRatio(t *testing.T) {
+	table := []struct {
+		ratio, bounds, expected image.Rectangle
+	}{
+		{image.Rect(0, 0, 32, 32), image.Rect(0, 0, 1920, 1080), image.Rect(0, 0, 1080, 1080)},
+		{image.Rect(0, 0, 32, 32), image.Rect(0, 0, 1080, 1920), image.Rect(0, 0, 1080, 1080)},
+		{image.Rect(0, 0, 16, 9), image.Rect(0, 0, 1000, 1000), image.Rect(0, 0, 1000, 563)},
+		{image.Rect(0, 0, 0, 0), image.Rect(0, 0, 10, 20), image.Rect(0, 0, 10, 20)},
+	}
+	for _, row := range table {
+		if got := fitRatio(row.ratio, row.bounds); got != row.expected {
+			t.Errorf("fitRatio(%v, %v): expected %v got %v", row.ratio, row.bounds, row.expected, got)
+		}
+	}
+}
+
+func TestCenterCrop(t *testing.T) {
+	got := centerCrop(image.Rect(10, 10, 110, 60), image.Rect(0, 0, 32, 32))
+	if expected := image.Rect(35, 10, 85, 60); got != expected {
+		t.Errorf("expected %v got %v", expected, got)
+	}
+}
+
+func TestSmartCrop(t *testing.T) {
+	// a flat image with noise on the right side
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+			if x >= 280 {
+				v := uint8(rnd.Intn(256))
+				c = color.RGBA{R: v, G: v, B: v, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	crop := smartCrop(img, image.Rect(0, 0, 32, 32))
+	if crop.Dx() != 100 || crop.Dy() != 100 {
+		t.Fatalf("expected a 100x100 crop got %v", crop)
+	}
+	if crop.Min.X < 280 {
+		t.Errorf("expected the crop to contain the noise got %v", crop)
+	}
+}
+
+func TestGenerateModes(t *testing.T) {
+	sut := NewSimpleManager(Resolutions{}, NoOpManager{}, log.NewLogger())
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	req := image.Rect(0, 0, 64, 64)
+
+	table := map[Mode]image.Rectangle{
+		"":        image.Rect(0, 0, 64, 32),
+		ModeFit:   image.Rect(0, 0, 64, 32),
+		ModeFill:  image.Rect(0, 0, 64, 64),
+		ModeSmart: image.Rect(0, 0, 64, 64),
+		ModeExact: image.Rect(0, 0, 64, 64),
+	}
+	for mode, expected := range table {
+		for _, resampling := range []Resampling{"", ResamplingApproxBiLinear, ResamplingCatmullRom, ResamplingLanczos} {
+			r := Request{Resolution: req, Mode: mode, Resampling: resampling}
+			got := sut.generate(r, sut.resolutions.ClosestMatch(req, img.Bounds()), img)
+			if got.Bounds() != expected {
+				t.Errorf("mode %s, resampling %s: expected %v got %v", mode, resampling, expected, got.Bounds())
+			}
+		}
+	}
+}
+
+func TestLanczos(t *testing.T) {
+	if lanczos.At(0) != 1 || lanczos.At(3) != 0 || lanczos.At(-4) != 0 {
+		t.Error("unexpected lanczos kernel values")
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/owncloud/ocis/ocis-pkg/log"
 	"github.com/owncloud/ocis/thumbnails/pkg/config"
@@ -16,23 +18,31 @@ import (
 )
 
 const (
-	usersDir = "users"
-	filesDir = "files"
+	usersDir   = "users"
+	filesDir   = "files"
+	gcLockFile = "gc.lock"
+
+	// accessResolution limits how often the access time of a thumbnail is updated
+	accessResolution = time.Minute
 )
 
 // NewFileSystemStorage creates a new instanz of FileSystem
 func NewFileSystemStorage(cfg config.FileSystemStorage, logger log.Logger) *FileSystem {
 	return &FileSystem{
-		root:   cfg.RootDirectory,
-		logger: logger,
+		root:    cfg.RootDirectory,
+		maxSize: cfg.MaxSize,
+		maxAge:  time.Duration(cfg.MaxAge) * time.Second,
+		logger:  logger,
 	}
 }
 
 // FileSystem represents a storage for the thumbnails using the local file system.
 type FileSystem struct {
-	root   string
-	logger log.Logger
-	mux    sync.Mutex
+	root    string
+	maxSize int64
+	maxAge  time.Duration
+	logger  log.Logger
+	mux     sync.Mutex
 }
 
 // Get loads the image from the file system.
@@ -44,6 +54,7 @@ func (s *FileSystem) Get(username string, key string) []byte {
 		s.logger.Debug().Str("err", err.Error()).Str("key", key).Msg("could not load thumbnail from store")
 		return nil
 	}
+	s.touch(img)
 	return content
 }
 
@@ -63,15 +74,22 @@ func (s *FileSystem) Set(username string, key string, img []byte) error {
 // BuildKey generate the unique key for a thumbnail.
 // The key is structure as follows:
 //
-// <first two letters of etag>/<next two letters of etag>/<rest of etag>/<width>x<height>.<filetype>
+// <first two letters of etag>/<next two letters of etag>/<rest of etag>/<width>x<height>[-<mode>][-<resampling>].<filetype>
 //
-// e.g. 97/9f/4c8db98f7b82e768ef478d3c8612/500x300.png
+// e.g. 97/9f/4c8db98f7b82e768ef478d3c8612/500x300.png or 97/9f/4c8db98f7b82e768ef478d3c8612/500x300-fill-lanczos.png
 //
 // The key also represents the path to the thumbnail in the filesystem under the configured root directory.
 func (s *FileSystem) BuildKey(r Request) string {
 	etag := r.ETag
 	filetype := r.Types[0]
-	filename := strconv.Itoa(r.Resolution.Dx()) + "x" + strconv.Itoa(r.Resolution.Dy()) + "." + filetype
+	filename := strconv.Itoa(r.Resolution.Dx()) + "x" + strconv.Itoa(r.Resolution.Dy())
+	if r.Mode != "" {
+		filename += "-" + r.Mode
+	}
+	if r.Resampling != "" {
+		filename += "-" + r.Resampling
+	}
+	filename += "." + filetype
 
 	return filepath.Join(etag[:2], etag[2:4], etag[4:], filename)
 }
@@ -106,6 +124,137 @@ func (s *FileSystem) storeImage(key string, img []byte) (string, error) {
 	return imgPath, nil
 }
 
+// touch records the access of a thumbnail. The modification time is used as access time because many file systems
+// are mounted with noatime.
+func (s *FileSystem) touch(img string) {
+	now := time.Now()
+	info, err := os.Stat(img)
+	if err != nil || now.Sub(info.ModTime()) < accessResolution {
+		return
+	}
+	if err := os.Chtimes(img, now, now); err != nil {
+		s.logger.Debug().Err(err).Str("path", img).Msg("could not update thumbnail access time")
+	}
+}
+
+// GC removes thumbnails which were not accessed for longer than the max age. If the remaining thumbnails exceed the
+// max size the least recently used ones are removed. Afterwards empty directories and user links to removed
+// thumbnails are cleaned up. ErrGCRunning is returned if another garbage collection is running on the storage.
+func (s *FileSystem) GC(now time.Time) (GCResult, error) {
+	res := GCResult{}
+
+	if err := os.MkdirAll(s.root, 0700); err != nil {
+		return res, errors.Wrapf(err, "error while creating directory %s", s.root)
+	}
+	unlock, err := lockGC(filepath.Join(s.root, gcLockFile))
+	if err != nil {
+		return res, err
+	}
+	defer unlock()
+
+	type thumbnail struct {
+		path   string
+		size   int64
+		access time.Time
+	}
+	var thumbnails []thumbnail
+	var dirs []string
+
+	filesRoot := filepath.Join(s.root, filesDir)
+	err = filepath.Walk(filesRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if path != filesRoot {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		thumbnails = append(thumbnails, thumbnail{path: path, size: info.Size(), access: info.ModTime()})
+		res.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "could not list thumbnails")
+	}
+
+	// least recently used first
+	sort.Slice(thumbnails, func(i, j int) bool {
+		return thumbnails[i].access.Before(thumbnails[j].access)
+	})
+
+	for _, t := range thumbnails {
+		expired := s.maxAge > 0 && now.Sub(t.access) > s.maxAge
+		tooBig := s.maxSize > 0 && res.Bytes > s.maxSize
+		if !expired && !tooBig {
+			res.Files++
+			continue
+		}
+		if err := s.remove(t.path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn().Err(err).Str("path", t.path).Msg("could not remove thumbnail")
+			res.Files++
+			continue
+		}
+		res.Removed++
+		res.RemovedBytes += t.size
+		res.Bytes -= t.size
+	}
+
+	s.mux.Lock()
+	// deepest directories first, removing directories which are not empty fails
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, d := range dirs {
+		_ = os.Remove(d)
+	}
+	s.mux.Unlock()
+
+	res.DanglingLinks, err = s.removeDanglingLinks()
+	return res, err
+}
+
+// remove removes a thumbnail while no thumbnail is stored or linked.
+func (s *FileSystem) remove(path string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return os.Remove(path)
+}
+
+// removeDanglingLinks removes the links in the user directories which point to removed thumbnail directories.
+func (s *FileSystem) removeDanglingLinks() (int, error) {
+	removed := 0
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	err := filepath.Walk(filepath.Join(s.root, usersDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.Remove(path); err != nil {
+				s.logger.Warn().Err(err).Str("path", path).Msg("could not remove dangling link")
+				return nil
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, errors.Wrap(err, "could not clean up user directories")
+	}
+	return removed, nil
+}
+
 // userDir returns the path to the user directory.
 // The username is hashed before appending it on the path to prevent bugs caused by invalid folder names.
 // Also the hash is then splitted up in three parts that results in a path which looks as follows:
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/thumbnails/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFileSystem(t *testing.T, maxSize int64, maxAge int) (*FileSystem, func()) {
+	root, err := ioutil.TempDir("", "thumbnails-storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewFileSystemStorage(config.FileSystemStorage{RootDirectory: root, MaxSize: maxSize, MaxAge: maxAge}, log.NewLogger())
+	return s, func() { os.RemoveAll(root) }
+}
+
+func storeTestThumbnail(t *testing.T, s *FileSystem, username, etag string, size int, access time.Time) string {
+	key := s.BuildKey(Request{ETag: etag, Types: []string{"png"}, Resolution: image.Rect(0, 0, 32, 32)})
+	if err := s.Set(username, key, make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(s.root, filesDir, key), access, access); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestFileSystemGCMaxAge(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 0, 3600)
+	defer cleanup()
+
+	now := time.Now()
+	old := storeTestThumbnail(t, s, "einstein", "1872ade88f3013edeb33decd74a4f947", 10, now.Add(-2*time.Hour))
+	recent := storeTestThumbnail(t, s, "einstein", "33a64df551425fcc55e4d42a148795d9", 20, now.Add(-time.Minute))
+
+	res, err := s.GC(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Removed)
+	assert.EqualValues(t, 10, res.RemovedBytes)
+	assert.Equal(t, 1, res.Files)
+	assert.EqualValues(t, 20, res.Bytes)
+	// the link of the user to the removed thumbnail directory is dangling
+	assert.Equal(t, 1, res.DanglingLinks)
+
+	assert.Nil(t, s.Get("einstein", old))
+	assert.NotNil(t, s.Get("einstein", recent))
+
+	_, err = os.Stat(filepath.Join(s.root, filesDir, "18"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileSystemGCMaxSize(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 25, 0)
+	defer cleanup()
+
+	now := time.Now()
+	lru := storeTestThumbnail(t, s, "einstein", "1872ade88f3013edeb33decd74a4f947", 10, now.Add(-2*time.Hour))
+	mru := storeTestThumbnail(t, s, "marie", "33a64df551425fcc55e4d42a148795d9", 20, now.Add(-time.Hour))
+
+	res, err := s.GC(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Removed)
+	assert.EqualValues(t, 20, res.Bytes)
+
+	assert.Nil(t, s.Get("einstein", lru))
+	assert.NotNil(t, s.Get("marie", mru))
+}
+
+func TestFileSystemGetUpdatesAccessTime(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 0, 3600)
+	defer cleanup()
+
+	now := time.Now()
+	key := storeTestThumbnail(t, s, "einstein", "1872ade88f3013edeb33decd74a4f947", 10, now.Add(-2*time.Hour))
+	assert.NotNil(t, s.Get("einstein", key))
+
+	res, err := s.GC(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Removed)
+	assert.Equal(t, 1, res.Files)
+}
+
+func TestFileSystemGCEmptyStorage(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 10, 10)
+	defer cleanup()
+
+	res, err := s.GC(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, GCResult{}, res)
+}
+
+func TestFileSystemGCRunsOnce(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 10, 10)
+	defer cleanup()
+
+	unlock, err := lockGC(filepath.Join(s.root, gcLockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.GC(time.Now())
+	assert.Equal(t, ErrGCRunning, err)
+
+	unlock()
+	_, err = s.GC(time.Now())
+	assert.NoError(t, err)
+}
+
+func TestFileSystemBuildKey(t *testing.T) {
+	s, cleanup := newTestFileSystem(t, 0, 0)
+	defer cleanup()
+
+	r := Request{ETag: "979f4c8db98f7b82e768ef478d3c8612", Types: []string{"png"}, Resolution: image.Rect(0, 0, 500, 300)}
+	assert.Equal(t, filepath.Join("97", "9f", "4c8db98f7b82e768ef478d3c8612", "500x300.png"), s.BuildKey(r))
+
+	r.Mode = "fill"
+	r.Resampling = "lanczos"
+	assert.Equal(t, filepath.Join("97", "9f", "4c8db98f7b82e768ef478d3c8612", "500x300-fill-lanczos.png"), s.BuildKey(r))
+}
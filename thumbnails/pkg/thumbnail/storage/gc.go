@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	"github.com/owncloud/ocis/thumbnails/pkg/metrics"
+)
+
+// GCResult summarizes a garbage collection run.
+type GCResult struct {
+	// Removed is the number of removed thumbnails
+	Removed int
+	// RemovedBytes is the size of the removed thumbnails
+	RemovedBytes int64
+	// DanglingLinks is the number of removed user links to thumbnails that no longer exist
+	DanglingLinks int
+	// Files is the number of remaining thumbnails
+	Files int
+	// Bytes is the size of the remaining thumbnails
+	Bytes int64
+}
+
+// ErrGCRunning is returned if another garbage collection is already running on the storage.
+var ErrGCRunning = errors.New("garbage collection is already running")
+
+// GarbageCollector is implemented by storages which can evict thumbnails.
+type GarbageCollector interface {
+	GC(now time.Time) (GCResult, error)
+}
+
+// NewJanitor creates a janitor which periodically runs the garbage collection of a storage.
+func NewJanitor(gc GarbageCollector, interval time.Duration, logger log.Logger, m *metrics.Metrics) Janitor {
+	return Janitor{
+		gc:       gc,
+		interval: interval,
+		logger:   logger,
+		metrics:  m,
+	}
+}
+
+// Janitor periodically runs the garbage collection of a storage.
+type Janitor struct {
+	gc       GarbageCollector
+	interval time.Duration
+	logger   log.Logger
+	metrics  *metrics.Metrics
+}
+
+// Run collects garbage until the context is done.
+func (j Janitor) Run(ctx context.Context) {
+	if j.interval <= 0 {
+		return
+	}
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+
+	j.collect()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			j.collect()
+		}
+	}
+}
+
+func (j Janitor) collect() {
+	res, err := j.gc.GC(time.Now())
+	if err == ErrGCRunning {
+		j.logger.Info().Msg("skipping thumbnail garbage collection, another one is running")
+		return
+	}
+	if err != nil {
+		j.logger.Error().Err(err).Msg("thumbnail garbage collection failed")
+	}
+	if j.metrics != nil {
+		j.metrics.CacheSize.WithLabelValues().Set(float64(res.Bytes))
+		j.metrics.CacheEvictions.WithLabelValues().Add(float64(res.Removed))
+	}
+	j.logger.Debug().
+		Int("removed", res.Removed).
+		Int64("removed_bytes", res.RemovedBytes).
+		Int("dangling_links", res.DanglingLinks).
+		Int("files", res.Files).
+		Int64("bytes", res.Bytes).
+		Msg("thumbnail garbage collection finished")
+}
@@ -0,0 +1,30 @@
+// +build !windows
+
+package storage
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lockGC takes an advisory lock on the lock file, so only one garbage collection runs on the storage at a time,
+// whether it is run by the service or the gc command. The lock is released by the kernel when the process exits.
+func lockGC(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open gc lock file")
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrGCRunning
+		}
+		return nil, errors.Wrap(err, "could not lock gc lock file")
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,25 @@
+// +build windows
+
+package storage
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// lockGC creates the lock file exclusively, so only one garbage collection runs on the storage at a time. Unlike
+// on other platforms the lock file is left behind if the process is killed and has to be removed manually.
+func lockGC(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrGCRunning
+		}
+		return nil, errors.Wrap(err, "could not create gc lock file")
+	}
+	f.Close()
+	return func() {
+		_ = os.Remove(path)
+	}, nil
+}
@@ -1,46 +1,85 @@
 package storage
 
 import (
+	"container/list"
 	"strings"
+	"sync"
 )
 
-// NewInMemoryStorage creates a new InMemory instance.
-func NewInMemoryStorage() InMemory {
-	return InMemory{
-		store: make(map[string]map[string][]byte),
+// NewInMemoryStorage creates a new InMemory instance. The least recently used thumbnails are evicted when the total
+// size exceeds maxSize bytes, a maxSize of 0 disables the limit.
+func NewInMemoryStorage(maxSize int64) *InMemory {
+	return &InMemory{
+		store:   make(map[string]map[string]*list.Element),
+		lru:     list.New(),
+		maxSize: maxSize,
 	}
 }
 
 // InMemory represents an in memory storage for thumbnails
 // Can be used during development
 type InMemory struct {
-	store map[string]map[string][]byte
+	store   map[string]map[string]*list.Element
+	lru     *list.List
+	size    int64
+	maxSize int64
+	mux     sync.Mutex
+}
+
+type inMemoryEntry struct {
+	username  string
+	key       string
+	thumbnail []byte
 }
 
 // Get loads the thumbnail from memory.
-func (s InMemory) Get(username string, key string) []byte {
-	userImages := s.store[username]
-	if userImages == nil {
+func (s *InMemory) Get(username string, key string) []byte {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	e, ok := s.store[username][key]
+	if !ok {
 		return nil
 	}
-	return s.store[username][key]
+	s.lru.MoveToFront(e)
+	return e.Value.(*inMemoryEntry).thumbnail
 }
 
 // Set stores the thumbnail in memory.
-func (s InMemory) Set(username string, key string, thumbnail []byte) error {
+func (s *InMemory) Set(username string, key string, thumbnail []byte) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
 	if _, ok := s.store[username]; !ok {
-		s.store[username] = make(map[string][]byte)
+		s.store[username] = make(map[string]*list.Element)
+	}
+	if e, ok := s.store[username][key]; ok {
+		s.remove(e)
+	}
+	s.store[username][key] = s.lru.PushFront(&inMemoryEntry{username: username, key: key, thumbnail: thumbnail})
+	s.size += int64(len(thumbnail))
+
+	for s.maxSize > 0 && s.size > s.maxSize {
+		s.remove(s.lru.Back())
 	}
-	s.store[username][key] = thumbnail
 	return nil
 }
 
+func (s *InMemory) remove(e *list.Element) {
+	entry := s.lru.Remove(e).(*inMemoryEntry)
+	s.size -= int64(len(entry.thumbnail))
+	delete(s.store[entry.username], entry.key)
+	if len(s.store[entry.username]) == 0 {
+		delete(s.store, entry.username)
+	}
+}
+
 // BuildKey generates a unique key to store and retrieve the thumbnail.
-func (s InMemory) BuildKey(r Request) string {
+func (s *InMemory) BuildKey(r Request) string {
 	parts := []string{
 		r.ETag,
 		r.Resolution.String(),
 		strings.Join(r.Types, ","),
+		r.Mode,
+		r.Resampling,
 	}
 	return strings.Join(parts, "+")
 }
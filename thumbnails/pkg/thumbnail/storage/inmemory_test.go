@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewInMemoryStorage(30)
+
+	assert.NoError(t, s.Set("einstein", "a", make([]byte, 10)))
+	assert.NoError(t, s.Set("einstein", "b", make([]byte, 10)))
+	assert.NoError(t, s.Set("marie", "c", make([]byte, 10)))
+
+	// a is now the most recently used thumbnail
+	assert.NotNil(t, s.Get("einstein", "a"))
+
+	assert.NoError(t, s.Set("marie", "d", make([]byte, 10)))
+	assert.Nil(t, s.Get("einstein", "b"))
+	assert.NotNil(t, s.Get("einstein", "a"))
+	assert.NotNil(t, s.Get("marie", "c"))
+	assert.NotNil(t, s.Get("marie", "d"))
+	assert.EqualValues(t, 30, s.size)
+
+	// replacing a thumbnail doesn't count it twice
+	assert.NoError(t, s.Set("marie", "d", make([]byte, 5)))
+	assert.EqualValues(t, 25, s.size)
+}
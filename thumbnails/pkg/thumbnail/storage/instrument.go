@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"github.com/owncloud/ocis/thumbnails/pkg/metrics"
+)
+
+// NewInstrument returns a storage that records cache hits and misses.
+func NewInstrument(next Storage, m *metrics.Metrics) Storage {
+	return instrument{
+		next:    next,
+		metrics: m,
+	}
+}
+
+type instrument struct {
+	next    Storage
+	metrics *metrics.Metrics
+}
+
+// Get implements the Storage interface.
+func (i instrument) Get(username string, key string) []byte {
+	img := i.next.Get(username, key)
+	if img == nil {
+		i.metrics.CacheMisses.WithLabelValues().Inc()
+	} else {
+		i.metrics.CacheHits.WithLabelValues().Inc()
+	}
+	return img
+}
+
+// Set implements the Storage interface.
+func (i instrument) Set(username string, key string, img []byte) error {
+	return i.next.Set(username, key, img)
+}
+
+// BuildKey implements the Storage interface.
+func (i instrument) BuildKey(r Request) string {
+	return i.next.BuildKey(r)
+}
@@ -9,6 +9,9 @@ type Request struct {
 	ETag       string
 	Types      []string
 	Resolution image.Rectangle
+	// Mode and Resampling are empty for the defaults.
+	Mode       string
+	Resampling string
 }
 
 // Storage defines the interface for a thumbnail store.
@@ -15,6 +15,10 @@ type Request struct {
 	Encoder    Encoder
 	ETag       string
 	Username   string
+	// Mode defaults to ModeFit.
+	Mode Mode
+	// Resampling defaults to ResamplingApproxBiLinear.
+	Resampling Resampling
 }
 
 // Manager is responsible for generating thumbnails
@@ -45,7 +49,7 @@ type SimpleManager struct {
 // Get implements the Get Method of Manager
 func (s SimpleManager) Get(r Request, img image.Image) ([]byte, error) {
 	match := s.resolutions.ClosestMatch(r.Resolution, img.Bounds())
-	thumbnail := s.generate(match, img)
+	thumbnail := s.generate(r, match, img)
 
 	key := s.storage.BuildKey(mapToStorageRequest(r))
 
@@ -70,10 +74,27 @@ func (s SimpleManager) GetStored(r Request) []byte {
 	return stored
 }
 
-func (s SimpleManager) generate(r image.Rectangle, img image.Image) image.Image {
-	targetResolution := mapRatio(img.Bounds(), r)
+// generate scales the image into the matched resolution according to the mode of the request. Except for ModeFit
+// the thumbnail has the aspect ratio of the requested resolution.
+func (s SimpleManager) generate(r Request, match image.Rectangle, img image.Image) image.Image {
+	src := img.Bounds()
+	var targetResolution image.Rectangle
+	switch r.Mode {
+	case ModeFill, ModeSmart, ModeExact:
+		targetResolution = fitRatio(r.Resolution, match)
+	default:
+		targetResolution = mapRatio(src, match)
+	}
+
+	switch r.Mode {
+	case ModeFill:
+		src = centerCrop(src, targetResolution)
+	case ModeSmart:
+		src = smartCrop(img, targetResolution)
+	}
+
 	thumbnail := image.NewRGBA(targetResolution)
-	draw.ApproxBiLinear.Scale(thumbnail, targetResolution, img, img.Bounds(), draw.Over, nil)
+	r.Resampling.scaler().Scale(thumbnail, targetResolution, img, src, draw.Over, nil)
 	return thumbnail
 }
 
@@ -83,5 +104,12 @@ func mapToStorageRequest(r Request) storage.Request {
 		Resolution: r.Resolution,
 		Types:      r.Encoder.Types(),
 	}
+	// the defaults are omitted to keep the keys of thumbnails stored before modes were introduced
+	if r.Mode != "" && r.Mode != ModeFit {
+		sR.Mode = string(r.Mode)
+	}
+	if r.Resampling != "" && r.Resampling != ResamplingApproxBiLinear {
+		sR.Resampling = string(r.Resampling)
+	}
 	return sR
 }
@@ -1,6 +1,7 @@
 package thumbnail
 
 import (
+	"bytes"
 	"image"
 	"os"
 	"path/filepath"
@@ -22,6 +23,39 @@ func (m NoOpManager) Set(username, key string, thumbnail []byte) error {
 	return nil
 }
 
+func TestGetStripsMetadata(t *testing.T) {
+	sut := NewSimpleManager(
+		Resolutions{},
+		NoOpManager{},
+		log.NewLogger(),
+	)
+
+	f, err := os.Open("../../testdata/orientation/landscape_6.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ft := range []string{"jpg", "png", "gif", "webp"} {
+		e := EncoderForType(ft)
+		if e == nil {
+			continue
+		}
+		res, _ := ParseResolution("16x16")
+		thumbnail, err := sut.Get(Request{Resolution: res, Encoder: e}, img)
+		if err != nil {
+			t.Fatalf("%s: %v", ft, err)
+		}
+		if bytes.Contains(thumbnail, []byte("Exif")) {
+			t.Errorf("%s: expected the thumbnail to contain no exif metadata", ft)
+		}
+	}
+}
+
 func BenchmarkGet(b *testing.B) {
 
 	sut := NewSimpleManager(
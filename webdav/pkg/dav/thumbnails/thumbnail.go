@@ -1,9 +1,13 @@
 package thumbnail
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,19 +21,42 @@ const (
 	DefaultHeight = 32
 )
 
+// mimeTypes maps the mimetypes the thumbnails service can encode to the file types of the thumbnails.
+var mimeTypes = map[string]string{
+	"image/webp": "webp",
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+}
+
+// modes are the supported values of the mode parameter, an empty mode uses the default.
+var modes = map[string]struct{}{"": {}, "fit": {}, "fill": {}, "smart": {}, "exact": {}}
+
+// resamplings are the supported values of the resampling parameter, an empty resampling uses the default.
+var resamplings = map[string]struct{}{"": {}, "approx_bilinear": {}, "catmull_rom": {}, "lanczos": {}}
+
 // Request combines all parameters provided when requesting a thumbnail
 type Request struct {
-	Filepath      string
-	Filetype      string
+	Filepath string
+	// Filetype is the file type the thumbnail is encoded to.
+	Filetype string
+	// MimeType is the mimetype of the source file as known by the client. The thumbnails service only uses it if the
+	// storage doesn't know the mimetype.
+	MimeType      string
 	Etag          string
 	Width         int
 	Height        int
 	Authorization string
+	// AccessToken is the reva token minted by the proxy.
+	AccessToken string
+	// Mode defines how the image is fitted into the resolution, one of fit, fill, smart or exact.
+	Mode string
+	// Resampling is the filter used to scale the image, one of approx_bilinear, catmull_rom or lanczos.
+	Resampling string
 }
 
 // NewRequest extracts all required parameters from a http request.
 func NewRequest(r *http.Request) (Request, error) {
-	path := extractFilePath(r)
 	query := r.URL.Query()
 	width, err := strconv.Atoi(query.Get("x"))
 	if err != nil {
@@ -45,18 +72,164 @@ func NewRequest(r *http.Request) (Request, error) {
 		return Request{}, fmt.Errorf("c (etag) is missing in query")
 	}
 
-	authorization := r.Header.Get("Authorization")
+	tr, err := newRequest(r)
+	if err != nil {
+		return Request{}, err
+	}
+	tr.setFile(extractFilePath(r), r.Header.Get("Accept"), "")
+	tr.Etag = etag
+	tr.Width = width
+	tr.Height = height
+	return tr, nil
+}
+
+// BatchFile identifies one thumbnail of a batch request.
+type BatchFile struct {
+	// Path of the file relative to the folder of the request.
+	Path   string `json:"path"`
+	Etag   string `json:"etag"`
+	Width  int    `json:"x"`
+	Height int    `json:"y"`
+	// Mimetype is the getcontenttype property of the file, it is optional.
+	Mimetype string `json:"mimetype,omitempty"`
+}
+
+// BatchRequest is the body of a request for the thumbnails of many files.
+type BatchRequest struct {
+	Files []BatchFile `json:"files"`
+}
+
+// maxBatchRequestSize limits the size of the body of a batch request in bytes.
+const maxBatchRequestSize = 1 << 20
 
-	tr := Request{
-		Filepath:      path,
-		Filetype:      strings.Replace(filepath.Ext(path), ".", "", 1),
-		Etag:          etag,
-		Width:         width,
-		Height:        height,
-		Authorization: authorization,
+// NewBatchRequest extracts the thumbnails requested by the body of a http request. The query parameters mode and
+// resampling and the accept header apply to all files.
+func NewBatchRequest(r *http.Request) (BatchRequest, []Request, error) {
+	var br BatchRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBatchRequestSize)).Decode(&br); err != nil {
+		return BatchRequest{}, nil, fmt.Errorf("could not decode the batch request: %v", err)
 	}
 
-	return tr, nil
+	tr, err := newRequest(r)
+	if err != nil {
+		return BatchRequest{}, nil, err
+	}
+	folder := extractFilePath(r)
+	trs := make([]Request, 0, len(br.Files))
+	for _, f := range br.Files {
+		if strings.TrimSpace(f.Etag) == "" {
+			return BatchRequest{}, nil, fmt.Errorf("etag of %s is missing", f.Path)
+		}
+		ftr := tr
+		// the files can't be outside of the folder
+		ftr.setFile(path.Join(folder, path.Clean("/"+f.Path)), r.Header.Get("Accept"), f.Mimetype)
+		ftr.Etag = f.Etag
+		ftr.Width = f.Width
+		if ftr.Width <= 0 {
+			ftr.Width = DefaultWidth
+		}
+		ftr.Height = f.Height
+		if ftr.Height <= 0 {
+			ftr.Height = DefaultHeight
+		}
+		trs = append(trs, ftr)
+	}
+	return br, trs, nil
+}
+
+// newRequest extracts the parameters which don't depend on the file from a http request.
+func newRequest(r *http.Request) (Request, error) {
+	query := r.URL.Query()
+	mode := normalizeEnum(query.Get("mode"))
+	if _, ok := modes[mode]; !ok {
+		return Request{}, fmt.Errorf("mode %s is invalid, expected one of fit, fill, smart or exact", query.Get("mode"))
+	}
+	resampling := normalizeEnum(query.Get("resampling"))
+	if _, ok := resamplings[resampling]; !ok {
+		return Request{}, fmt.Errorf("resampling %s is invalid, expected one of approx_bilinear, catmull_rom or lanczos", query.Get("resampling"))
+	}
+
+	return Request{
+		Authorization: r.Header.Get("Authorization"),
+		AccessToken:   r.Header.Get("x-access-token"),
+		Mode:          mode,
+		Resampling:    resampling,
+	}, nil
+}
+
+// setFile sets the path and the mimetype of the file and the file type of the thumbnail.
+func (tr *Request) setFile(p, accept, mimeType string) {
+	tr.Filepath = p
+	tr.Filetype = negotiateFiletype(accept, filepath.Ext(p))
+	tr.MimeType = mimeType
+}
+
+// normalizeEnum lower cases the value of a parameter and accepts dashes instead of underscores.
+func normalizeEnum(val string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(val)), "-", "_", -1)
+}
+
+// negotiateFiletype picks the file type of the thumbnail from the accept header. If the client accepts any image
+// the file type of the source is kept when possible, everything else is encoded to png.
+func negotiateFiletype(accept, ext string) string {
+	fallback := strings.ToLower(strings.TrimPrefix(ext, "."))
+	switch fallback {
+	case "jpeg":
+		fallback = "jpg"
+	case "png", "jpg", "gif", "webp":
+	default:
+		fallback = "png"
+	}
+
+	for _, mimeType := range parseAccept(accept) {
+		if mimeType == "*/*" || mimeType == "image/*" {
+			return fallback
+		}
+		if ft, ok := mimeTypes[mimeType]; ok {
+			return ft
+		}
+	}
+	return fallback
+}
+
+// parseAccept returns the accepted mimetypes ordered by their quality. Mimetypes with a quality of 0 are omitted.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		mimeType string
+		q        float64
+	}
+
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{
+			mimeType: strings.ToLower(strings.TrimSpace(params[0])),
+			q:        1,
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+				mr.q = q
+			}
+		}
+		if mr.mimeType == "" || mr.q <= 0 {
+			continue
+		}
+		ranges = append(ranges, mr)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	accepted := make([]string, 0, len(ranges))
+	for _, mr := range ranges {
+		accepted = append(accepted, mr.mimeType)
+	}
+	return accepted
 }
 
 // the url looks as followed
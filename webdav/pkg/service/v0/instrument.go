@@ -28,3 +28,8 @@ func (i instrument) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (i instrument) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	i.next.Thumbnail(w, r)
 }
+
+// Thumbnails implements the Service interface.
+func (i instrument) Thumbnails(w http.ResponseWriter, r *http.Request) {
+	i.next.Thumbnails(w, r)
+}
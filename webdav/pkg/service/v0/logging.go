@@ -28,3 +28,8 @@ func (l logging) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (l logging) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	l.next.Thumbnail(w, r)
 }
+
+// Thumbnails implements the Service interface.
+func (l logging) Thumbnails(w http.ResponseWriter, r *http.Request) {
+	l.next.Thumbnails(w, r)
+}
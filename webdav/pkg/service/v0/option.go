@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/owncloud/ocis/ocis-pkg/log"
+	thumbnails "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
 	"github.com/owncloud/ocis/webdav/pkg/config"
 )
 
@@ -15,6 +16,8 @@ type Options struct {
 	Logger     log.Logger
 	Config     *config.Config
 	Middleware []func(http.Handler) http.Handler
+	// ThumbnailsClient defaults to a client of the thumbnails service
+	ThumbnailsClient thumbnails.ThumbnailService
 }
 
 // newOptions initializes the available default options.
@@ -48,3 +51,10 @@ func Middleware(val ...func(http.Handler) http.Handler) Option {
 		o.Middleware = val
 	}
 }
+
+// ThumbnailsClient provides a function to set the thumbnails client option.
+func ThumbnailsClient(val thumbnails.ThumbnailService) Option {
+	return func(o *Options) {
+		o.ThumbnailsClient = val
+	}
+}
@@ -1,11 +1,15 @@
 package svc
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/micro/go-micro/v2/client"
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/log"
 	thumbnails "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
 	"github.com/owncloud/ocis/webdav/pkg/config"
 	thumbnail "github.com/owncloud/ocis/webdav/pkg/dav/thumbnails"
@@ -15,6 +19,7 @@ import (
 type Service interface {
 	ServeHTTP(http.ResponseWriter, *http.Request)
 	Thumbnail(http.ResponseWriter, *http.Request)
+	Thumbnails(http.ResponseWriter, *http.Request)
 }
 
 // NewService returns a service implementation for Service.
@@ -24,13 +29,22 @@ func NewService(opts ...Option) Service {
 	m := chi.NewMux()
 	m.Use(options.Middleware...)
 
+	thumbnailsClient := options.ThumbnailsClient
+	if thumbnailsClient == nil {
+		thumbnailsClient = thumbnails.NewThumbnailService("com.owncloud.api.thumbnails", client.DefaultClient)
+	}
+
 	svc := Webdav{
-		config: options.Config,
-		mux:    m,
+		config:           options.Config,
+		log:              options.Logger,
+		mux:              m,
+		thumbnailsClient: thumbnailsClient,
 	}
 
 	m.Route(options.Config.HTTP.Root, func(r chi.Router) {
 		r.Get("/remote.php/dav/files/{user}/*", svc.Thumbnail)
+		r.Post("/remote.php/dav/files/{user}", svc.Thumbnails)
+		r.Post("/remote.php/dav/files/{user}/*", svc.Thumbnails)
 	})
 
 	return svc
@@ -38,8 +52,10 @@ func NewService(opts ...Option) Service {
 
 // Webdav defines implements the business logic for Service.
 type Webdav struct {
-	config *config.Config
-	mux    *chi.Mux
+	config           *config.Config
+	log              log.Logger
+	mux              *chi.Mux
+	thumbnailsClient thumbnails.ThumbnailService
 }
 
 // ServeHTTP implements the Service interface.
@@ -56,15 +72,7 @@ func (g Webdav) Thumbnail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := thumbnails.NewThumbnailService("com.owncloud.api.thumbnails", client.DefaultClient)
-	rsp, err := c.GetThumbnail(r.Context(), &thumbnails.GetRequest{
-		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
-		Filetype:      extensionToFiletype(tr.Filetype),
-		Etag:          tr.Etag,
-		Width:         int32(tr.Width),
-		Height:        int32(tr.Height),
-		Authorization: tr.Authorization,
-	})
+	rsp, err := g.thumbnailsClient.GetThumbnail(r.Context(), getRequest(tr))
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(err.Error()))
@@ -77,10 +85,120 @@ func (g Webdav) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", rsp.GetMimetype())
+	// the encoding of the thumbnail depends on the accept header of the request
+	w.Header().Set("Vary", "Accept")
 	w.WriteHeader(http.StatusOK)
 	w.Write(rsp.Thumbnail)
 }
 
+// Thumbnails implements the Service interface. The thumbnails are streamed as a json array in the order they are
+// ready, the index of an entry refers to the requested file. If the batch fails after the first thumbnail was sent,
+// the array ends with an entry with the index -1 and the error.
+func (g Webdav) Thumbnails(w http.ResponseWriter, r *http.Request) {
+	br, trs, err := thumbnail.NewBatchRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	req := &thumbnails.GetThumbnailsRequest{
+		Authorization: r.Header.Get("Authorization"),
+		AccessToken:   r.Header.Get("x-access-token"),
+	}
+	for _, tr := range trs {
+		req.Requests = append(req.Requests, getRequest(tr))
+	}
+
+	stream, err := g.thumbnailsClient.GetThumbnails(r.Context(), req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	defer stream.Close()
+
+	// errors of the whole batch are returned with the first response
+	rsp, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		status := int(merrors.Parse(err.Error()).Code)
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("["))
+	enc := json.NewEncoder(w)
+	for i := 0; err == nil; i++ {
+		if i > 0 {
+			w.Write([]byte(","))
+		}
+		_ = enc.Encode(batchResponse(br, rsp))
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		rsp, err = stream.Recv()
+	}
+	if err != io.EOF {
+		// the status is already sent, a trailing entry without a file tells the client that the result is incomplete
+		g.log.Error().Err(err).Int("files", len(br.Files)).Msg("thumbnail batch failed")
+		status := int(merrors.Parse(err.Error()).Code)
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		w.Write([]byte(","))
+		_ = enc.Encode(BatchResponse{Index: -1, Status: status, Error: err.Error()})
+	}
+	w.Write([]byte("]"))
+}
+
+// BatchResponse is one thumbnail of the response to a batch request.
+type BatchResponse struct {
+	// Index of the file in the request, -1 for the error of an incomplete batch.
+	Index int `json:"index"`
+	// Path of the file as requested.
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+	Mimetype  string `json:"mimetype,omitempty"`
+	Thumbnail []byte `json:"thumbnail,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func batchResponse(br thumbnail.BatchRequest, rsp *thumbnails.GetThumbnailsResponse) BatchResponse {
+	res := BatchResponse{
+		Index:     int(rsp.Index),
+		Status:    int(rsp.Status),
+		Mimetype:  rsp.Mimetype,
+		Thumbnail: rsp.Thumbnail,
+		Error:     rsp.Error,
+	}
+	if res.Index >= 0 && res.Index < len(br.Files) {
+		res.Path = br.Files[res.Index].Path
+	}
+	return res
+}
+
+func getRequest(tr thumbnail.Request) *thumbnails.GetRequest {
+	return &thumbnails.GetRequest{
+		Filepath:      strings.TrimLeft(tr.Filepath, "/"),
+		Filetype:      extensionToFiletype(tr.Filetype),
+		Etag:          tr.Etag,
+		Width:         int32(tr.Width),
+		Height:        int32(tr.Height),
+		Authorization: tr.Authorization,
+		AccessToken:   tr.AccessToken,
+		Mimetype:      tr.MimeType,
+		Mode:          thumbnails.GetRequest_Mode(thumbnails.GetRequest_Mode_value[strings.ToUpper(tr.Mode)]),
+		Resampling:    thumbnails.GetRequest_Resampling(thumbnails.GetRequest_Resampling_value[strings.ToUpper(tr.Resampling)]),
+	}
+}
+
 func extensionToFiletype(ext string) thumbnails.GetRequest_FileType {
 	val, ok := thumbnails.GetRequest_FileType_value[strings.ToUpper(ext)]
 	if !ok {
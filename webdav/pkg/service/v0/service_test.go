@@ -0,0 +1,98 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/micro/go-micro/v2/client"
+	merrors "github.com/micro/go-micro/v2/errors"
+	"github.com/owncloud/ocis/ocis-pkg/log"
+	thumbnails "github.com/owncloud/ocis/thumbnails/pkg/proto/v0"
+	"github.com/owncloud/ocis/webdav/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// thumbnailsMock streams the given responses and then fails with err, io.EOF for a complete batch.
+type thumbnailsMock struct {
+	thumbnails.ThumbnailService
+	responses []*thumbnails.GetThumbnailsResponse
+	err       error
+}
+
+func (m thumbnailsMock) GetThumbnails(ctx context.Context, in *thumbnails.GetThumbnailsRequest, opts ...client.CallOption) (thumbnails.ThumbnailService_GetThumbnailsService, error) {
+	return &streamMock{responses: m.responses, err: m.err}, nil
+}
+
+type streamMock struct {
+	thumbnails.ThumbnailService_GetThumbnailsService
+	responses []*thumbnails.GetThumbnailsResponse
+	err       error
+}
+
+func (s *streamMock) Recv() (*thumbnails.GetThumbnailsResponse, error) {
+	if len(s.responses) == 0 {
+		return nil, s.err
+	}
+	rsp := s.responses[0]
+	s.responses = s.responses[1:]
+	return rsp, nil
+}
+
+func (s *streamMock) Close() error {
+	return nil
+}
+
+func batch(t *testing.T, m thumbnailsMock) []BatchResponse {
+	cfg := config.New()
+	cfg.HTTP.Root = "/"
+	svc := NewService(Logger(log.NewLogger()), Config(cfg), ThumbnailsClient(m))
+
+	body := `{"files":[{"path":"a.png","etag":"1"},{"path":"b.png","etag":"2"},{"path":"c.png","etag":"3"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/remote.php/dav/files/einstein/folder?preview=1", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	svc.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var res []BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("the response is no valid json: %v", err)
+	}
+	return res
+}
+
+func TestThumbnails(t *testing.T) {
+	res := batch(t, thumbnailsMock{
+		responses: []*thumbnails.GetThumbnailsResponse{
+			{Index: 1, Status: http.StatusOK, Mimetype: "image/png", Thumbnail: []byte("b")},
+			{Index: 0, Status: http.StatusOK, Mimetype: "image/png", Thumbnail: []byte("a")},
+			{Index: 2, Status: http.StatusNotFound, Error: "unsupported mimetype"},
+		},
+		err: io.EOF,
+	})
+
+	assert.Len(t, res, 3)
+	assert.Equal(t, BatchResponse{Index: 1, Path: "b.png", Status: http.StatusOK, Mimetype: "image/png", Thumbnail: []byte("b")}, res[0])
+	assert.Equal(t, "a.png", res[1].Path)
+	assert.Equal(t, http.StatusNotFound, res[2].Status)
+}
+
+func TestThumbnailsFailingMidBatch(t *testing.T) {
+	res := batch(t, thumbnailsMock{
+		responses: []*thumbnails.GetThumbnailsResponse{
+			{Index: 1, Status: http.StatusOK, Mimetype: "image/png", Thumbnail: []byte("b")},
+		},
+		err: merrors.Timeout("com.owncloud.api.thumbnails", "stream timed out"),
+	})
+
+	// the result ends with an error entry, so the client knows it is incomplete
+	assert.Len(t, res, 2)
+	assert.Equal(t, "b.png", res[0].Path)
+	assert.Equal(t, -1, res[1].Index)
+	assert.Equal(t, http.StatusRequestTimeout, res[1].Status)
+	assert.Contains(t, res[1].Error, "stream timed out")
+}
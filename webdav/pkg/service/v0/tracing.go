@@ -24,3 +24,8 @@ func (t tracing) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (t tracing) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	t.next.Thumbnail(w, r)
 }
+
+// Thumbnails implements the Service interface.
+func (t tracing) Thumbnails(w http.ResponseWriter, r *http.Request) {
+	t.next.Thumbnails(w, r)
+}